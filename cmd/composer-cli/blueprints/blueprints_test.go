@@ -0,0 +1,29 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+)
+
+// executeCmd runs the command with the mock server and returns its stdout and stderr
+func executeCmd(t *testing.T, server func(request *http.Request) (*http.Response, error), args ...string) (string, string, error) {
+	root.SetupCmdTest(server)
+	cmd, out, err := root.ExecuteTest(args...)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, cmd)
+	stdout, rerr := ioutil.ReadAll(out.Stdout)
+	require.Nil(t, rerr)
+	stderr, rerr := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, rerr)
+	return string(stdout), string(stderr), err
+}
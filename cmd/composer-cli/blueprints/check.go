@@ -0,0 +1,235 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	checkCmd = &cobra.Command{
+		Use:   "check BLUEPRINT|FILE ...",
+		Short: "Check that the blueprint's packages are available",
+		Long: `Check that the packages and modules in a blueprint, or a blueprint file, are available
+
+Each entry is looked up in the repositories for the blueprint's distro and its
+version glob is matched against the available builds. Missing entries are listed
+with similar package names, and entries that match more than one package or
+version are listed as ambiguous. Groups cannot be looked up on the server and are
+not checked.`,
+		RunE: check,
+		Args: cobra.MinimumNArgs(1),
+	}
+)
+
+func init() {
+	blueprintsCmd.AddCommand(checkCmd)
+}
+
+// loadBlueprint reads the blueprint from a file, or from the server if there is no file
+func loadBlueprint(arg string) (weldr.Blueprint, error) {
+	if _, err := os.Stat(arg); err == nil {
+		data, err := ioutil.ReadFile(arg)
+		if err != nil {
+			return weldr.Blueprint{}, err
+		}
+		return weldr.NewBlueprint(string(data), root.FormatFromFilename(arg))
+	}
+
+	blueprints, errors, err := root.Client.GetBlueprintsJSON([]string{arg})
+	if err != nil {
+		return weldr.Blueprint{}, err
+	}
+	if len(errors) > 0 {
+		return weldr.Blueprint{}, fmt.Errorf("%s: %s", errors[0].ID, errors[0].Msg)
+	}
+	if len(blueprints) != 1 {
+		return weldr.Blueprint{}, fmt.Errorf("missing blueprint %s", arg)
+	}
+	data, err := json.Marshal(blueprints[0])
+	if err != nil {
+		return weldr.Blueprint{}, err
+	}
+	return weldr.NewBlueprintFromJSON(string(data))
+}
+
+// versionMatches returns true if the version glob matches the build
+// Like the depsolver the glob can match the version, or the version and release.
+func versionMatches(glob string, b weldr.ProjectBuildV0) bool {
+	if glob == "" || glob == "*" {
+		return true
+	}
+	for _, v := range []string{b.Source.Version, fmt.Sprintf("%s-%s", b.Source.Version, b.Release), b.EVR()} {
+		if ok, _ := path.Match(glob, v); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// projectVersions returns the versions of the builds that match the glob, oldest first
+func projectVersions(glob string, p weldr.ProjectV0) []string {
+	builds := append([]weldr.ProjectBuildV0{}, p.Builds...)
+	weldr.SortBuilds(builds)
+	seen := make(map[string]bool)
+	var versions []string
+	for _, b := range builds {
+		v := b.EVR()
+		if versionMatches(glob, b) && !seen[v] {
+			seen[v] = true
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// blueprintChecker checks the blueprint entries against the projects on the server
+type blueprintChecker struct {
+	distro   string
+	allNames []string
+	missing  bool
+}
+
+// projectNames returns the names of all the projects, they are only listed once
+func (c *blueprintChecker) projectNames() []string {
+	if c.allNames != nil {
+		return c.allNames
+	}
+	c.allNames = []string{}
+	projects, resp, err := root.Client.ListProjects(c.distro)
+	if err != nil || resp != nil {
+		return c.allNames
+	}
+	for _, p := range projects {
+		c.allNames = append(c.allNames, p.Name)
+	}
+	return c.allNames
+}
+
+// checkEntry returns a line describing whether the entry is available
+func (c *blueprintChecker) checkEntry(kind string, e weldr.Package, projects []weldr.ProjectV0) string {
+	glob := e.Version
+	if glob == "" {
+		glob = "*"
+	}
+	prefix := fmt.Sprintf("%s %s (%s):", kind, e.Name, glob)
+
+	var matched []weldr.ProjectV0
+	for _, p := range projects {
+		if ok, _ := path.Match(e.Name, p.Name); ok {
+			matched = append(matched, p)
+		}
+	}
+	if len(matched) == 0 {
+		c.missing = true
+		suggestions := root.SuggestNames(e.Name, c.projectNames(), 3)
+		if len(suggestions) == 0 {
+			return prefix + " missing"
+		}
+		return fmt.Sprintf("%s missing, did you mean %s?", prefix, strings.Join(suggestions, ", "))
+	}
+
+	if len(matched) > 1 {
+		var names []string
+		for _, p := range matched {
+			names = append(names, p.Name)
+		}
+		return fmt.Sprintf("%s ambiguous, matches %s", prefix, strings.Join(names, ", "))
+	}
+
+	versions := projectVersions(glob, matched[0])
+	if len(versions) == 0 {
+		c.missing = true
+		return fmt.Sprintf("%s missing, no build matches the version, available: %s",
+			prefix, strings.Join(projectVersions("*", matched[0]), ", "))
+	}
+	if len(versions) > 1 && glob != "*" {
+		return fmt.Sprintf("%s ambiguous, matches %s", prefix, strings.Join(versions, ", "))
+	}
+	return fmt.Sprintf("%s ok, %s", prefix, strings.Join(versions, ", "))
+}
+
+// lookup returns the projects or modules for the entries
+// Entries that are not found are left out. No results at all is not an error.
+func lookup(entries []weldr.Package, distro string, info func([]string, string) ([]weldr.ProjectV0, *weldr.APIResponse, error)) ([]weldr.ProjectV0, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	projects, resp, err := info(names, distro)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil && !resp.Status {
+		for _, e := range resp.Errors {
+			if e.ID != "UnknownProject" && e.ID != "UnknownModule" {
+				return nil, fmt.Errorf("%s: %s", e.ID, e.Msg)
+			}
+		}
+	}
+	return projects, nil
+}
+
+// checkBlueprint prints the availability of the blueprint's entries
+// It returns true if any of them are missing.
+func checkBlueprint(bp weldr.Blueprint) (bool, error) {
+	c := blueprintChecker{distro: bp.Distro}
+	projects, err := lookup(bp.Packages, bp.Distro, root.Client.ProjectsInfo)
+	if err != nil {
+		return false, err
+	}
+	modules, err := lookup(bp.Modules, bp.Distro, root.Client.ModulesInfo)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range bp.Packages {
+		fmt.Println(c.checkEntry("package", p, projects))
+	}
+	for _, m := range bp.Modules {
+		fmt.Println(c.checkEntry("module", m, modules))
+	}
+	for _, g := range bp.Groups {
+		fmt.Printf("group %s: not checked\n", g.Name)
+	}
+	return c.missing, nil
+}
+
+func check(cmd *cobra.Command, args []string) (rcErr error) {
+	for _, arg := range args {
+		bp, err := loadBlueprint(arg)
+		if err != nil {
+			rcErr = root.ExecutionError(cmd, "Check Error: %s: %s", arg, err)
+			continue
+		}
+		if len(args) > 1 {
+			fmt.Printf("%s:\n", arg)
+		}
+		missing, err := checkBlueprint(bp)
+		if err != nil {
+			rcErr = root.ExecutionError(cmd, "Check Error: %s: %s", arg, err)
+			continue
+		}
+		if missing {
+			rcErr = root.ExecutionError(cmd, "")
+		}
+	}
+
+	// If there were any errors, even if other blueprints succeeded, it returns an error
+	return rcErr
+}
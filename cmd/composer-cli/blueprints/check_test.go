@@ -0,0 +1,162 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+func testProject(name string, versions ...string) weldr.ProjectV0 {
+	p := weldr.ProjectV0{Name: name}
+	for _, v := range versions {
+		vr := strings.SplitN(v, "-", 2)
+		p.Builds = append(p.Builds, weldr.ProjectBuildV0{
+			Arch:    "x86_64",
+			Release: vr[1],
+			Source:  weldr.ProjectSourceV0{Version: vr[0]},
+		})
+	}
+	return p
+}
+
+var checkTestProjects = []weldr.ProjectV0{
+	testProject("bash", "5.1.8-1.fc34", "5.1.0-2.fc34"),
+	testProject("nginx", "1.20.1-2.fc34"),
+	testProject("nodejs", "14.17.0-1.fc34"),
+	testProject("tmux", "3.2a-1.fc34"),
+	testProject("vim-enhanced", "8.2.3318-1.fc34"),
+	testProject("vim-minimal", "8.2.3318-1.fc34"),
+}
+
+const checkTestBlueprint = `{"blueprints": [{
+	"name": "check-bp",
+	"distro": "fedora-34",
+	"packages": [
+		{"name": "bash", "version": "5.1.*"},
+		{"name": "tmux", "version": "3.2a"},
+		{"name": "vim-enchanced", "version": "*"},
+		{"name": "nginx", "version": "9.*"},
+		{"name": "vim-*"}
+	],
+	"modules": [{"name": "nodejs", "version": "14.*"}],
+	"groups": [{"name": "core"}]
+}], "changes": [], "errors": []}`
+
+func checkTestServer(request *http.Request) (*http.Response, error) {
+	path := strings.TrimPrefix(request.URL.Path, "/api/v1")
+	var body interface{}
+	switch {
+	case path == "/blueprints/info/check-bp":
+		body = json.RawMessage(checkTestBlueprint)
+	case strings.HasPrefix(path, "/projects/info/"), strings.HasPrefix(path, "/modules/info/"):
+		if request.URL.Query().Get("distro") != "fedora-34" {
+			return nil, fmt.Errorf("wrong distro: %s", request.URL.RawQuery)
+		}
+		names := strings.Split(path[strings.LastIndex(path, "/")+1:], ",")
+		var found []weldr.ProjectV0
+		for _, p := range checkTestProjects {
+			for _, n := range names {
+				if n == p.Name || (strings.HasSuffix(n, "*") && strings.HasPrefix(p.Name, strings.TrimSuffix(n, "*"))) {
+					found = append(found, p)
+					break
+				}
+			}
+		}
+		if strings.HasPrefix(path, "/projects") {
+			body = map[string]interface{}{"projects": found}
+		} else {
+			body = map[string]interface{}{"modules": found}
+		}
+	case path == "/projects/list":
+		body = weldr.ProjectsListV0{Total: uint(len(checkTestProjects)), Limit: uint(len(checkTestProjects)), Projects: checkTestProjects}
+	default:
+		return &http.Response{
+			Request:    request,
+			StatusCode: 400,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"status": false, "errors": [{"id": "UnknownBlueprint", "msg": "unknown-bp: "}]}`))),
+		}, nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewReader(data)),
+	}, nil
+}
+
+func runCheck(t *testing.T, args ...string) (string, string, error) {
+	return executeCmd(t, checkTestServer, append([]string{"blueprints", "check"}, args...)...)
+}
+
+func TestCmdBlueprintsCheck(t *testing.T) {
+	stdout, stderr, err := runCheck(t, "check-bp")
+	require.NotNil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, `package bash (5.1.*): ambiguous, matches 5.1.0-2.fc34, 5.1.8-1.fc34
+package tmux (3.2a): ok, 3.2a-1.fc34
+package vim-enchanced (*): missing, did you mean vim-enhanced?
+package nginx (9.*): missing, no build matches the version, available: 1.20.1-2.fc34
+package vim-* (*): ambiguous, matches vim-enhanced, vim-minimal
+module nodejs (14.*): ok, 14.17.0-1.fc34
+group core: not checked
+`, stdout)
+}
+
+func TestCmdBlueprintsCheckFile(t *testing.T) {
+	tmpBp, err := ioutil.TempFile("", "test-bp-*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBp.Name())
+	_, err = tmpBp.Write([]byte(`name = "check-file"
+distro = "fedora-34"
+
+[[packages]]
+name = "tmux"
+version = "3.*"
+
+[[packages]]
+name = "bash"
+version = "5.1.8-1.fc34"
+`))
+	require.Nil(t, err)
+	tmpBp.Close()
+
+	stdout, stderr, err := runCheck(t, tmpBp.Name())
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, `package tmux (3.*): ok, 3.2a-1.fc34
+package bash (5.1.8-1.fc34): ok, 5.1.8-1.fc34
+`, stdout)
+}
+
+func TestCmdBlueprintsCheckUnknown(t *testing.T) {
+	stdout, stderr, err := runCheck(t, "unknown-bp")
+	require.NotNil(t, err)
+	assert.Equal(t, "", stdout)
+	assert.Equal(t, "ERROR: Check Error: unknown-bp: UnknownBlueprint: unknown-bp: \n", stderr)
+}
+
+func TestVersionMatches(t *testing.T) {
+	b := weldr.ProjectBuildV0{Epoch: 2, Release: "1.fc34", Source: weldr.ProjectSourceV0{Version: "8.2.3318"}}
+	assert.True(t, versionMatches("", b))
+	assert.True(t, versionMatches("8.2.*", b))
+	assert.True(t, versionMatches("8.2.3318-1.fc34", b))
+	assert.True(t, versionMatches("2:8.2.3318-1.*", b))
+	assert.False(t, versionMatches("8.1.*", b))
+	assert.Equal(t, "2:8.2.3318-1.fc34", b.EVR())
+}
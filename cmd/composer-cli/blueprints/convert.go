@@ -0,0 +1,86 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	convertCmd = &cobra.Command{
+		Use:   "convert [FILE] [--from toml|json|yaml] --to toml|json|yaml",
+		Short: "Convert a blueprint file between TOML, JSON, and YAML",
+		Long: `Convert a blueprint file between TOML, JSON, and YAML and write it to stdout
+
+The blueprint is read from stdin if FILE is - or is not given. The input format
+defaults to the file's extension.`,
+		RunE: convert,
+		Args: cobra.MaximumNArgs(1),
+	}
+	convertFrom string
+	convertTo   string
+)
+
+func init() {
+	convertCmd.Flags().StringVarP(&convertFrom, "from", "", "", "Format of the input, toml, json, or yaml")
+	convertCmd.Flags().StringVarP(&convertTo, "to", "", "toml", "Format of the output, toml, json, or yaml")
+	blueprintsCmd.AddCommand(convertCmd)
+}
+
+// checkFormat returns an error if the format is not one of the blueprint formats
+func checkFormat(format string) error {
+	if !isStringInList(weldr.BlueprintFormats, format) {
+		return fmt.Errorf("unknown format %s, it should be one of: %s", format, strings.Join(weldr.BlueprintFormats, ", "))
+	}
+	return nil
+}
+
+func convert(cmd *cobra.Command, args []string) error {
+	filename := "-"
+	if len(args) > 0 {
+		filename = args[0]
+	}
+	from := convertFrom
+	if len(from) == 0 {
+		if filename == "-" {
+			return root.ExecutionError(cmd, "Convert Error: --from is required when reading from stdin")
+		}
+		from = root.FormatFromFilename(filename)
+	}
+	for _, f := range []string{from, convertTo} {
+		if err := checkFormat(f); err != nil {
+			return root.ExecutionError(cmd, "Convert Error: %s", err)
+		}
+	}
+
+	var data []byte
+	var err error
+	if filename == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		return root.ExecutionError(cmd, "Convert Error: %s", err)
+	}
+	bp, err := weldr.NewBlueprint(string(data), from)
+	if err != nil {
+		return root.ExecutionError(cmd, "Convert Error: %s: %s", filename, err)
+	}
+	out, err := bp.Format(convertTo)
+	if err != nil {
+		return root.ExecutionError(cmd, "Convert Error: %s", err)
+	}
+	fmt.Print(out)
+	return nil
+}
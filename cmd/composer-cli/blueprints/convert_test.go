@@ -0,0 +1,64 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+func runConvert(t *testing.T, args ...string) (string, string, error) {
+	return executeCmd(t, blueprintTestServer("", new(string)), append([]string{"blueprints", "convert"}, args...)...)
+}
+
+func TestCmdBlueprintsConvert(t *testing.T) {
+	tmpBp, err := ioutil.TempFile("", "test-bp-*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBp.Name())
+	_, err = tmpBp.Write([]byte(customizeTestTOML))
+	require.Nil(t, err)
+	tmpBp.Close()
+
+	stdout, stderr, err := runConvert(t, tmpBp.Name(), "--from", "", "--to", "json")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	bp, err := weldr.NewBlueprintFromJSON(stdout)
+	require.Nil(t, err)
+	expected, err := weldr.NewBlueprintFromTOML(customizeTestTOML)
+	require.Nil(t, err)
+	assert.Equal(t, expected.Customizations, bp.Customizations)
+
+	// Convert the JSON to YAML using stdin
+	restore, err := root.SetupStdin(stdout)
+	require.Nil(t, err)
+	defer restore()
+	stdout, stderr, err = runConvert(t, "--from", "json", "--to", "yaml")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	bp, err = weldr.NewBlueprintFromYAML(stdout)
+	require.Nil(t, err)
+	assert.Equal(t, expected.Customizations, bp.Customizations)
+}
+
+func TestCmdBlueprintsConvertErrors(t *testing.T) {
+	_, stderr, err := runConvert(t, "--from", "", "--to", "toml")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Convert Error: --from is required when reading from stdin\n", stderr)
+
+	_, stderr, err = runConvert(t, "bp.toml", "--from", "toml", "--to", "xml")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Convert Error: unknown format xml, it should be one of: toml, json, yaml\n", stderr)
+
+	_, stderr, err = runConvert(t, "/missing/bp.toml", "--from", "", "--to", "json")
+	require.NotNil(t, err)
+	assert.Contains(t, stderr, "ERROR: Convert Error: open /missing/bp.toml")
+}
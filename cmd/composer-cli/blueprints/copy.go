@@ -0,0 +1,286 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	copyCmd = &cobra.Command{
+		Use:   "copy SRC DST [--force]",
+		Short: "Copy a blueprint to a new name",
+		Long: `Copy the blueprint SRC, including any workspace changes, to a new blueprint named DST
+
+It refuses to overwrite an existing blueprint unless --force is used.`,
+		RunE: copyBlueprint,
+		Args: cobra.ExactArgs(2),
+	}
+	renameCmd = &cobra.Command{
+		Use:   "rename OLD NEW [--force]",
+		Short: "Rename a blueprint, keeping its history",
+		Long: `Rename the blueprint OLD to NEW, keeping its history
+
+Each commit of OLD is pushed to NEW, oldest first, and tagged commits are tagged
+again. The server writes its own commit messages so the original commits, with
+their messages and timestamps, are listed as they are replayed and saved to
+NEW-history.json in the current directory. OLD is deleted when they have all
+been replayed. If the replay fails a new NEW is deleted again, and the commit to
+restore an existing NEW to is printed.
+
+It refuses to rename to an existing blueprint unless --force is used, or to
+rename a blueprint with uncommitted workspace changes.`,
+		RunE: renameBlueprint,
+		Args: cobra.ExactArgs(2),
+	}
+	copyForce bool
+)
+
+func init() {
+	copyCmd.Flags().BoolVarP(&copyForce, "force", "", false, "Overwrite an existing blueprint")
+	blueprintsCmd.AddCommand(copyCmd)
+	renameCmd.Flags().BoolVarP(&copyForce, "force", "", false, "Add the history to an existing blueprint")
+	blueprintsCmd.AddCommand(renameCmd)
+}
+
+// blueprintExists returns true if the server has a blueprint with the name
+func blueprintExists(name string) (bool, error) {
+	blueprints, errors, err := root.Client.GetBlueprintsJSON([]string{name})
+	if err != nil {
+		return false, err
+	}
+	for _, e := range errors {
+		if e.ID != "UnknownBlueprint" {
+			return false, fmt.Errorf("%s: %s", e.ID, e.Msg)
+		}
+	}
+	return len(blueprints) > 0, nil
+}
+
+// checkDestination returns an error if the blueprint exists and --force is not used
+func checkDestination(name string) error {
+	if copyForce {
+		return nil
+	}
+	exists, err := blueprintExists(name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", name)
+	}
+	return nil
+}
+
+// pushRenamed pushes the blueprint with a new name
+// The blueprint is kept as a map so that fields unknown to the client are not lost.
+func pushRenamed(bp interface{}, name string) ([]weldr.APIErrorMsg, error) {
+	fields, ok := bp.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected blueprint: %v", bp)
+	}
+	fields["name"] = name
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := root.Client.PushBlueprintJSON(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil && !resp.Status {
+		return resp.Errors, nil
+	}
+	return nil, nil
+}
+
+func copyBlueprint(cmd *cobra.Command, args []string) error {
+	src, dst := args[0], args[1]
+	if !blueprintNameRegex.MatchString(dst) {
+		return root.ExecutionError(cmd, "Copy Error: %s is not a valid blueprint name", dst)
+	}
+	if err := checkDestination(dst); err != nil {
+		return root.ExecutionError(cmd, "Copy Error: %s", err)
+	}
+	blueprints, errors, err := root.Client.GetBlueprintsJSON([]string{src})
+	if err != nil {
+		return root.ExecutionError(cmd, "Copy Error: %s", err)
+	}
+	if len(errors) > 0 {
+		return root.ExecutionErrors(cmd, errors)
+	}
+	if len(blueprints) != 1 {
+		return root.ExecutionError(cmd, "Copy Error: missing blueprint %s", src)
+	}
+	errors, err = pushRenamed(blueprints[0], dst)
+	if err != nil {
+		return root.ExecutionError(cmd, "Copy Error: %s", err)
+	}
+	if len(errors) > 0 {
+		return root.ExecutionErrors(cmd, errors)
+	}
+	return nil
+}
+
+// replayChanges pushes the changes to the new blueprint, oldest first, and tags the tagged ones
+// It returns the number of changes that were pushed before an error.
+func replayChanges(oldName, newName string, changes []weldr.Change) (int, []weldr.APIErrorMsg, error) {
+	for i, c := range changes {
+		bp, errors, err := root.Client.GetBlueprintChangeJSON(oldName, c.Commit)
+		if err != nil || len(errors) > 0 {
+			return i, errors, err
+		}
+		errors, err = pushRenamed(bp, newName)
+		if err != nil || len(errors) > 0 {
+			return i, errors, err
+		}
+		// The change has been pushed even if tagging it fails
+		if c.Revision != nil {
+			resp, err := root.Client.TagBlueprint(newName)
+			if err != nil {
+				return i + 1, nil, err
+			}
+			if resp != nil && !resp.Status {
+				return i + 1, resp.Errors, nil
+			}
+		}
+		fmt.Printf("%s %s\n", c.Commit, c.Message)
+	}
+	return len(changes), nil, nil
+}
+
+// newestCommit returns the newest commit of a blueprint
+func newestCommit(name string) (string, error) {
+	history, errors, err := root.Client.GetBlueprintsChanges([]string{name})
+	if err != nil {
+		return "", err
+	}
+	if len(errors) > 0 {
+		return "", fmt.Errorf("%s: %s", errors[0].ID, errors[0].Msg)
+	}
+	if len(history) != 1 || len(history[0].Changes) == 0 {
+		return "", fmt.Errorf("no changes for %s", name)
+	}
+	return history[0].Changes[0].Commit, nil
+}
+
+// writeHistory saves the original changes, oldest first, to NAME-history.json
+// The server writes its own commit messages, so this is where the original ones are kept.
+func writeHistory(name string, changes []weldr.Change) (string, error) {
+	filename := name + "-history.json"
+	data, err := json.MarshalIndent(changes, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return filename, ioutil.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+func renameBlueprint(cmd *cobra.Command, args []string) error {
+	oldName, newName := args[0], args[1]
+	if !blueprintNameRegex.MatchString(newName) {
+		return root.ExecutionError(cmd, "Rename Error: %s is not a valid blueprint name", newName)
+	}
+	if err := checkDestination(newName); err != nil {
+		return root.ExecutionError(cmd, "Rename Error: %s", err)
+	}
+
+	// Workspace changes are not part of the history, they would be lost
+	changed, errors, err := workspaceChanges([]string{oldName})
+	if err != nil {
+		return root.ExecutionError(cmd, "Rename Error: %s", err)
+	}
+	if len(errors) > 0 {
+		return root.ExecutionErrors(cmd, errors)
+	}
+	if changed[oldName] {
+		return root.ExecutionError(cmd, "Rename Error: %s has uncommitted workspace changes, commit or discard them first", oldName)
+	}
+
+	history, errors, err := root.Client.GetBlueprintsChanges([]string{oldName})
+	if err != nil {
+		return root.ExecutionError(cmd, "Rename Error: %s", err)
+	}
+	if len(errors) > 0 {
+		return root.ExecutionErrors(cmd, errors)
+	}
+	if len(history) != 1 || len(history[0].Changes) == 0 {
+		return root.ExecutionError(cmd, "Rename Error: no changes for %s", oldName)
+	}
+
+	// An existing blueprint can only be restored to its newest commit, a new one is deleted
+	exists, err := blueprintExists(newName)
+	if err != nil {
+		return root.ExecutionError(cmd, "Rename Error: %s", err)
+	}
+	var restore string
+	if exists {
+		if restore, err = newestCommit(newName); err != nil {
+			return root.ExecutionError(cmd, "Rename Error: %s", err)
+		}
+	}
+
+	// The changes are newest first, replay them oldest first
+	var changes []weldr.Change
+	for i := len(history[0].Changes) - 1; i >= 0; i-- {
+		changes = append(changes, history[0].Changes[i])
+	}
+	replayed, errors, err := replayChanges(oldName, newName, changes)
+	if err == nil && len(errors) > 0 {
+		var msgs []string
+		for _, e := range errors {
+			msgs = append(msgs, e.String())
+		}
+		err = fmt.Errorf("%s", strings.Join(msgs, "; "))
+	}
+	if err != nil {
+		return renameFailed(cmd, oldName, newName, restore, replayed, len(changes), err)
+	}
+
+	filename, err := writeHistory(newName, changes)
+	if err != nil {
+		return root.ExecutionError(cmd, "Rename Error: %s was not deleted, saving the history failed: %s", oldName, err)
+	}
+	fmt.Printf("The original commit messages are saved in %s\n", filename)
+
+	resp, err := root.Client.DeleteBlueprint(oldName)
+	if err != nil {
+		return root.ExecutionError(cmd, "Rename Error: %s", err)
+	}
+	if resp != nil && !resp.Status {
+		return root.ExecutionErrors(cmd, resp.Errors)
+	}
+	fmt.Printf("Renamed %s to %s\n", oldName, newName)
+	return nil
+}
+
+// renameFailed cleans up after a rename that stopped part way through the history
+// A new blueprint is deleted, an existing one cannot be restored automatically so the
+// commit to undo it to is printed. The error includes the reason the replay stopped.
+func renameFailed(cmd *cobra.Command, oldName, newName, restore string, replayed, total int, replayErr error) error {
+	if replayed == 0 && len(restore) == 0 {
+		return root.ExecutionError(cmd, "Rename Error: %s, %s was not changed", replayErr, oldName)
+	}
+	if len(restore) > 0 {
+		return root.ExecutionError(cmd, "Rename Error: %s, %s has %d of the %d changes of %s, %s was not changed. Restore %s with: composer-cli blueprints undo %s %s",
+			replayErr, newName, replayed, total, oldName, oldName, newName, newName, restore)
+	}
+	resp, err := root.Client.DeleteBlueprint(newName)
+	if err == nil && resp != nil && !resp.Status {
+		err = fmt.Errorf("%s", strings.Join(resp.AllErrors(), "; "))
+	}
+	if err != nil {
+		return root.ExecutionError(cmd, "Rename Error: %s, %s has %d of the %d changes of %s and could not be deleted: %s. Delete it with: composer-cli blueprints delete %s",
+			replayErr, newName, replayed, total, oldName, err, newName)
+	}
+	return root.ExecutionError(cmd, "Rename Error: %s, deleted the partial copy %s, %s was not changed", replayErr, newName, oldName)
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+// copyTestServer has src-bp with two commits, and exists-bp
+// ws-bp has workspace changes, the second commit of part-bp cannot be read.
+// The requests that change the server are recorded.
+func copyTestServer(requests *[]string) func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		path := strings.TrimPrefix(request.URL.Path, "/api/v1")
+		status := 200
+		var body string
+		switch {
+		case path == "/blueprints/info/src-bp", path == "/blueprints/info/part-bp":
+			name := strings.TrimPrefix(path, "/blueprints/info/")
+			body = fmt.Sprintf(`{"blueprints": [{"name": "%s", "version": "0.0.2", "packages": [{"name": "tmux"}], "future": true}],
+				"changes": [{"name": "%s", "changed": false}], "errors": []}`, name, name)
+		case path == "/blueprints/info/exists-bp":
+			body = `{"blueprints": [{"name": "exists-bp"}], "changes": [{"name": "exists-bp", "changed": false}], "errors": []}`
+		case path == "/blueprints/info/ws-bp":
+			body = `{"blueprints": [{"name": "ws-bp"}], "changes": [{"name": "ws-bp", "changed": true}], "errors": []}`
+		case strings.HasPrefix(path, "/blueprints/info/"):
+			status = 400
+			name := strings.TrimPrefix(path, "/blueprints/info/")
+			body = fmt.Sprintf(`{"status": false, "errors": [{"id": "UnknownBlueprint", "msg": "%s: "}]}`, name)
+		case path == "/blueprints/changes/src-bp", path == "/blueprints/changes/part-bp":
+			name := strings.TrimPrefix(path, "/blueprints/changes/")
+			limit, _ := strconv.ParseUint(request.URL.Query().Get("limit"), 10, 64)
+			body = fmt.Sprintf(`{"blueprints": [{"changes": [
+				{"commit": "222", "message": "Recipe %s, version 0.0.2 saved.", "revision": null, "timestamp": "2021-02-08T15:44:35Z"},
+				{"commit": "111", "message": "Recipe %s, version 0.0.1 saved.", "revision": 1, "timestamp": "2021-02-04T14:48:08Z"}
+				], "name": "%s", "total": 2}], "errors": [], "limit": %d, "offset": 0}`, name, name, name, limit)
+		case path == "/blueprints/changes/exists-bp":
+			limit, _ := strconv.ParseUint(request.URL.Query().Get("limit"), 10, 64)
+			body = fmt.Sprintf(`{"blueprints": [{"changes": [
+				{"commit": "999", "message": "Recipe exists-bp, version 0.0.1 saved.", "revision": null, "timestamp": "2021-02-01T10:00:00Z"}
+				], "name": "exists-bp", "total": 1}], "errors": [], "limit": %d, "offset": 0}`, limit)
+		case path == "/blueprints/change/src-bp/111", path == "/blueprints/change/part-bp/111":
+			body = `{"name": "src-bp", "version": "0.0.1"}`
+		case path == "/blueprints/change/src-bp/222":
+			body = `{"name": "src-bp", "version": "0.0.2"}`
+		case path == "/blueprints/change/part-bp/222":
+			status = 400
+			body = `{"status": false, "errors": [{"id": "BlueprintsError", "msg": "part-bp commit 222 is missing"}]}`
+		default:
+			data, _ := ioutil.ReadAll(request.Body)
+			*requests = append(*requests, strings.TrimSpace(request.Method+" "+path+" "+string(data)))
+			body = `{"status": true}`
+		}
+		return &http.Response{
+			Request:    request,
+			StatusCode: status,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+func runCopy(t *testing.T, args ...string) (string, string, []string, error) {
+	var requests []string
+	stdout, stderr, err := executeCmd(t, copyTestServer(&requests), append([]string{"blueprints"}, args...)...)
+	return stdout, stderr, requests, err
+}
+
+func TestCmdBlueprintsCopy(t *testing.T) {
+	stdout, stderr, requests, err := runCopy(t, "copy", "--force=false", "src-bp", "new-bp")
+	require.Nil(t, err)
+	assert.Equal(t, "", stdout)
+	assert.Equal(t, "", stderr)
+	// Fields the client does not know about are kept
+	assert.Equal(t, []string{`POST /blueprints/new {"future":true,"name":"new-bp","packages":[{"name":"tmux"}],"version":"0.0.2"}`}, requests)
+}
+
+func TestCmdBlueprintsCopyErrors(t *testing.T) {
+	_, stderr, requests, err := runCopy(t, "copy", "--force=false", "src-bp", "exists-bp")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Copy Error: exists-bp already exists, use --force to overwrite it\n", stderr)
+	assert.Equal(t, 0, len(requests))
+
+	_, stderr, _, err = runCopy(t, "copy", "--force=false", "missing-bp", "new-bp")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: UnknownBlueprint: missing-bp: \n", stderr)
+
+	_, stderr, _, err = runCopy(t, "copy", "--force=false", "src-bp", "new bp")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Copy Error: new bp is not a valid blueprint name\n", stderr)
+
+	_, _, requests, err = runCopy(t, "copy", "--force", "src-bp", "exists-bp")
+	require.Nil(t, err)
+	assert.Equal(t, 1, len(requests))
+}
+
+// inTempDir runs the function in a temporary directory
+func inTempDir(t *testing.T, f func()) {
+	dir, err := ioutil.TempDir("", "test-bp-rename-*")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	prevDir, _ := os.Getwd()
+	err = os.Chdir(dir)
+	require.Nil(t, err)
+	//nolint:errcheck
+	defer os.Chdir(prevDir)
+	f()
+}
+
+func TestCmdBlueprintsRename(t *testing.T) {
+	inTempDir(t, func() {
+		stdout, stderr, requests, err := runCopy(t, "rename", "--force=false", "src-bp", "new-bp")
+		require.Nil(t, err)
+		assert.Equal(t, "", stderr)
+		assert.Equal(t, `111 Recipe src-bp, version 0.0.1 saved.
+222 Recipe src-bp, version 0.0.2 saved.
+The original commit messages are saved in new-bp-history.json
+Renamed src-bp to new-bp
+`, stdout)
+		assert.Equal(t, []string{
+			`POST /blueprints/new {"name":"new-bp","version":"0.0.1"}`,
+			"POST /blueprints/tag/new-bp",
+			`POST /blueprints/new {"name":"new-bp","version":"0.0.2"}`,
+			"DELETE /blueprints/delete/src-bp",
+		}, requests)
+
+		data, err := ioutil.ReadFile("new-bp-history.json")
+		require.Nil(t, err)
+		var history []weldr.Change
+		require.Nil(t, json.Unmarshal(data, &history))
+		require.Equal(t, 2, len(history))
+		assert.Equal(t, "111", history[0].Commit)
+		assert.Equal(t, "Recipe src-bp, version 0.0.1 saved.", history[0].Message)
+		assert.Equal(t, "2021-02-04T14:48:08Z", history[0].Timestamp)
+		assert.Equal(t, "222", history[1].Commit)
+	})
+}
+
+func TestCmdBlueprintsRenamePartial(t *testing.T) {
+	inTempDir(t, func() {
+		// The partial copy of a new blueprint is deleted
+		stdout, stderr, requests, err := runCopy(t, "rename", "--force=false", "part-bp", "new-bp")
+		require.NotNil(t, err)
+		assert.Equal(t, "111 Recipe part-bp, version 0.0.1 saved.\n", stdout)
+		assert.Equal(t, "ERROR: Rename Error: BlueprintsError: part-bp commit 222 is missing, "+
+			"deleted the partial copy new-bp, part-bp was not changed\n", stderr)
+		assert.Equal(t, []string{
+			`POST /blueprints/new {"name":"new-bp","version":"0.0.1"}`,
+			"POST /blueprints/tag/new-bp",
+			"DELETE /blueprints/delete/new-bp",
+		}, requests)
+
+		// An existing blueprint cannot be deleted, the commit to restore it to is printed
+		_, stderr, requests, err = runCopy(t, "rename", "--force", "part-bp", "exists-bp")
+		require.NotNil(t, err)
+		assert.Equal(t, "ERROR: Rename Error: BlueprintsError: part-bp commit 222 is missing, "+
+			"exists-bp has 1 of the 2 changes of part-bp, part-bp was not changed. "+
+			"Restore exists-bp with: composer-cli blueprints undo exists-bp 999\n", stderr)
+		assert.Equal(t, 2, len(requests))
+
+		_, err = os.Stat("new-bp-history.json")
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestCmdBlueprintsRenameErrors(t *testing.T) {
+	_, stderr, requests, err := runCopy(t, "rename", "--force=false", "src-bp", "exists-bp")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Rename Error: exists-bp already exists, use --force to overwrite it\n", stderr)
+	assert.Equal(t, 0, len(requests))
+
+	_, stderr, requests, err = runCopy(t, "rename", "--force=false", "ws-bp", "new-bp")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Rename Error: ws-bp has uncommitted workspace changes, commit or discard them first\n", stderr)
+	assert.Equal(t, 0, len(requests))
+}
@@ -0,0 +1,450 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	customizeCmd = &cobra.Command{
+		Use:   "customize ...",
+		Short: "Change the customizations of a blueprint",
+		Long:  "Change the customizations of a blueprint and commit it with the patch version bumped. The changes are printed, the server does not store a commit message.",
+	}
+	hostnameCmd = &cobra.Command{
+		Use:   "hostname BLUEPRINT HOSTNAME",
+		Short: "Set the hostname",
+		Long:  "Set the hostname of the image",
+		RunE:  customizeHostname,
+		Args:  cobra.ExactArgs(2),
+	}
+	timezoneCmd = &cobra.Command{
+		Use:   "timezone BLUEPRINT TIMEZONE",
+		Short: "Set the timezone and optional NTP servers",
+		Long:  "Set the timezone of the image, eg. US/Eastern, and optionally the NTP servers to use",
+		RunE:  customizeTimezone,
+		Args:  cobra.ExactArgs(2),
+	}
+	kernelCmd = &cobra.Command{
+		Use:   "kernel ...",
+		Short: "Change the kernel customizations",
+		Long:  "Change the kernel customizations",
+	}
+	kernelAppendCmd = &cobra.Command{
+		Use:   "append BLUEPRINT ARGUMENT ...",
+		Short: "Append arguments to the kernel cmdline",
+		Long:  "Append arguments to the kernel cmdline, arguments that are already included are skipped",
+		RunE:  customizeKernelAppend,
+		Args:  cobra.MinimumNArgs(2),
+	}
+	serviceCmd = &cobra.Command{
+		Use:   "service ...",
+		Short: "Enable or disable systemd services",
+		Long:  "Enable or disable systemd services",
+	}
+	serviceEnableCmd = &cobra.Command{
+		Use:   "enable BLUEPRINT SERVICE,...",
+		Short: "Enable systemd services",
+		Long:  "Enable systemd services, removing them from the disabled list",
+		RunE:  customizeServiceEnable,
+		Args:  cobra.MinimumNArgs(2),
+	}
+	serviceDisableCmd = &cobra.Command{
+		Use:   "disable BLUEPRINT SERVICE,...",
+		Short: "Disable systemd services",
+		Long:  "Disable systemd services, removing them from the enabled list",
+		RunE:  customizeServiceDisable,
+		Args:  cobra.MinimumNArgs(2),
+	}
+	firewallCmd = &cobra.Command{
+		Use:   "firewall ...",
+		Short: "Change the firewall ports and services",
+		Long:  "Change the firewall ports and services",
+	}
+	firewallAddPortCmd = &cobra.Command{
+		Use:   "add-port BLUEPRINT PORT/PROTOCOL,...",
+		Short: "Open firewall ports",
+		Long:  "Open firewall ports. PORT is a number, a range like 8000-8010, or a service name like https. PROTOCOL is tcp, udp, sctp, or dccp",
+		RunE:  customizeFirewallAddPort,
+		Args:  cobra.MinimumNArgs(2),
+	}
+	firewallRemovePortCmd = &cobra.Command{
+		Use:   "remove-port BLUEPRINT PORT/PROTOCOL,...",
+		Short: "Remove open firewall ports",
+		Long:  "Remove firewall ports from the list of open ports",
+		RunE:  customizeFirewallRemovePort,
+		Args:  cobra.MinimumNArgs(2),
+	}
+	firewallEnableCmd = &cobra.Command{
+		Use:   "enable BLUEPRINT SERVICE,...",
+		Short: "Enable firewalld services",
+		Long:  "Enable firewalld services, eg. ssh, removing them from the disabled list",
+		RunE:  customizeFirewallEnable,
+		Args:  cobra.MinimumNArgs(2),
+	}
+	firewallDisableCmd = &cobra.Command{
+		Use:   "disable BLUEPRINT SERVICE,...",
+		Short: "Disable firewalld services",
+		Long:  "Disable firewalld services, removing them from the enabled list",
+		RunE:  customizeFirewallDisable,
+		Args:  cobra.MinimumNArgs(2),
+	}
+	ntpServers []string
+)
+
+func init() {
+	blueprintsCmd.AddCommand(customizeCmd)
+	customizeCmd.AddCommand(hostnameCmd)
+	timezoneCmd.Flags().StringSliceVarP(&ntpServers, "ntp", "", nil, "NTP servers to use")
+	customizeCmd.AddCommand(timezoneCmd)
+	customizeCmd.AddCommand(kernelCmd)
+	kernelCmd.AddCommand(kernelAppendCmd)
+	customizeCmd.AddCommand(serviceCmd)
+	serviceCmd.AddCommand(serviceEnableCmd)
+	serviceCmd.AddCommand(serviceDisableCmd)
+	customizeCmd.AddCommand(firewallCmd)
+	firewallCmd.AddCommand(firewallAddPortCmd)
+	firewallCmd.AddCommand(firewallRemovePortCmd)
+	firewallCmd.AddCommand(firewallEnableCmd)
+	firewallCmd.AddCommand(firewallDisableCmd)
+}
+
+var (
+	hostnameLabelRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+	serviceNameRegex   = regexp.MustCompile(`^[a-zA-Z0-9:_.\\@-]+$`)
+	portNameRegex      = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+)
+
+// validateHostname checks the hostname against RFC 1123
+func validateHostname(hostname string) error {
+	if len(hostname) == 0 || len(hostname) > 253 {
+		return fmt.Errorf("invalid hostname length: %s", hostname)
+	}
+	for _, label := range strings.Split(hostname, ".") {
+		if len(label) > 63 || !hostnameLabelRegex.MatchString(label) {
+			return fmt.Errorf("invalid hostname: %s", hostname)
+		}
+	}
+	return nil
+}
+
+// validateServiceName checks for characters that are allowed in unit names
+func validateServiceName(name string) error {
+	if !serviceNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid service name: %s", name)
+	}
+	return nil
+}
+
+// normalizePort checks the port and returns it in the PORT:PROTOCOL form used by blueprints
+// It accepts PORT/PROTOCOL or PORT:PROTOCOL where PORT is a number, a range of
+// numbers, or a service name.
+func normalizePort(port string) (string, error) {
+	fields := strings.FieldsFunc(port, func(c rune) bool { return c == '/' || c == ':' })
+	if len(fields) != 2 {
+		return "", fmt.Errorf("invalid port, it should be PORT/PROTOCOL: %s", port)
+	}
+	number, protocol := fields[0], strings.ToLower(fields[1])
+	switch protocol {
+	case "tcp", "udp", "sctp", "dccp":
+	default:
+		return "", fmt.Errorf("invalid protocol in %s, it should be one of tcp, udp, sctp, dccp", port)
+	}
+
+	if portNameRegex.MatchString(number) {
+		return number + ":" + protocol, nil
+	}
+	numbers := strings.SplitN(number, "-", 2)
+	var values []int
+	for _, n := range numbers {
+		v, err := strconv.Atoi(n)
+		if err != nil || v < 1 || v > 65535 {
+			return "", fmt.Errorf("invalid port number in %s", port)
+		}
+		values = append(values, v)
+	}
+	if len(values) == 2 && values[0] >= values[1] {
+		return "", fmt.Errorf("invalid port range in %s", port)
+	}
+	return number + ":" + protocol, nil
+}
+
+// appendUnique appends the values that are not already in the list
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if !isStringInList(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// removeStrings returns the list without the values
+func removeStrings(list []string, values ...string) []string {
+	var result []string
+	for _, s := range list {
+		if !isStringInList(values, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// isStringInList returns true if the string is in the unsorted list
+func isStringInList(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// customizeBlueprint retrieves the blueprint, applies the change, and commits it
+// The change function returns a description of the change, or an error.
+func customizeBlueprint(cmd *cobra.Command, name string, change func(*weldr.Customizations) (string, error)) error {
+	bp, err := getBlueprintToCommit(cmd, name)
+	if err != nil {
+		return err
+	}
+	if bp.Customizations == nil {
+		bp.Customizations = &weldr.Customizations{}
+	}
+	message, err := change(bp.Customizations)
+	if err != nil {
+		return root.ExecutionError(cmd, "Customize Error: %s", err)
+	}
+	if len(message) == 0 {
+		fmt.Printf("No changes made to %s\n", bp.Name)
+		return nil
+	}
+	bp.Customizations = pruneCustomizations(bp.Customizations)
+	return commitBlueprint(cmd, bp, message)
+}
+
+// pruneCustomizations removes empty sections so that they are not written to the blueprint
+func pruneCustomizations(c *weldr.Customizations) *weldr.Customizations {
+	if c.Kernel != nil && *c.Kernel == (weldr.KernelCustomization{}) {
+		c.Kernel = nil
+	}
+	if c.Timezone != nil && len(c.Timezone.Timezone) == 0 && len(c.Timezone.NTPServers) == 0 {
+		c.Timezone = nil
+	}
+	if c.Locale != nil && len(c.Locale.Languages) == 0 && len(c.Locale.Keyboard) == 0 {
+		c.Locale = nil
+	}
+	if c.Services != nil && len(c.Services.Enabled) == 0 && len(c.Services.Disabled) == 0 {
+		c.Services = nil
+	}
+	if c.Firewall != nil {
+		if c.Firewall.Services != nil && len(c.Firewall.Services.Enabled) == 0 && len(c.Firewall.Services.Disabled) == 0 {
+			c.Firewall.Services = nil
+		}
+		if len(c.Firewall.Ports) == 0 && c.Firewall.Services == nil {
+			c.Firewall = nil
+		}
+	}
+	if reflect.DeepEqual(*c, weldr.Customizations{}) {
+		return nil
+	}
+	return c
+}
+
+func customizeHostname(cmd *cobra.Command, args []string) error {
+	hostname := args[1]
+	if err := validateHostname(hostname); err != nil {
+		return root.ExecutionError(cmd, "Customize Error: %s", err)
+	}
+	return customizeBlueprint(cmd, args[0], func(c *weldr.Customizations) (string, error) {
+		if c.Hostname == hostname {
+			return "", nil
+		}
+		c.Hostname = hostname
+		return fmt.Sprintf("set hostname to %s", hostname), nil
+	})
+}
+
+func customizeTimezone(cmd *cobra.Command, args []string) error {
+	timezone := args[1]
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		return root.ExecutionError(cmd, "Customize Error: unknown timezone: %s", timezone)
+	}
+	servers := root.GetCommaArgs(ntpServers)
+	for _, s := range servers {
+		if err := validateHostname(s); err != nil {
+			return root.ExecutionError(cmd, "Customize Error: invalid NTP server: %s", s)
+		}
+	}
+	return customizeBlueprint(cmd, args[0], func(c *weldr.Customizations) (string, error) {
+		if c.Timezone == nil {
+			c.Timezone = &weldr.TimezoneCustomization{}
+		}
+		if c.Timezone.Timezone == timezone && (len(servers) == 0 || reflect.DeepEqual(c.Timezone.NTPServers, servers)) {
+			return "", nil
+		}
+		c.Timezone.Timezone = timezone
+		message := fmt.Sprintf("set timezone to %s", timezone)
+		if len(servers) > 0 {
+			c.Timezone.NTPServers = servers
+			message += fmt.Sprintf(" with NTP servers %s", strings.Join(servers, ", "))
+		}
+		return message, nil
+	})
+}
+
+func customizeKernelAppend(cmd *cobra.Command, args []string) error {
+	var arguments []string
+	for _, a := range args[1:] {
+		arguments = append(arguments, strings.Fields(a)...)
+	}
+	for _, a := range arguments {
+		if strings.ContainsAny(a, "\"'\\") {
+			return root.ExecutionError(cmd, "Customize Error: quotes and backslashes are not supported: %s", a)
+		}
+	}
+	return customizeBlueprint(cmd, args[0], func(c *weldr.Customizations) (string, error) {
+		if c.Kernel == nil {
+			c.Kernel = &weldr.KernelCustomization{}
+		}
+		current := strings.Fields(c.Kernel.Append)
+		added := removeStrings(appendUnique(nil, arguments...), current...)
+		if len(added) == 0 {
+			return "", nil
+		}
+		c.Kernel.Append = strings.Join(append(current, added...), " ")
+		return fmt.Sprintf("appended %s to the kernel cmdline", strings.Join(added, " ")), nil
+	})
+}
+
+// serviceLists returns the enabled and disabled lists from the services customization
+func serviceLists(c *weldr.Customizations) (*[]string, *[]string) {
+	if c.Services == nil {
+		c.Services = &weldr.ServicesCustomization{}
+	}
+	return &c.Services.Enabled, &c.Services.Disabled
+}
+
+// firewallServiceLists returns the enabled and disabled lists from the firewall customization
+func firewallServiceLists(c *weldr.Customizations) (*[]string, *[]string) {
+	if c.Firewall == nil {
+		c.Firewall = &weldr.FirewallCustomization{}
+	}
+	if c.Firewall.Services == nil {
+		c.Firewall.Services = &weldr.FirewallServicesCustomization{}
+	}
+	return &c.Firewall.Services.Enabled, &c.Firewall.Services.Disabled
+}
+
+// toggleServices moves the services to the enabled, or disabled, list
+func toggleServices(cmd *cobra.Command, args []string, lists func(*weldr.Customizations) (*[]string, *[]string), enable bool) error {
+	services := root.GetCommaArgs(args[1:])
+	for _, s := range services {
+		if err := validateServiceName(s); err != nil {
+			return root.ExecutionError(cmd, "Customize Error: %s", err)
+		}
+	}
+	return customizeBlueprint(cmd, args[0], func(c *weldr.Customizations) (string, error) {
+		enabled, disabled := lists(c)
+		add, remove, action := enabled, disabled, "enabled"
+		if !enable {
+			add, remove, action = disabled, enabled, "disabled"
+		}
+		var changed []string
+		for _, s := range services {
+			if !isStringInList(*add, s) {
+				changed = append(changed, s)
+			}
+		}
+		*add = appendUnique(*add, services...)
+		*remove = removeStrings(*remove, services...)
+		if len(changed) == 0 {
+			return "", nil
+		}
+		return fmt.Sprintf("%s %s", action, strings.Join(changed, ", ")), nil
+	})
+}
+
+func customizeServiceEnable(cmd *cobra.Command, args []string) error {
+	return toggleServices(cmd, args, serviceLists, true)
+}
+
+func customizeServiceDisable(cmd *cobra.Command, args []string) error {
+	return toggleServices(cmd, args, serviceLists, false)
+}
+
+func customizeFirewallEnable(cmd *cobra.Command, args []string) error {
+	return toggleServices(cmd, args, firewallServiceLists, true)
+}
+
+func customizeFirewallDisable(cmd *cobra.Command, args []string) error {
+	return toggleServices(cmd, args, firewallServiceLists, false)
+}
+
+// firewallPorts validates the ports and returns them in PORT:PROTOCOL form
+func firewallPorts(args []string) ([]string, error) {
+	var ports []string
+	for _, p := range root.GetCommaArgs(args) {
+		port, err := normalizePort(p)
+		if err != nil {
+			return nil, err
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+func customizeFirewallAddPort(cmd *cobra.Command, args []string) error {
+	ports, err := firewallPorts(args[1:])
+	if err != nil {
+		return root.ExecutionError(cmd, "Customize Error: %s", err)
+	}
+	return customizeBlueprint(cmd, args[0], func(c *weldr.Customizations) (string, error) {
+		if c.Firewall == nil {
+			c.Firewall = &weldr.FirewallCustomization{}
+		}
+		added := removeStrings(appendUnique(nil, ports...), c.Firewall.Ports...)
+		if len(added) == 0 {
+			return "", nil
+		}
+		c.Firewall.Ports = append(c.Firewall.Ports, added...)
+		return fmt.Sprintf("opened firewall ports %s", strings.Join(added, ", ")), nil
+	})
+}
+
+func customizeFirewallRemovePort(cmd *cobra.Command, args []string) error {
+	ports, err := firewallPorts(args[1:])
+	if err != nil {
+		return root.ExecutionError(cmd, "Customize Error: %s", err)
+	}
+	return customizeBlueprint(cmd, args[0], func(c *weldr.Customizations) (string, error) {
+		if c.Firewall == nil {
+			return "", fmt.Errorf("there are no open firewall ports")
+		}
+		var missing, removed []string
+		for _, p := range ports {
+			if isStringInList(c.Firewall.Ports, p) {
+				removed = append(removed, p)
+			} else {
+				missing = append(missing, p)
+			}
+		}
+		if len(missing) > 0 {
+			return "", fmt.Errorf("firewall ports are not open: %s", strings.Join(missing, ", "))
+		}
+		c.Firewall.Ports = removeStrings(c.Firewall.Ports, removed...)
+		return fmt.Sprintf("closed firewall ports %s", strings.Join(removed, ", ")), nil
+	})
+}
@@ -0,0 +1,259 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+const customizeTestTOML = `name = "custom"
+description = "customized blueprint"
+version = "1.0.0"
+
+[[packages]]
+name = "bash"
+version = "*"
+
+[customizations.kernel]
+append = "nosmt=force"
+
+[customizations.services]
+enabled = ["sshd"]
+disabled = ["telnet"]
+
+[customizations.firewall]
+ports = ["22:tcp"]
+`
+
+// blueprintInfoBody returns the blueprints info response for bpTOML, or an unknown blueprint error if it is empty
+func blueprintInfoBody(bpTOML string, changed bool) string {
+	bp, err := weldr.NewBlueprintFromTOML(bpTOML)
+	if len(bpTOML) == 0 || err != nil {
+		return `{"blueprints": [], "changes": [], "errors": [{"id": "UnknownBlueprint", "msg": "unknown blueprint"}]}`
+	}
+	data, _ := bp.JSON()
+	return fmt.Sprintf(`{"blueprints": [%s], "changes": [{"name": %q, "changed": %v}], "errors": []}`, data, bp.Name, changed)
+}
+
+// blueprintTestServer returns bpTOML for GET requests and records the pushed blueprint
+// The blueprint info requests without format=toml get the JSON info, without workspace changes.
+func blueprintTestServer(bpTOML string, pushed *string) func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		body := bpTOML
+		if request.Method == "POST" {
+			data, _ := ioutil.ReadAll(request.Body)
+			*pushed = string(data)
+			body = `{"status": true}`
+		} else if strings.HasPrefix(request.URL.Path, "/api/v1/blueprints/info/") && request.URL.Query().Get("format") != "toml" {
+			body = blueprintInfoBody(bpTOML, false)
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+// runCustomize runs a customize command and returns the stdout, stderr, and pushed blueprint
+func runCustomize(t *testing.T, args ...string) (string, string, *weldr.Blueprint, error) {
+	var pushed string
+	root.SetupCmdTest(blueprintTestServer(customizeTestTOML, &pushed))
+
+	cmd, out, err := root.ExecuteTest(append([]string{"blueprints", "customize"}, args...)...)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, cmd)
+	stdout, rerr := ioutil.ReadAll(out.Stdout)
+	require.Nil(t, rerr)
+	stderr, rerr := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, rerr)
+
+	if len(pushed) == 0 {
+		return string(stdout), string(stderr), nil, err
+	}
+	bp, perr := weldr.NewBlueprintFromTOML(pushed)
+	require.Nil(t, perr)
+	return string(stdout), string(stderr), &bp, err
+}
+
+func TestNormalizePort(t *testing.T) {
+	for _, p := range [][]string{
+		{"443/tcp", "443:tcp"},
+		{"53:udp", "53:udp"},
+		{"8000-8010/TCP", "8000-8010:tcp"},
+		{"https/tcp", "https:tcp"},
+	} {
+		port, err := normalizePort(p[0])
+		require.Nil(t, err)
+		assert.Equal(t, p[1], port)
+	}
+	for _, p := range []string{"443", "443/icmp", "0/tcp", "65536/tcp", "10-5/tcp", "a b/tcp", "/tcp"} {
+		_, err := normalizePort(p)
+		assert.NotNil(t, err, p)
+	}
+}
+
+func TestValidateHostname(t *testing.T) {
+	assert.Nil(t, validateHostname("server"))
+	assert.Nil(t, validateHostname("web-1.example.com"))
+	assert.NotNil(t, validateHostname(""))
+	assert.NotNil(t, validateHostname("-web"))
+	assert.NotNil(t, validateHostname("web_1"))
+	assert.NotNil(t, validateHostname("web..example.com"))
+}
+
+func TestCmdBlueprintsCustomizeHostname(t *testing.T) {
+	stdout, stderr, bp, err := runCustomize(t, "hostname", "custom", "web-1.example.com")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, "custom v1.0.1: set hostname to web-1.example.com\n", stdout)
+	require.NotNil(t, bp)
+	assert.Equal(t, "web-1.example.com", bp.Customizations.Hostname)
+	// Nothing else should have changed
+	assert.Equal(t, "nosmt=force", bp.Customizations.Kernel.Append)
+	assert.Equal(t, []string{"22:tcp"}, bp.Customizations.Firewall.Ports)
+}
+
+func TestCmdBlueprintsCustomizeHostnameInvalid(t *testing.T) {
+	_, stderr, bp, err := runCustomize(t, "hostname", "custom", "web_1")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Customize Error: invalid hostname: web_1\n", stderr)
+	assert.Nil(t, bp)
+}
+
+func TestCmdBlueprintsCustomizeTimezone(t *testing.T) {
+	stdout, stderr, bp, err := runCustomize(t, "timezone", "custom", "UTC", "--ntp", "0.pool.ntp.org,1.pool.ntp.org")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, "custom v1.0.1: set timezone to UTC with NTP servers 0.pool.ntp.org, 1.pool.ntp.org\n", stdout)
+	require.NotNil(t, bp)
+	assert.Equal(t, &weldr.TimezoneCustomization{
+		Timezone:   "UTC",
+		NTPServers: []string{"0.pool.ntp.org", "1.pool.ntp.org"},
+	}, bp.Customizations.Timezone)
+}
+
+func TestCmdBlueprintsCustomizeTimezoneUnchanged(t *testing.T) {
+	var pushed string
+	root.SetupCmdTest(blueprintTestServer(customizeTestTOML+`
+[customizations.timezone]
+timezone = "UTC"
+ntpservers = ["0.pool.ntp.org"]
+`, &pushed))
+
+	for _, args := range [][]string{{"--ntp", ""}, {"--ntp", "0.pool.ntp.org"}} {
+		// The slice flag appends to the previous test's servers
+		ntpServers = nil
+		cmd, out, err := root.ExecuteTest(append([]string{"blueprints", "customize", "timezone", "custom", "UTC"}, args...)...)
+		require.NotNil(t, out)
+		require.Nil(t, err)
+		require.NotNil(t, cmd)
+		stdout, err := ioutil.ReadAll(out.Stdout)
+		require.Nil(t, err)
+		out.Close()
+		assert.Equal(t, "No changes made to custom\n", string(stdout))
+		assert.Equal(t, "", pushed)
+	}
+}
+
+func TestCmdBlueprintsCustomizeTimezoneUnknown(t *testing.T) {
+	_, stderr, bp, err := runCustomize(t, "timezone", "custom", "Mars/Olympus_Mons", "--ntp", "")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Customize Error: unknown timezone: Mars/Olympus_Mons\n", stderr)
+	assert.Nil(t, bp)
+}
+
+func TestCmdBlueprintsCustomizeKernelAppend(t *testing.T) {
+	stdout, stderr, bp, err := runCustomize(t, "kernel", "append", "custom", "nosmt=force console=ttyS0", "quiet")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, "custom v1.0.1: appended console=ttyS0 quiet to the kernel cmdline\n", stdout)
+	require.NotNil(t, bp)
+	assert.Equal(t, "nosmt=force console=ttyS0 quiet", bp.Customizations.Kernel.Append)
+}
+
+func TestCmdBlueprintsCustomizeKernelAppendNoChange(t *testing.T) {
+	stdout, stderr, bp, err := runCustomize(t, "kernel", "append", "custom", "nosmt=force")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, "No changes made to custom\n", stdout)
+	assert.Nil(t, bp)
+}
+
+func TestCmdBlueprintsCustomizeServiceEnable(t *testing.T) {
+	stdout, stderr, bp, err := runCustomize(t, "service", "enable", "custom", "telnet,httpd.service")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, "custom v1.0.1: enabled telnet, httpd.service\n", stdout)
+	require.NotNil(t, bp)
+	assert.Equal(t, &weldr.ServicesCustomization{
+		Enabled: []string{"sshd", "telnet", "httpd.service"},
+	}, bp.Customizations.Services)
+}
+
+func TestCmdBlueprintsCustomizeServiceDisable(t *testing.T) {
+	stdout, stderr, bp, err := runCustomize(t, "service", "disable", "custom", "sshd")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, "custom v1.0.1: disabled sshd\n", stdout)
+	require.NotNil(t, bp)
+	assert.Equal(t, &weldr.ServicesCustomization{
+		Disabled: []string{"telnet", "sshd"},
+	}, bp.Customizations.Services)
+}
+
+func TestCmdBlueprintsCustomizeServiceInvalid(t *testing.T) {
+	_, stderr, bp, err := runCustomize(t, "service", "enable", "custom", "bad;name")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Customize Error: invalid service name: bad;name\n", stderr)
+	assert.Nil(t, bp)
+}
+
+func TestCmdBlueprintsCustomizeFirewallAddPort(t *testing.T) {
+	stdout, stderr, bp, err := runCustomize(t, "firewall", "add-port", "custom", "443/tcp", "22/tcp,53/udp")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, "custom v1.0.1: opened firewall ports 443:tcp, 53:udp\n", stdout)
+	require.NotNil(t, bp)
+	assert.Equal(t, []string{"22:tcp", "443:tcp", "53:udp"}, bp.Customizations.Firewall.Ports)
+}
+
+func TestCmdBlueprintsCustomizeFirewallAddPortInvalid(t *testing.T) {
+	_, stderr, bp, err := runCustomize(t, "firewall", "add-port", "custom", "443")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Customize Error: invalid port, it should be PORT/PROTOCOL: 443\n", stderr)
+	assert.Nil(t, bp)
+}
+
+func TestCmdBlueprintsCustomizeFirewallRemovePort(t *testing.T) {
+	stdout, stderr, bp, err := runCustomize(t, "firewall", "remove-port", "custom", "22/tcp")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, "custom v1.0.1: closed firewall ports 22:tcp\n", stdout)
+	require.NotNil(t, bp)
+	// The empty firewall section is removed
+	assert.Nil(t, bp.Customizations.Firewall)
+}
+
+func TestCmdBlueprintsCustomizeFirewallEnable(t *testing.T) {
+	stdout, stderr, bp, err := runCustomize(t, "firewall", "enable", "custom", "https")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, "custom v1.0.1: enabled https\n", stdout)
+	require.NotNil(t, bp)
+	assert.Equal(t, []string{"https"}, bp.Customizations.Firewall.Services.Enabled)
+	assert.Equal(t, []string{"22:tcp"}, bp.Customizations.Firewall.Ports)
+}
@@ -0,0 +1,250 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	userCmd = &cobra.Command{
+		Use:   "user ...",
+		Short: "Add or remove users",
+		Long:  "Add or remove the users created in the image",
+	}
+	userAddCmd = &cobra.Command{
+		Use:   "add BLUEPRINT --name USER [--key-file FILE] [--groups GROUP,...]",
+		Short: "Add a user",
+		Long:  "Add a user to the image, optionally with ssh public keys read from a file",
+		RunE:  customizeUserAdd,
+		Args:  cobra.ExactArgs(1),
+	}
+	userRemoveCmd = &cobra.Command{
+		Use:   "remove BLUEPRINT USER",
+		Short: "Remove a user",
+		Long:  "Remove a user from the blueprint",
+		RunE:  customizeUserRemove,
+		Args:  cobra.ExactArgs(2),
+	}
+	sshkeyCmd = &cobra.Command{
+		Use:   "sshkey ...",
+		Short: "Set ssh keys for existing users",
+		Long:  "Set ssh keys for users that already exist in the image, eg. root",
+	}
+	sshkeyAddCmd = &cobra.Command{
+		Use:   "add BLUEPRINT USER --key-file FILE",
+		Short: "Set the ssh keys for an existing user",
+		Long:  "Set the ssh public keys read from a file for a user that already exists in the image",
+		RunE:  customizeSSHKeyAdd,
+		Args:  cobra.ExactArgs(2),
+	}
+	userName        string
+	userDescription string
+	userKeyFile     string
+	userGroups      []string
+	userHome        string
+	userShell       string
+	userUID         int
+	userGID         int
+)
+
+func init() {
+	customizeCmd.AddCommand(userCmd)
+	userAddCmd.Flags().StringVarP(&userName, "name", "", "", "Name of the user")
+	userAddCmd.Flags().StringVarP(&userDescription, "description", "", "", "Description of the user")
+	userAddCmd.Flags().StringVarP(&userKeyFile, "key-file", "", "", "File with the user's ssh public keys")
+	userAddCmd.Flags().StringSliceVarP(&userGroups, "groups", "", nil, "Groups the user is a member of")
+	userAddCmd.Flags().StringVarP(&userHome, "home", "", "", "Home directory of the user")
+	userAddCmd.Flags().StringVarP(&userShell, "shell", "", "", "Login shell of the user")
+	userAddCmd.Flags().IntVarP(&userUID, "uid", "", -1, "User ID")
+	userAddCmd.Flags().IntVarP(&userGID, "gid", "", -1, "Group ID")
+	userCmd.AddCommand(userAddCmd)
+	userCmd.AddCommand(userRemoveCmd)
+
+	sshkeyAddCmd.Flags().StringVarP(&userKeyFile, "key-file", "", "", "File with the user's ssh public keys")
+	customizeCmd.AddCommand(sshkeyCmd)
+	sshkeyCmd.AddCommand(sshkeyAddCmd)
+}
+
+// userNameRegex matches the user and group names accepted by useradd
+var userNameRegex = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,30}[$]?$`)
+
+// sshKeyTypes are the public key types supported by OpenSSH
+var sshKeyTypes = []string{
+	"ssh-rsa",
+	"ssh-dss",
+	"ssh-ed25519",
+	"ecdsa-sha2-nistp256",
+	"ecdsa-sha2-nistp384",
+	"ecdsa-sha2-nistp521",
+	"sk-ssh-ed25519@openssh.com",
+	"sk-ecdsa-sha2-nistp256@openssh.com",
+}
+
+// validateUserName checks that the user or group name can be used on the system
+func validateUserName(name string) error {
+	if !userNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid user or group name: %s", name)
+	}
+	return nil
+}
+
+// validateSSHKey checks that the line is an OpenSSH public key
+// It is of the form TYPE BASE64-BLOB [COMMENT] and the blob starts with the key type
+func validateSSHKey(key string) error {
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return fmt.Errorf("not an ssh public key: %s", key)
+	}
+	if !isStringInList(sshKeyTypes, fields[0]) {
+		return fmt.Errorf("unknown ssh key type: %s", fields[0])
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return fmt.Errorf("invalid %s key data: %s", fields[0], err)
+	}
+	if len(blob) < 4 {
+		return fmt.Errorf("invalid %s key data", fields[0])
+	}
+	n := binary.BigEndian.Uint32(blob[:4])
+	if uint64(n)+4 > uint64(len(blob)) || string(blob[4:4+n]) != fields[0] {
+		return fmt.Errorf("invalid %s key data", fields[0])
+	}
+	return nil
+}
+
+// readSSHKeys reads the ssh public keys from a file
+// Empty lines and comments are skipped, the keys are returned one per line
+func readSSHKeys(filename string) (string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(string(data), "PRIVATE KEY") {
+		return "", fmt.Errorf("%s is a private key, use the .pub file", filename)
+	}
+	var keys []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := validateSSHKey(line); err != nil {
+			return "", fmt.Errorf("%s: %s", filename, err)
+		}
+		keys = append(keys, line)
+	}
+	if len(keys) == 0 {
+		return "", fmt.Errorf("no ssh public keys in %s", filename)
+	}
+	return strings.Join(keys, "\n"), nil
+}
+
+// findUser returns the index of the user in the customizations, or -1
+func findUser(c *weldr.Customizations, name string) int {
+	for i := range c.User {
+		if c.User[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func customizeUserAdd(cmd *cobra.Command, args []string) error {
+	if len(userName) == 0 {
+		return root.ExecutionError(cmd, "Customize Error: --name is required")
+	}
+	user := weldr.UserCustomization{
+		Name:        userName,
+		Description: userDescription,
+		Home:        userHome,
+		Shell:       userShell,
+		Groups:      root.GetCommaArgs(userGroups),
+	}
+	if err := validateUserName(user.Name); err != nil {
+		return root.ExecutionError(cmd, "Customize Error: %s", err)
+	}
+	for _, g := range user.Groups {
+		if err := validateUserName(g); err != nil {
+			return root.ExecutionError(cmd, "Customize Error: %s", err)
+		}
+	}
+	if len(user.Home) > 0 && !strings.HasPrefix(user.Home, "/") {
+		return root.ExecutionError(cmd, "Customize Error: home must be an absolute path: %s", user.Home)
+	}
+	if len(user.Shell) > 0 && !strings.HasPrefix(user.Shell, "/") {
+		return root.ExecutionError(cmd, "Customize Error: shell must be an absolute path: %s", user.Shell)
+	}
+	if userUID >= 0 {
+		uid := userUID
+		user.UID = &uid
+	}
+	if userGID >= 0 {
+		gid := userGID
+		user.GID = &gid
+	}
+	if len(userKeyFile) > 0 {
+		key, err := readSSHKeys(userKeyFile)
+		if err != nil {
+			return root.ExecutionError(cmd, "Customize Error: %s", err)
+		}
+		user.Key = key
+	}
+
+	return customizeBlueprint(cmd, args[0], func(c *weldr.Customizations) (string, error) {
+		if findUser(c, user.Name) >= 0 {
+			return "", fmt.Errorf("user %s already exists", user.Name)
+		}
+		c.User = append(c.User, user)
+		return fmt.Sprintf("added user %s", user.Name), nil
+	})
+}
+
+func customizeUserRemove(cmd *cobra.Command, args []string) error {
+	name := args[1]
+	return customizeBlueprint(cmd, args[0], func(c *weldr.Customizations) (string, error) {
+		i := findUser(c, name)
+		if i < 0 {
+			return "", fmt.Errorf("user %s does not exist", name)
+		}
+		c.User = append(c.User[:i], c.User[i+1:]...)
+		return fmt.Sprintf("removed user %s", name), nil
+	})
+}
+
+func customizeSSHKeyAdd(cmd *cobra.Command, args []string) error {
+	name := args[1]
+	if err := validateUserName(name); err != nil {
+		return root.ExecutionError(cmd, "Customize Error: %s", err)
+	}
+	if len(userKeyFile) == 0 {
+		return root.ExecutionError(cmd, "Customize Error: --key-file is required")
+	}
+	key, err := readSSHKeys(userKeyFile)
+	if err != nil {
+		return root.ExecutionError(cmd, "Customize Error: %s", err)
+	}
+
+	return customizeBlueprint(cmd, args[0], func(c *weldr.Customizations) (string, error) {
+		for i := range c.SSHKey {
+			if c.SSHKey[i].User == name {
+				c.SSHKey[i].Key = key
+				return fmt.Sprintf("replaced ssh key for %s", name), nil
+			}
+		}
+		c.SSHKey = append(c.SSHKey, weldr.SSHKeyCustomization{User: name, Key: key})
+		return fmt.Sprintf("added ssh key for %s", name), nil
+	})
+}
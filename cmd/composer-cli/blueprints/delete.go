@@ -5,6 +5,11 @@
 package blueprints
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
@@ -12,20 +17,94 @@ import (
 
 var (
 	deleteCmd = &cobra.Command{
-		Use:   "delete BLUEPRINT",
+		Use:   "delete BLUEPRINT [--yes]",
 		Short: "Delete the blueprint from the server",
-		Long:  "Delete the blueprint from the server",
-		RunE:  delete,
-		Args:  cobra.ExactArgs(1),
+		Long: `Delete the blueprint from the server
+
+It warns about waiting or running composes using the blueprint and asks for
+confirmation unless --yes is used. A copy of the blueprint is saved to a
+temporary file so that it can be pushed again to restore it.`,
+		RunE: delete,
+		Args: cobra.ExactArgs(1),
 	}
+	deleteYes bool
 )
 
 func init() {
+	deleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Delete the blueprint without asking for confirmation")
 	blueprintsCmd.AddCommand(deleteCmd)
 }
 
+// activeComposes returns the ids of the waiting and running composes using the blueprint
+func activeComposes(name string) ([]string, error) {
+	composes, errors, err := root.Client.ListComposes()
+	if err != nil {
+		return nil, err
+	}
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("%s: %s", errors[0].ID, errors[0].Msg)
+	}
+	var ids []string
+	for _, c := range composes {
+		if c.Blueprint == name && (c.Status == "WAITING" || c.Status == "RUNNING") {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids, nil
+}
+
 func delete(cmd *cobra.Command, args []string) error {
-	resp, err := root.Client.DeleteBlueprint(args[0])
+	name := args[0]
+	if root.JSONOutput {
+		// The preview and prompt cannot be shown with the raw JSON output
+		if !deleteYes {
+			return root.ExecutionError(cmd, "Delete Error: --yes is required with --json")
+		}
+		return deleteBlueprint(cmd, name)
+	}
+
+	blueprints, resp, err := root.Client.GetBlueprintsTOML([]string{name})
+	if err != nil {
+		return root.ExecutionError(cmd, "Delete Error: %s", err)
+	}
+	if resp != nil && !resp.Status {
+		return root.ExecutionErrors(cmd, resp.Errors)
+	}
+	if len(blueprints) != 1 {
+		return root.ExecutionError(cmd, "Delete Error: missing blueprint %s", name)
+	}
+
+	ids, err := activeComposes(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: cannot check the composes using %s: %s\n", name, err)
+	} else if len(ids) > 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: %s is used by waiting or running composes: %s\n", name, strings.Join(ids, ", "))
+	}
+	if !deleteYes && !root.Confirm(fmt.Sprintf("Delete blueprint %s?", name)) {
+		return root.ExecutionError(cmd, "Delete cancelled")
+	}
+
+	// Keep a copy so that the blueprint can be restored
+	f, err := ioutil.TempFile("", name+"-*.toml")
+	if err != nil {
+		return root.ExecutionError(cmd, "Delete Error: %s", err)
+	}
+	_, err = f.Write([]byte(blueprints[0]))
+	f.Close()
+	if err != nil {
+		return root.ExecutionError(cmd, "Delete Error: %s", err)
+	}
+
+	if err := deleteBlueprint(cmd, name); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	fmt.Printf("Deleted %s, to restore it run: composer-cli blueprints push %s\n", name, f.Name())
+	return nil
+}
+
+func deleteBlueprint(cmd *cobra.Command, name string) error {
+	resp, err := root.Client.DeleteBlueprint(name)
 	if err != nil {
 		return root.ExecutionError(cmd, "Delete Error: %s", err)
 	}
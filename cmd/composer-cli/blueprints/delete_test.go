@@ -8,6 +8,8 @@ import (
 	"bytes"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -16,18 +18,41 @@ import (
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
 )
 
-func TestCmdBlueprintsDelete(t *testing.T) {
-	// Test the "blueprints delete" command
-	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
-		json := `{"status": true}`
-
+// deleteTestServer returns the blueprint and the composes using it
+// Blueprints other than cli-test-bp-1 are unknown.
+func deleteTestServer(request *http.Request) (*http.Response, error) {
+	var json string
+	switch request.URL.Path {
+	case "/api/v1/blueprints/info/cli-test-bp-1":
+		json = `name = "cli-test-bp-1"`
+	case "/api/v1/compose/queue":
+		json = `{"new": [{"id": "id-1", "blueprint": "cli-test-bp-1", "queue_status": "WAITING"}],
+			 "run": [{"id": "id-2", "blueprint": "other-bp", "queue_status": "RUNNING"}]}`
+	case "/api/v1/compose/finished":
+		json = `{"finished": [{"id": "id-3", "blueprint": "cli-test-bp-1", "queue_status": "FINISHED"}]}`
+	case "/api/v1/compose/failed":
+		json = `{"failed": []}`
+	case "/api/v1/blueprints/delete/cli-test-bp-1":
+		json = `{"status": true}`
+	default:
+		json = `{"status": false, "errors": [{"id": "UnknownBlueprint", "msg": "foo-bp-1: "}]}`
 		return &http.Response{
-			StatusCode: 200,
+			Request:    request,
+			StatusCode: 400,
 			Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
 		}, nil
-	})
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
+	}, nil
+}
+
+func TestCmdBlueprintsDelete(t *testing.T) {
+	// Test the "blueprints delete" command
+	mc := root.SetupCmdTest(deleteTestServer)
 
-	cmd, out, err := root.ExecuteTest("blueprints", "delete", "cli-test-bp-1")
+	cmd, out, err := root.ExecuteTest("blueprints", "delete", "--yes", "cli-test-bp-1")
 	require.NotNil(t, out)
 	defer out.Close()
 	require.Nil(t, err)
@@ -37,14 +62,40 @@ func TestCmdBlueprintsDelete(t *testing.T) {
 	assert.Equal(t, cmd, deleteCmd)
 	stdout, err := ioutil.ReadAll(out.Stdout)
 	assert.Nil(t, err)
-	assert.Equal(t, []byte(""), stdout)
+	prefix := "Deleted cli-test-bp-1, to restore it run: composer-cli blueprints push "
+	require.True(t, strings.HasPrefix(string(stdout), prefix))
+	backup := strings.TrimSpace(strings.TrimPrefix(string(stdout), prefix))
+	defer os.Remove(backup)
+	data, err := ioutil.ReadFile(backup)
+	require.Nil(t, err)
+	assert.Equal(t, `name = "cli-test-bp-1"`, string(data))
 	stderr, err := ioutil.ReadAll(out.Stderr)
 	assert.Nil(t, err)
-	assert.Equal(t, []byte(""), stderr)
+	assert.Equal(t, "WARNING: cli-test-bp-1 is used by waiting or running composes: id-1\n", string(stderr))
 	assert.Equal(t, "DELETE", mc.Req.Method)
 	assert.Equal(t, "/api/v1/blueprints/delete/cli-test-bp-1", mc.Req.URL.Path)
 }
 
+func TestCmdBlueprintsDeleteConfirm(t *testing.T) {
+	// Answering no cancels the delete
+	restore, err := root.SetupStdin("n\n")
+	require.Nil(t, err)
+	defer restore()
+	mc := root.SetupCmdTest(deleteTestServer)
+	cmd, out, err := root.ExecuteTest("blueprints", "delete", "--yes=false", "cli-test-bp-1")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "Delete blueprint cli-test-bp-1? [y/N] ", string(stdout))
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: Delete cancelled\n")
+	assert.Equal(t, "GET", mc.Req.Method)
+}
+
 func TestCmdBlueprintsDeleteJSON(t *testing.T) {
 	// Test the "blueprints delete" command
 	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
@@ -56,7 +107,7 @@ func TestCmdBlueprintsDeleteJSON(t *testing.T) {
 		}, nil
 	})
 
-	cmd, out, err := root.ExecuteTest("--json", "blueprints", "delete", "cli-test-bp-1")
+	cmd, out, err := root.ExecuteTest("--json", "blueprints", "delete", "--yes", "cli-test-bp-1")
 	require.NotNil(t, out)
 	defer out.Close()
 	require.Nil(t, err)
@@ -77,24 +128,12 @@ func TestCmdBlueprintsDeleteJSON(t *testing.T) {
 
 func TestCmdBlueprintsDeleteUnknown(t *testing.T) {
 	// Test the "blueprints delete" command with an unknown blueprint
-	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
-		json := `{
-"errors": [{
-	"id": "BlueprintsError",
-	"msg": "Unknown blueprint: foo-bp-1"
-	}],
-"status": false                                        
-}`
-		return &http.Response{
-			StatusCode: 200,
-			Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
-		}, nil
-	})
+	mc := root.SetupCmdTest(deleteTestServer)
 
-	cmd, out, err := root.ExecuteTest("blueprints", "delete", "foo-bp-1")
+	cmd, out, err := root.ExecuteTest("blueprints", "delete", "--yes", "foo-bp-1")
 	require.NotNil(t, out)
 	defer out.Close()
-	require.Nil(t, err)
+	require.NotNil(t, err)
 	require.NotNil(t, out.Stdout)
 	require.NotNil(t, out.Stderr)
 	require.NotNil(t, cmd)
@@ -104,9 +143,9 @@ func TestCmdBlueprintsDeleteUnknown(t *testing.T) {
 	assert.Equal(t, []byte(""), stdout)
 	stderr, err := ioutil.ReadAll(out.Stderr)
 	assert.Nil(t, err)
-	assert.Equal(t, []byte(""), stderr)
-	assert.Equal(t, "DELETE", mc.Req.Method)
-	assert.Equal(t, "/api/v1/blueprints/delete/foo-bp-1", mc.Req.URL.Path)
+	assert.Equal(t, "ERROR: UnknownBlueprint: foo-bp-1: \n", string(stderr))
+	assert.Equal(t, "GET", mc.Req.Method)
+	assert.Equal(t, "/api/v1/blueprints/info/foo-bp-1", mc.Req.URL.Path)
 }
 
 func TestCmdBlueprintsDeleteJSONUnknown(t *testing.T) {
@@ -125,7 +164,7 @@ func TestCmdBlueprintsDeleteJSONUnknown(t *testing.T) {
 		}, nil
 	})
 
-	cmd, out, err := root.ExecuteTest("--json", "blueprints", "delete", "foo-bp-1")
+	cmd, out, err := root.ExecuteTest("--json", "blueprints", "delete", "--yes", "foo-bp-1")
 	require.NotNil(t, out)
 	defer out.Close()
 	require.Nil(t, err)
@@ -13,46 +13,55 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
 )
 
 var (
 	depsolveCmd = &cobra.Command{
-		Use:   "depsolve BLUEPRINT,...",
+		Use:   "depsolve BLUEPRINT,... [--summary [--baseline FILE] [--save-baseline FILE]]",
 		Short: "Depsolve the blueprints and output the package lists",
-		Long:  "Depsolve the blueprints and output the package lists",
-		RunE:  depsolve,
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Depsolve the blueprints and output the package lists
+
+With --summary the number of packages, the number for each architecture and the
+packages and modules that pull in the most packages are listed instead. The
+summary can be saved as a JSON baseline with --save-baseline and compared with a
+baseline using --baseline, which returns an error when more than --threshold
+packages were added to a blueprint. Packages are compared by name and
+architecture, so replacing one package with another is reported even when the
+number of packages is the same. The server does not report the size of the
+packages so only the packages themselves are compared.`,
+		RunE: depsolve,
+		Args: cobra.MinimumNArgs(1),
 	}
+	depsolveSummary  bool
+	summaryBaseline  string
+	summarySave      string
+	summaryThreshold int
 )
 
 func init() {
+	depsolveCmd.Flags().BoolVarP(&depsolveSummary, "summary", "", false, "Summarize the package lists")
+	depsolveCmd.Flags().StringVarP(&summaryBaseline, "baseline", "", "", "Compare the summary with a JSON baseline")
+	depsolveCmd.Flags().StringVarP(&summarySave, "save-baseline", "", "", "Save the summary as a JSON baseline")
+	depsolveCmd.Flags().IntVarP(&summaryThreshold, "threshold", "", 0, "Number of packages a blueprint can grow by before it is an error")
 	blueprintsCmd.AddCommand(depsolveCmd)
 }
 
-type pkg struct {
-	Name    string
-	Epoch   int
-	Version string
-	Release string
-	Arch    string
-}
-
-func (p pkg) String() string {
-	if p.Epoch == 0 {
-		return fmt.Sprintf("%s-%s-%s.%s", p.Name, p.Version, p.Release, p.Arch)
-	}
-	return fmt.Sprintf("%d:%s-%s-%s.%s", p.Epoch, p.Name, p.Version, p.Release, p.Arch)
-}
-
 type depsolvedBlueprint struct {
 	Blueprint struct {
-		Name    string
-		Version string
+		Name     string
+		Version  string
+		Distro   string
+		Packages []weldr.Package
+		Modules  []weldr.Package
 	}
-	Dependencies []pkg
+	Dependencies []weldr.PackageNEVRA
 }
 
 func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
+	if !depsolveSummary && (len(summaryBaseline) > 0 || len(summarySave) > 0) {
+		return root.ExecutionError(cmd, "Depsolve Error: --baseline and --save-baseline need --summary")
+	}
 	names := root.GetCommaArgs(args)
 	bps, errors, err := root.Client.DepsolveBlueprints(names)
 	if err != nil {
@@ -60,8 +69,10 @@ func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
 	}
 	if len(errors) > 0 {
 		rcErr = root.ExecutionErrors(cmd, errors)
+		root.PrintBlueprintSuggestions(errors, names)
 	}
 
+	var summaries []blueprintSummary
 	for _, bp := range bps {
 		// Encode it using json
 		data := new(bytes.Buffer)
@@ -79,12 +90,23 @@ func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
 			continue
 		}
 
+		if depsolveSummary {
+			summaries = append(summaries, summarize(parts))
+			continue
+		}
+
 		fmt.Printf("blueprint: %s v%s\n", parts.Blueprint.Name, parts.Blueprint.Version)
 		for _, d := range parts.Dependencies {
 			fmt.Printf("    %s\n", d)
 		}
 	}
 
+	if depsolveSummary {
+		if err := reportSummaries(summaries); err != nil {
+			rcErr = root.ExecutionError(cmd, "Depsolve Error: %s", err)
+		}
+	}
+
 	// If there were any errors, even if other blueprints succeeded, it returns an error
 	return rcErr
 }
@@ -0,0 +1,209 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+// summaryContributors is the number of packages listed as the largest contributors
+const summaryContributors = 5
+
+// contributor is a top level package and the number of packages it pulls in
+type contributor struct {
+	Name     string `json:"name"`
+	Packages int    `json:"packages"`
+}
+
+// blueprintSummary describes the size of a depsolved blueprint
+// It is also the format of the JSON baseline.
+type blueprintSummary struct {
+	Name         string         `json:"name"`
+	Version      string         `json:"version"`
+	Packages     int            `json:"packages"`
+	Arches       map[string]int `json:"arches"`
+	Contributors []contributor  `json:"contributors"`
+	Dependencies []string       `json:"dependencies,omitempty"`
+}
+
+// packageKeys returns the sorted name.arch of the packages, without duplicates
+// Packages like the kernel can have more than one version installed, they are only
+// counted once.
+func packageKeys(pkgs []weldr.PackageNEVRA) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, p := range pkgs {
+		k := p.Name + "." + p.Arch
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffKeys returns the sorted keys that are only in after, and the ones only in before
+func diffKeys(before, after []string) (added, removed []string) {
+	inBefore := make(map[string]bool)
+	for _, k := range before {
+		inBefore[k] = true
+	}
+	inAfter := make(map[string]bool)
+	for _, k := range after {
+		inAfter[k] = true
+		if !inBefore[k] {
+			added = append(added, k)
+		}
+	}
+	for _, k := range before {
+		if !inAfter[k] {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// baselineGrowth returns the number of packages added since the baseline
+// Baselines saved without the dependencies can only be compared by the number of packages.
+func baselineGrowth(s, baseline blueprintSummary) int {
+	if baseline.Dependencies == nil {
+		return s.Packages - baseline.Packages
+	}
+	added, _ := diffKeys(baseline.Dependencies, s.Dependencies)
+	return len(added)
+}
+
+// summarize counts the depsolved packages and finds the largest contributors
+// Each of the blueprint's packages and modules is depsolved on its own to find
+// out how many packages it pulls in.
+func summarize(parts depsolvedBlueprint) blueprintSummary {
+	s := blueprintSummary{
+		Name:         parts.Blueprint.Name,
+		Version:      parts.Blueprint.Version,
+		Packages:     len(parts.Dependencies),
+		Arches:       make(map[string]int),
+		Dependencies: packageKeys(parts.Dependencies),
+	}
+	for _, d := range parts.Dependencies {
+		s.Arches[d.Arch]++
+	}
+
+	names := topLevelNames(weldr.Blueprint{Packages: parts.Blueprint.Packages, Modules: parts.Blueprint.Modules})
+	deps, errs := packageClosures(names, parts.Blueprint.Distro)
+	for _, name := range names {
+		if err, ok := errs[name]; ok {
+			fmt.Fprintf(os.Stderr, "WARNING: %s: %s\n", name, err)
+			continue
+		}
+		s.Contributors = append(s.Contributors, contributor{Name: name, Packages: len(deps[name])})
+	}
+	sort.SliceStable(s.Contributors, func(i, j int) bool {
+		return s.Contributors[i].Packages > s.Contributors[j].Packages
+	})
+	if len(s.Contributors) > summaryContributors {
+		s.Contributors = s.Contributors[:summaryContributors]
+	}
+	return s
+}
+
+// printSummary prints the summary, and the change from the baseline if there is one
+func printSummary(s blueprintSummary, baseline *blueprintSummary) {
+	fmt.Printf("blueprint: %s v%s\n", s.Name, s.Version)
+	fmt.Printf("    packages: %d\n", s.Packages)
+	var arches []string
+	for arch := range s.Arches {
+		arches = append(arches, arch)
+	}
+	sort.Strings(arches)
+	for _, arch := range arches {
+		fmt.Printf("    %s: %d\n", arch, s.Arches[arch])
+	}
+	if len(s.Contributors) > 0 {
+		fmt.Printf("    largest:\n")
+		for _, c := range s.Contributors {
+			fmt.Printf("        %s: %d packages\n", c.Name, c.Packages)
+		}
+	}
+	if baseline != nil {
+		fmt.Printf("    baseline: %d packages, %+d\n", baseline.Packages, s.Packages-baseline.Packages)
+		if baseline.Dependencies != nil {
+			added, removed := diffKeys(baseline.Dependencies, s.Dependencies)
+			for _, k := range added {
+				fmt.Printf("        added: %s\n", k)
+			}
+			for _, k := range removed {
+				fmt.Printf("        removed: %s\n", k)
+			}
+		}
+	}
+}
+
+// readBaseline reads the summaries from a JSON baseline file
+func readBaseline(filename string) (map[string]blueprintSummary, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var summaries []blueprintSummary
+	if err := json.Unmarshal(data, &summaries); err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	baseline := make(map[string]blueprintSummary)
+	for _, s := range summaries {
+		baseline[s.Name] = s
+	}
+	return baseline, nil
+}
+
+// reportSummaries prints the summaries and compares them with the baseline
+// It returns an error if a blueprint has grown by more than the threshold.
+func reportSummaries(summaries []blueprintSummary) error {
+	var baseline map[string]blueprintSummary
+	if len(summaryBaseline) > 0 {
+		var err error
+		baseline, err = readBaseline(summaryBaseline)
+		if err != nil {
+			return err
+		}
+	}
+
+	var grown []string
+	for _, s := range summaries {
+		b, ok := baseline[s.Name]
+		if !ok {
+			printSummary(s, nil)
+			continue
+		}
+		printSummary(s, &b)
+		if growth := baselineGrowth(s, b); growth > summaryThreshold {
+			grown = append(grown, fmt.Sprintf("%s (%+d)", s.Name, growth))
+		}
+	}
+
+	if len(summarySave) > 0 {
+		data, err := json.MarshalIndent(summaries, "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(summarySave, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+	}
+
+	if len(grown) > 0 {
+		return fmt.Errorf("more than %d packages added to %s", summaryThreshold, strings.Join(grown, ", "))
+	}
+	return nil
+}
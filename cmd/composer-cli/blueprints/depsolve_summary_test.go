@@ -0,0 +1,155 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+)
+
+const summaryTestDepsolve = `{"blueprints": [{
+	"blueprint": {"name": "summary-bp", "version": "0.1.0", "distro": "fedora-summary",
+		"packages": [{"name": "tmux", "version": "*"}, {"name": "bash", "version": "*"}]},
+	"dependencies": [
+		{"name": "bash", "epoch": 0, "version": "5.1.8", "release": "1.fc34", "arch": "x86_64"},
+		{"name": "glibc", "epoch": 0, "version": "2.33", "release": "5.fc34", "arch": "x86_64"},
+		{"name": "tmux", "epoch": 0, "version": "3.2a", "release": "1.fc34", "arch": "x86_64"},
+		{"name": "tzdata", "epoch": 0, "version": "2021a", "release": "1.fc34", "arch": "noarch"}
+	]}], "errors": []}`
+
+var summaryTestClosures = map[string][]string{
+	"bash": {"bash", "glibc", "tzdata"},
+	"tmux": {"glibc", "tmux"},
+}
+
+func summaryTestServer(request *http.Request) (*http.Response, error) {
+	var body []byte
+	if strings.HasPrefix(request.URL.Path, "/api/v1/projects/depsolve/") {
+		var projects []map[string]string
+		for _, d := range summaryTestClosures[strings.TrimPrefix(request.URL.Path, "/api/v1/projects/depsolve/")] {
+			projects = append(projects, map[string]string{"name": d})
+		}
+		body, _ = json.Marshal(map[string]interface{}{"projects": projects})
+	} else {
+		body = []byte(summaryTestDepsolve)
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+	}, nil
+}
+
+func runSummary(t *testing.T, args ...string) (string, string, error) {
+	defer func() {
+		depsolveSummary = false
+		summaryBaseline = ""
+		summarySave = ""
+		summaryThreshold = 0
+	}()
+	return executeCmd(t, summaryTestServer, append([]string{"blueprints", "depsolve", "--summary"}, args...)...)
+}
+
+func TestCmdBlueprintsDepsolveSummary(t *testing.T) {
+	stdout, stderr, err := runSummary(t, "summary-bp")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, `blueprint: summary-bp v0.1.0
+    packages: 4
+    noarch: 1
+    x86_64: 3
+    largest:
+        bash: 3 packages
+        tmux: 2 packages
+`, stdout)
+}
+
+func TestCmdBlueprintsDepsolveBaseline(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "test-baseline-")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+	baseline := filepath.Join(tmpdir, "baseline.json")
+
+	_, _, err = runSummary(t, "--save-baseline", baseline, "summary-bp")
+	require.Nil(t, err)
+	data, err := ioutil.ReadFile(baseline)
+	require.Nil(t, err)
+	var saved []blueprintSummary
+	require.Nil(t, json.Unmarshal(data, &saved))
+	require.Equal(t, 1, len(saved))
+	assert.Equal(t, 4, saved[0].Packages)
+	assert.Equal(t, map[string]int{"x86_64": 3, "noarch": 1}, saved[0].Arches)
+	assert.Equal(t, []string{"bash.x86_64", "glibc.x86_64", "tmux.x86_64", "tzdata.noarch"}, saved[0].Dependencies)
+
+	// Shrink the baseline so that the blueprint has grown by 2 packages
+	saved[0].Packages = 2
+	saved[0].Dependencies = []string{"bash.x86_64", "glibc.x86_64"}
+	writeBaseline(t, baseline, saved)
+
+	stdout, stderr, err := runSummary(t, "--baseline", baseline, "summary-bp")
+	require.NotNil(t, err)
+	assert.Contains(t, stdout, "    baseline: 2 packages, +2\n        added: tmux.x86_64\n        added: tzdata.noarch\n")
+	assert.Equal(t, "ERROR: Depsolve Error: more than 0 packages added to summary-bp (+2)\n", stderr)
+
+	_, _, err = runSummary(t, "--baseline", baseline, "--threshold", "2", "summary-bp")
+	require.Nil(t, err)
+}
+
+func TestCmdBlueprintsDepsolveBaselineReplaced(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "test-baseline-")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+	baseline := filepath.Join(tmpdir, "baseline.json")
+
+	// The same number of packages, but tzdata replaced ncurses-libs
+	writeBaseline(t, baseline, []blueprintSummary{{
+		Name:         "summary-bp",
+		Version:      "0.1.0",
+		Packages:     4,
+		Arches:       map[string]int{"x86_64": 4},
+		Dependencies: []string{"bash.x86_64", "glibc.x86_64", "ncurses-libs.x86_64", "tmux.x86_64"},
+	}})
+	stdout, stderr, err := runSummary(t, "--baseline", baseline, "summary-bp")
+	require.NotNil(t, err)
+	assert.Contains(t, stdout, "    baseline: 4 packages, +0\n        added: tzdata.noarch\n        removed: ncurses-libs.x86_64\n")
+	assert.Equal(t, "ERROR: Depsolve Error: more than 0 packages added to summary-bp (+1)\n", stderr)
+
+	// A baseline without the dependencies only compares the number of packages
+	writeBaseline(t, baseline, []blueprintSummary{{Name: "summary-bp", Version: "0.1.0", Packages: 4}})
+	stdout, stderr, err = runSummary(t, "--baseline", baseline, "summary-bp")
+	require.Nil(t, err)
+	assert.Contains(t, stdout, "    baseline: 4 packages, +0\n")
+	assert.NotContains(t, stdout, "added:")
+	assert.Equal(t, "", stderr)
+}
+
+func writeBaseline(t *testing.T, filename string, summaries []blueprintSummary) {
+	data, err := json.Marshal(summaries)
+	require.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(filename, data, 0644))
+}
+
+func TestCmdBlueprintsDepsolveBaselineNoSummary(t *testing.T) {
+	root.SetupCmdTest(summaryTestServer)
+	defer func() { summaryBaseline = "" }()
+	cmd, out, err := root.ExecuteTest("blueprints", "depsolve", "--summary=false", "--baseline", "baseline.json", "summary-bp")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, cmd)
+	require.NotNil(t, err)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, err)
+	assert.Equal(t, "ERROR: Depsolve Error: --baseline and --save-baseline need --summary\n", string(stderr))
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -191,7 +192,9 @@ func TestCmdBlueprintsBadDepsolve(t *testing.T) {
             "msg": "cli-test-bp-1: DNF error occured: MarkingErrors: Error occurred when marking packages for installation: Problems in request:\nmissing packages: themissing"
 		}
     ]}`
-	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+	var paths []string
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		paths = append(paths, request.URL.Path)
 		return &http.Response{
 			StatusCode: 200,
 			Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
@@ -214,8 +217,43 @@ func TestCmdBlueprintsBadDepsolve(t *testing.T) {
 	stderr, err := ioutil.ReadAll(out.Stderr)
 	assert.Nil(t, err)
 	assert.Contains(t, string(stderr), "BlueprintsError: cli-test-bp-1: DNF error occured:")
-	assert.Equal(t, "GET", mc.Req.Method)
-	assert.Equal(t, "/api/v1/blueprints/depsolve/cli-test-bp-1", mc.Req.URL.Path)
+	// The depsolve is followed by the requests looking for similar package names
+	require.Greater(t, len(paths), 0)
+	assert.Equal(t, "/api/v1/blueprints/depsolve/cli-test-bp-1", paths[0])
+}
+
+func TestCmdBlueprintsBadDepsolveDistro(t *testing.T) {
+	// The similar package names are looked up in the blueprint's distro
+	var listQuery string
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		path := strings.TrimPrefix(request.URL.Path, "/api/v1")
+		var body string
+		switch path {
+		case "/blueprints/depsolve/cli-test-bp-1":
+			body = `{"blueprints": [], "errors": [{"id": "BlueprintsError",
+				"msg": "cli-test-bp-1: DNF error occured: MarkingErrors: Error occurred when marking packages for installation: Problems in request:\nmissing packages: vim-enchanced"}]}`
+		case "/blueprints/info/cli-test-bp-1":
+			body = `{"blueprints": [{"name": "cli-test-bp-1", "distro": "fedora-35"}], "changes": [], "errors": []}`
+		case "/projects/list":
+			listQuery = request.URL.Query().Get("distro")
+			body = `{"projects": [{"name": "vim-enhanced"}], "total": 1, "offset": 0, "limit": 1}`
+		default:
+			body = `{"sources": [], "projects": []}`
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	})
+
+	_, out, err := root.ExecuteTest("blueprints", "depsolve", "cli-test-bp-1")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "vim-enchanced was not found, did you mean:\n    vim-enhanced")
+	assert.Equal(t, "fedora-35", listQuery)
 }
 
 func TestCmdBlueprintsBadDepsolveJSON(t *testing.T) {
@@ -272,3 +310,25 @@ func TestCmdBlueprintsBadDepsolveJSON(t *testing.T) {
 	assert.Equal(t, "GET", mc.Req.Method)
 	assert.Equal(t, "/api/v1/blueprints/depsolve/cli-test-bp-1", mc.Req.URL.Path)
 }
+
+func TestCmdBlueprintsDepsolveEpoch(t *testing.T) {
+	// The epoch goes before the version, not before the name
+	json := `{"blueprints": [{
+		"blueprint": {"name": "cli-test-bp-1", "version": "0.0.1"},
+		"dependencies": [{"name": "shadow-utils", "epoch": 2, "version": "4.9", "release": "8.fc35", "arch": "x86_64"}]
+	}], "errors": []}`
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	_, out, err := root.ExecuteTest("blueprints", "depsolve", "cli-test-bp-1")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "blueprint: cli-test-bp-1 v0.0.1\n    shadow-utils-2:4.9-8.fc35.x86_64\n", string(stdout))
+}
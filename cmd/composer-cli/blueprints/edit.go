@@ -0,0 +1,159 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+)
+
+var (
+	editCmd = &cobra.Command{
+		Use:   "edit BLUEPRINT",
+		Short: "Edit a blueprint using $EDITOR",
+		Long: `Edit the blueprint in $EDITOR and push the changes to the server.
+If the blueprint has changes in the workspace those are edited instead of the most recent commit.
+When the editor exits the changes are checked and shown as a diff and they can then be
+committed as a new blueprint version, saved to the workspace, or edited again.`,
+		RunE: edit,
+		Args: cobra.ExactArgs(1),
+	}
+)
+
+func init() {
+	blueprintsCmd.AddCommand(editCmd)
+}
+
+// editorCommand returns the editor and its arguments
+// $EDITOR may include arguments, eg. "emacs -nw", it falls back to vi if it is not set.
+func editorCommand() []string {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		return []string{"vi"}
+	}
+	return editor
+}
+
+// runEditor runs the user's editor on filename and waits for it to exit
+func runEditor(filename string) error {
+	editor := editorCommand()
+	c := exec.Command(editor[0], append(editor[1:], filename)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
+
+func edit(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	bps, resp, err := root.Client.GetBlueprintsTOML([]string{name})
+	if err != nil {
+		return root.ExecutionError(cmd, "Edit Error: %s", err)
+	}
+	if resp != nil && !resp.Status {
+		return root.ExecutionErrors(cmd, resp.Errors)
+	}
+	if len(bps) == 0 {
+		return root.ExecutionError(cmd, "Edit Error: %s was not found", name)
+	}
+	original := bps[0]
+
+	tmpFile, err := ioutil.TempFile("", "composer-cli-edit-*.toml")
+	if err != nil {
+		return root.ExecutionError(cmd, "Edit Error: %s", err)
+	}
+	// The file is kept if the user aborts after making changes
+	keepFile := false
+	defer func() {
+		if !keepFile {
+			os.Remove(tmpFile.Name())
+		}
+	}()
+	_, err = tmpFile.Write([]byte(original))
+	tmpFile.Close()
+	if err != nil {
+		return root.ExecutionError(cmd, "Edit Error: %s", err)
+	}
+
+	for {
+		if err := runEditor(tmpFile.Name()); err != nil {
+			keepFile = true
+			return root.ExecutionError(cmd, "Edit Error: running %s: %s (changes are in %s)",
+				editorCommand()[0], err, tmpFile.Name())
+		}
+		data, err := ioutil.ReadFile(tmpFile.Name())
+		if err != nil {
+			return root.ExecutionError(cmd, "Edit Error: %s", err)
+		}
+		edited := string(data)
+		if edited == original {
+			fmt.Printf("No changes made to %s\n", name)
+			return nil
+		}
+
+		// Make sure the new blueprint can be parsed before sending it to the server
+		if err := checkEditedBlueprint(name, edited); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			if root.Confirm("Edit the blueprint again?") {
+				continue
+			}
+			keepFile = true
+			return root.ExecutionError(cmd, "Changes have not been pushed, they are in %s", tmpFile.Name())
+		}
+
+		fmt.Print(unifiedDiff(name, name+" (edited)", original, edited))
+		answer, err := root.Prompt("(c)ommit, save to (w)orkspace, (e)dit again, or (a)bort? ")
+		if err != nil {
+			answer = "a"
+		}
+
+		switch strings.ToLower(answer) {
+		case "c", "commit":
+			resp, err = root.Client.PushBlueprintTOML(edited)
+		case "w", "workspace":
+			resp, err = root.Client.PushBlueprintWorkspaceTOML(edited)
+		case "e", "edit":
+			continue
+		default:
+			keepFile = true
+			fmt.Printf("Changes have not been pushed, they are in %s\n", tmpFile.Name())
+			return nil
+		}
+		if err != nil {
+			keepFile = true
+			return root.ExecutionError(cmd, "Push TOML Error: %s (changes are in %s)", err, tmpFile.Name())
+		}
+		if resp != nil && !resp.Status {
+			keepFile = true
+			fmt.Fprintf(os.Stderr, "ERROR: changes are in %s\n", tmpFile.Name())
+			return root.ExecutionErrors(cmd, resp.Errors)
+		}
+		return nil
+	}
+}
+
+// checkEditedBlueprint makes sure the TOML can be parsed and the name has not changed
+func checkEditedBlueprint(name, data string) error {
+	var bp map[string]interface{}
+	if _, err := toml.Decode(data, &bp); err != nil {
+		return fmt.Errorf("parsing blueprint: %s", err)
+	}
+	newName, ok := bp["name"].(string)
+	if !ok {
+		return fmt.Errorf("blueprint is missing the name")
+	}
+	if newName != name {
+		return fmt.Errorf("blueprint name cannot be changed from %s to %s", name, newName)
+	}
+	return nil
+}
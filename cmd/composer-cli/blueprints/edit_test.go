@@ -0,0 +1,221 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+)
+
+const editTestTOML = `name = "simple"
+description = "simple blueprint"
+version = "0.1.0"
+
+[[packages]]
+  name = "bash"
+  version = "*"
+`
+
+// setupEditor writes a shell script that runs the commands on the file passed to it
+// and sets $EDITOR to use it. It returns a function to restore $EDITOR and cleanup.
+func setupEditor(t *testing.T, commands string) func() {
+	dir, err := ioutil.TempDir("", "test-bp-editor-*")
+	require.Nil(t, err)
+	script := filepath.Join(dir, "editor.sh")
+	err = ioutil.WriteFile(script, []byte("#!/bin/sh\n"+commands+"\n"), 0700)
+	require.Nil(t, err)
+
+	editor, hadEditor := os.LookupEnv("EDITOR")
+	os.Setenv("EDITOR", script)
+	return func() {
+		if hadEditor {
+			os.Setenv("EDITOR", editor)
+		} else {
+			os.Unsetenv("EDITOR")
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+// editTestServer returns the blueprint TOML and records the pushed blueprint
+func editTestServer(pushed *string) func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		if request.Method == "POST" {
+			body, _ := ioutil.ReadAll(request.Body)
+			*pushed = string(body)
+			return &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"status": true}`))),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(editTestTOML))),
+		}, nil
+	}
+}
+
+func TestCmdBlueprintsEditCommit(t *testing.T) {
+	// Test the "blueprints edit" command
+	var pushed string
+	mc := root.SetupCmdTest(editTestServer(&pushed))
+	defer setupEditor(t, `sed -i 's/0.1.0/0.1.1/' "$1"`)()
+	restore, err := root.SetupStdin("c\n")
+	require.Nil(t, err)
+	defer restore()
+
+	cmd, out, err := root.ExecuteTest("blueprints", "edit", "simple")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, out.Stdout)
+	require.NotNil(t, out.Stderr)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, editCmd)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stdout), "-version = \"0.1.0\"")
+	assert.Contains(t, string(stdout), "+version = \"0.1.1\"")
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+	assert.Equal(t, "POST", mc.Req.Method)
+	assert.Equal(t, "/api/v1/blueprints/new", mc.Req.URL.Path)
+	assert.Contains(t, pushed, "version = \"0.1.1\"")
+}
+
+func TestCmdBlueprintsEditWorkspace(t *testing.T) {
+	// Test the "blueprints edit" command
+	var pushed string
+	mc := root.SetupCmdTest(editTestServer(&pushed))
+	defer setupEditor(t, `sed -i 's/simple blueprint/edited blueprint/' "$1"`)()
+	restore, err := root.SetupStdin("w\n")
+	require.Nil(t, err)
+	defer restore()
+
+	cmd, out, err := root.ExecuteTest("blueprints", "edit", "simple")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, editCmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+	assert.Equal(t, "POST", mc.Req.Method)
+	assert.Equal(t, "/api/v1/blueprints/workspace", mc.Req.URL.Path)
+	assert.Contains(t, pushed, "edited blueprint")
+}
+
+func TestCmdBlueprintsEditNoChange(t *testing.T) {
+	// Test the "blueprints edit" command
+	var pushed string
+	mc := root.SetupCmdTest(editTestServer(&pushed))
+	defer setupEditor(t, "true")()
+
+	cmd, out, err := root.ExecuteTest("blueprints", "edit", "simple")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, editCmd)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "No changes made to simple\n", string(stdout))
+	assert.Equal(t, "GET", mc.Req.Method)
+	assert.Equal(t, "/api/v1/blueprints/info/simple", mc.Req.URL.Path)
+	assert.Equal(t, "", pushed)
+}
+
+func TestCmdBlueprintsEditParseError(t *testing.T) {
+	// Test the "blueprints edit" command with broken TOML
+	var pushed string
+	root.SetupCmdTest(editTestServer(&pushed))
+	defer setupEditor(t, `sed -i 's/^\[\[packages\]\]/[[packages]/' "$1"`)()
+	restore, err := root.SetupStdin("n\n")
+	require.Nil(t, err)
+	defer restore()
+
+	cmd, out, err := root.ExecuteTest("blueprints", "edit", "simple")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, editCmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: parsing blueprint")
+	assert.Contains(t, string(stderr), "Changes have not been pushed")
+	assert.Equal(t, "", pushed)
+
+	// The edited file is kept so that the changes are not lost
+	fields := strings.Fields(strings.TrimSpace(string(stderr)))
+	kept := fields[len(fields)-1]
+	data, err := ioutil.ReadFile(kept)
+	require.Nil(t, err)
+	os.Remove(kept)
+	assert.Contains(t, string(data), "[[packages]\n")
+}
+
+func TestCmdBlueprintsEditReedit(t *testing.T) {
+	// Test the "blueprints edit" command fixing a parse error on the 2nd edit
+	var pushed string
+	mc := root.SetupCmdTest(editTestServer(&pushed))
+	defer setupEditor(t, `if grep -q BROKEN "$1"; then
+    sed -i 's/BROKEN/version = "0.2.0"/' "$1"
+else
+    sed -i 's/^version.*/BROKEN/' "$1"
+fi`)()
+	restore, err := root.SetupStdin("y\nc\n")
+	require.Nil(t, err)
+	defer restore()
+
+	cmd, out, err := root.ExecuteTest("blueprints", "edit", "simple")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, editCmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: parsing blueprint")
+	assert.Equal(t, "/api/v1/blueprints/new", mc.Req.URL.Path)
+	assert.Contains(t, pushed, "version = \"0.2.0\"")
+}
+
+func TestCmdBlueprintsEditUnknown(t *testing.T) {
+	// Test the "blueprints edit" command with an unknown blueprint
+	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{"status": false, "errors": [{"id": "UnknownBlueprint", "msg": "test-no-bp: blueprint not found"}]}`
+		return &http.Response{
+			Request:    request,
+			StatusCode: 400,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+	defer setupEditor(t, "true")()
+
+	cmd, out, err := root.ExecuteTest("blueprints", "edit", "test-no-bp")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, editCmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "UnknownBlueprint")
+	assert.Equal(t, "GET", mc.Req.Method)
+	assert.Equal(t, "/api/v1/blueprints/info/test-no-bp", mc.Req.URL.Path)
+}
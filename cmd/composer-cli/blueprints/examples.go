@@ -0,0 +1,195 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+//go:generate go run gen_examples.go
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	examplesCmd = &cobra.Command{
+		Use:   "examples ...",
+		Short: "Example blueprints",
+		Long:  "List, show, and push the example blueprints included with composer-cli",
+	}
+	examplesListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the example blueprints",
+		Long:  "List the names and descriptions of the example blueprints",
+		RunE:  examplesList,
+		Args:  cobra.NoArgs,
+	}
+	examplesShowCmd = &cobra.Command{
+		Use:   "show EXAMPLE",
+		Short: "Show an example blueprint",
+		Long:  "Show the TOML of an example blueprint",
+		RunE:  examplesShow,
+		Args:  cobra.ExactArgs(1),
+	}
+	examplesPushCmd = &cobra.Command{
+		Use:   "push EXAMPLE [--as NAME]",
+		Short: "Push an example blueprint to the server",
+		Long:  "Push an example blueprint to the server, optionally with a new name",
+		RunE:  examplesPush,
+		Args:  cobra.ExactArgs(1),
+	}
+	newCmd = &cobra.Command{
+		Use:   "new NAME [--from-example EXAMPLE]",
+		Short: "Create a new blueprint file",
+		Long:  "Create NAME.toml in the current directory, empty or copied from an example blueprint",
+		RunE:  newBlueprint,
+		Args:  cobra.ExactArgs(1),
+	}
+	examplesPushAs string
+	newFromExample string
+)
+
+func init() {
+	examplesCmd.AddCommand(examplesListCmd)
+	examplesCmd.AddCommand(examplesShowCmd)
+	examplesPushCmd.Flags().StringVarP(&examplesPushAs, "as", "", "", "Push the example with this name")
+	examplesCmd.AddCommand(examplesPushCmd)
+	blueprintsCmd.AddCommand(examplesCmd)
+	newCmd.Flags().StringVarP(&newFromExample, "from-example", "", "", "Start from this example blueprint")
+	blueprintsCmd.AddCommand(newCmd)
+}
+
+// newBlueprintTemplate is used by new when no example is selected
+const newBlueprintTemplate = `name = "%s"
+description = ""
+version = "0.0.1"
+
+[[packages]]
+name = "tmux"
+version = "*"
+`
+
+// blueprintNameRegex matches the names allowed by the server
+var blueprintNameRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// tomlNameRegex matches the blueprint's name line
+var tomlNameRegex = regexp.MustCompile(`(?m)^name\s*=\s*"[^"\n]*"[ \t]*$`)
+
+// exampleNames returns the short names of the examples, eg. http-server
+func exampleNames() []string {
+	var names []string
+	for f := range exampleBlueprints {
+		names = append(names, strings.TrimSuffix(strings.TrimPrefix(f, "example-"), ".toml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getExample returns the TOML of an example
+// The name may be the short name, or the name of the blueprint, eg. example-http-server
+func getExample(name string) (string, error) {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "example-"), ".toml")
+	data, ok := exampleBlueprints["example-"+name+".toml"]
+	if !ok {
+		return "", fmt.Errorf("unknown example %s, it should be one of: %s", name, strings.Join(exampleNames(), ", "))
+	}
+	return data, nil
+}
+
+// renameBlueprintTOML changes the name of the blueprint without changing anything else
+// Only the top level name, before any of the tables, is changed.
+func renameBlueprintTOML(data, name string) (string, error) {
+	if !blueprintNameRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid blueprint name: %s", name)
+	}
+	top := len(data)
+	if loc := regexp.MustCompile(`(?m)^\s*\[`).FindStringIndex(data); loc != nil {
+		top = loc[0]
+	}
+	loc := tomlNameRegex.FindStringIndex(data[:top])
+	if loc == nil {
+		return "", fmt.Errorf("blueprint has no name")
+	}
+	return data[:loc[0]] + fmt.Sprintf("name = %q", name) + data[loc[1]:], nil
+}
+
+func examplesList(cmd *cobra.Command, args []string) error {
+	for _, name := range exampleNames() {
+		bp, err := weldr.NewBlueprintFromTOML(exampleBlueprints["example-"+name+".toml"])
+		if err != nil {
+			return root.ExecutionError(cmd, "Examples Error: %s: %s", name, err)
+		}
+		fmt.Printf("%-16s %s\n", name, bp.Description)
+	}
+	return nil
+}
+
+func examplesShow(cmd *cobra.Command, args []string) error {
+	data, err := getExample(args[0])
+	if err != nil {
+		return root.ExecutionError(cmd, "Examples Error: %s", err)
+	}
+	fmt.Print(data)
+	return nil
+}
+
+func examplesPush(cmd *cobra.Command, args []string) error {
+	data, err := getExample(args[0])
+	if err != nil {
+		return root.ExecutionError(cmd, "Examples Error: %s", err)
+	}
+	if len(examplesPushAs) > 0 {
+		data, err = renameBlueprintTOML(data, examplesPushAs)
+		if err != nil {
+			return root.ExecutionError(cmd, "Examples Error: %s", err)
+		}
+	}
+	resp, err := root.Client.PushBlueprintTOML(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: Push TOML: %s\n", err)
+		return root.ExecutionError(cmd, "")
+	}
+	if resp != nil && !resp.Status {
+		return root.ExecutionErrors(cmd, resp.Errors)
+	}
+	return nil
+}
+
+func newBlueprint(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if !blueprintNameRegex.MatchString(name) {
+		return root.ExecutionError(cmd, "New Error: invalid blueprint name: %s", name)
+	}
+	data := fmt.Sprintf(newBlueprintTemplate, name)
+	if len(newFromExample) > 0 {
+		example, err := getExample(newFromExample)
+		if err != nil {
+			return root.ExecutionError(cmd, "New Error: %s", err)
+		}
+		data, err = renameBlueprintTOML(example, name)
+		if err != nil {
+			return root.ExecutionError(cmd, "New Error: %s", err)
+		}
+	}
+
+	// Never overwrite an existing file
+	filename := name + ".toml"
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return root.ExecutionError(cmd, "New Error: %s", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		return root.ExecutionError(cmd, "New Error: writing %s: %s", filename, err)
+	}
+	fmt.Printf("Created %s, push it with 'composer-cli blueprints push %s'\n", filename, filename)
+	return nil
+}
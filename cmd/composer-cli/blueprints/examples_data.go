@@ -0,0 +1,242 @@
+// Code generated by gen_examples.go; DO NOT EDIT.
+
+package blueprints
+
+// exampleBlueprints holds the contents of the examples/*.toml files, by filename
+var exampleBlueprints = map[string]string{
+	"example-append.toml": `name = "example-append"
+description = "An example using kernel append customization"
+version = "0.0.1"
+
+[[packages]]
+name = "tmux"
+version = "*"
+
+[[packages]]
+name = "openssh-server"
+version = "*"
+
+[[packages]]
+name = "rsync"
+version = "*"
+
+[customizations.kernel]
+append = "nosmt=force"
+`,
+	"example-custom-base.toml": `name = "example-custom-base"
+description = "A base system with customizations"
+version = "0.0.1"
+
+[[packages]]
+name = "bash"
+version = "*"
+
+[customizations]
+hostname = "custombase"
+
+[[customizations.sshkey]]
+user = "root"
+key = "A SSH KEY FOR ROOT"
+
+[[customizations.user]]
+name = "widget"
+description = "Widget process user account"
+home = "/srv/widget/"
+shell = "/usr/bin/false"
+groups = ["dialout", "users"]
+
+[[customizations.user]]
+name = "admin"
+description = "Widget admin account"
+password = "$6$CHO2$3rN8eviE2t50lmVyBYihTgVRHcaecmeCk31LeOUleVK/R/aeWVHVZDi26zAH.o0ywBKH9Tc0/wm7sW/q39uyd1"
+home = "/srv/widget/"
+shell = "/usr/bin/bash"
+groups = ["widget", "users", "students"]
+uid = 1200
+
+[[customizations.user]]
+name = "plain"
+password = "simple plain password"
+
+[[customizations.user]]
+name = "bart"
+key = "SSH KEY FOR BART"
+groups = ["students"]
+
+[[customizations.group]]
+name = "widget"
+
+[[customizations.group]]
+name = "students"
+`,
+	"example-development.toml": `name = "example-development"
+description = "A general purpose development image"
+
+[[packages]]
+name = "cmake"
+version = "*"
+
+[[packages]]
+name = "curl"
+version = "*"
+
+[[packages]]
+name = "file"
+version = "*"
+
+[[packages]]
+name = "gcc"
+version = "*"
+
+[[packages]]
+name = "gcc-c++"
+version = "*"
+
+[[packages]]
+name = "gdb"
+version = "*"
+
+[[packages]]
+name = "git"
+version = "*"
+
+[[packages]]
+name = "glibc-devel"
+version = "*"
+
+[[packages]]
+name = "gnupg2"
+version = "*"
+
+[[packages]]
+name = "libcurl-devel"
+version = "*"
+
+[[packages]]
+name = "make"
+version = "*"
+
+[[packages]]
+name = "openssl-devel"
+version = "*"
+
+[[packages]]
+name = "openssl-devel"
+version = "*"
+
+[[packages]]
+name = "sqlite"
+version = "*"
+
+[[packages]]
+name = "sqlite-devel"
+version = "*"
+
+[[packages]]
+name = "sudo"
+version = "*"
+
+[[packages]]
+name = "tar"
+version = "*"
+
+[[packages]]
+name = "xz"
+version = "*"
+
+[[packages]]
+name = "xz-devel"
+version = "*"
+
+[[packages]]
+name = "zlib-devel"
+version = "*"
+`,
+	"example-glusterfs.toml": `name = "example-glusterfs"
+description = "An example GlusterFS server with samba"
+
+[[packages]]
+name = "glusterfs"
+version = "*"
+
+[[packages]]
+name = "glusterfs-cli"
+version = "*"
+
+[[packages]]
+name = "samba"
+version = "*"
+`,
+	"example-http-server.toml": `name = "example-http-server"
+description = "An example http server with PHP and MySQL support."
+version = "0.0.1"
+
+[[packages]]
+name = "httpd"
+version = "*"
+
+[[packages]]
+name = "mod_auth_openid"
+version = "*"
+
+[[packages]]
+name = "mod_ssl"
+version = "*"
+
+[[packages]]
+name = "php"
+version = "*"
+
+[[packages]]
+name = "php-mysqlnd"
+version = "*"
+
+[[packages]]
+name = "tmux"
+version = "*"
+
+[[packages]]
+name = "openssh-server"
+version = "*"
+
+[[packages]]
+name = "rsync"
+version = "*"
+`,
+	"example-jboss.toml": `name = "example-jboss"
+description = "An example jboss server"
+version = "0.0.1"
+
+[[packages]]
+name = "jboss-servlet-3.1-api"
+version = "*"
+
+[[packages]]
+name = "jboss-interceptors-1.2-api"
+version = "*"
+
+[[packages]]
+name = "java-1.8.0-openjdk"
+version = "*"
+`,
+	"example-kubernetes.toml": `name = "example-kubernetes"
+description = "An example kubernetes master"
+version = "0.0.1"
+
+[[packages]]
+name = "kubernetes"
+version = "*"
+
+[[packages]]
+name = "docker"
+version = "*"
+
+[[packages]]
+name = "etcd"
+version = "*"
+
+[[packages]]
+name = "flannel"
+version = "*"
+`,
+}
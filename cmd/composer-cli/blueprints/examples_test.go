@@ -0,0 +1,113 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+func TestExamplesUpToDate(t *testing.T) {
+	// The examples must match the files in the examples directory, run go generate if this fails
+	files, err := filepath.Glob("../../../examples/*.toml")
+	require.Nil(t, err)
+	require.Equal(t, len(files), len(exampleBlueprints))
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		require.Nil(t, err)
+		assert.Equal(t, string(data), exampleBlueprints[filepath.Base(f)], f)
+	}
+}
+
+func TestRenameBlueprintTOML(t *testing.T) {
+	data := "# comment\nname = \"example\"\ndescription = \"test\"\n\n[[packages]]\nname = \"tmux\"\n"
+	renamed, err := renameBlueprintTOML(data, "new-name")
+	require.Nil(t, err)
+	assert.Equal(t, "# comment\nname = \"new-name\"\ndescription = \"test\"\n\n[[packages]]\nname = \"tmux\"\n", renamed)
+
+	_, err = renameBlueprintTOML(data, "bad name")
+	assert.NotNil(t, err)
+	_, err = renameBlueprintTOML("description = \"test\"\n[[packages]]\nname = \"tmux\"\n", "new-name")
+	assert.NotNil(t, err)
+}
+
+func runExamples(t *testing.T, args ...string) (string, string, string, error) {
+	var pushed string
+	stdout, stderr, err := executeCmd(t, blueprintTestServer("", &pushed), append([]string{"blueprints"}, args...)...)
+	return stdout, stderr, pushed, err
+}
+
+func TestCmdBlueprintsExamplesList(t *testing.T) {
+	stdout, _, _, err := runExamples(t, "examples", "list")
+	require.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	assert.Equal(t, len(exampleBlueprints), len(lines))
+	assert.Contains(t, stdout, "http-server      An example http server with PHP and MySQL support.\n")
+}
+
+func TestCmdBlueprintsExamplesShow(t *testing.T) {
+	stdout, _, _, err := runExamples(t, "examples", "show", "jboss")
+	require.Nil(t, err)
+	assert.Equal(t, exampleBlueprints["example-jboss.toml"], stdout)
+
+	_, stderr, _, err := runExamples(t, "examples", "show", "unknown")
+	require.NotNil(t, err)
+	assert.Contains(t, stderr, "ERROR: Examples Error: unknown example unknown, it should be one of: append, custom-base")
+}
+
+func TestCmdBlueprintsExamplesPush(t *testing.T) {
+	_, _, pushed, err := runExamples(t, "examples", "push", "example-kubernetes", "--as", "")
+	require.Nil(t, err)
+	assert.Equal(t, exampleBlueprints["example-kubernetes.toml"], pushed)
+
+	_, _, pushed, err = runExamples(t, "examples", "push", "kubernetes", "--as", "k8s")
+	require.Nil(t, err)
+	bp, err := weldr.NewBlueprintFromTOML(pushed)
+	require.Nil(t, err)
+	assert.Equal(t, "k8s", bp.Name)
+	assert.Equal(t, "An example kubernetes master", bp.Description)
+}
+
+func TestCmdBlueprintsNew(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-new-")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	prevDir, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir(dir))
+	//nolint:errcheck
+	defer os.Chdir(prevDir)
+
+	stdout, _, _, err := runExamples(t, "new", "web", "--from-example", "http-server")
+	require.Nil(t, err)
+	assert.Equal(t, "Created web.toml, push it with 'composer-cli blueprints push web.toml'\n", stdout)
+	data, err := ioutil.ReadFile("web.toml")
+	require.Nil(t, err)
+	bp, err := weldr.NewBlueprintFromTOML(string(data))
+	require.Nil(t, err)
+	assert.Equal(t, "web", bp.Name)
+	assert.Equal(t, "httpd", bp.Packages[0].Name)
+
+	// It will not overwrite an existing file
+	_, stderr, _, err := runExamples(t, "new", "web", "--from-example", "")
+	require.NotNil(t, err)
+	assert.Contains(t, stderr, "ERROR: New Error: open web.toml: file exists")
+
+	_, _, _, err = runExamples(t, "new", "empty", "--from-example", "")
+	require.Nil(t, err)
+	data, err = ioutil.ReadFile("empty.toml")
+	require.Nil(t, err)
+	bp, err = weldr.NewBlueprintFromTOML(string(data))
+	require.Nil(t, err)
+	assert.Equal(t, "empty", bp.Name)
+}
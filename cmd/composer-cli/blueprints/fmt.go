@@ -0,0 +1,98 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	fmtCmd = &cobra.Command{
+		Use:   "fmt [-w] [--check] FILE...",
+		Short: "Format blueprint files in the canonical order",
+		Long: `Format TOML, JSON, or YAML blueprint files in the canonical order
+
+The name, description, version, and distro come first, followed by the packages,
+modules, and groups sorted by name, and then the customizations. The formatted
+blueprint is written to stdout unless -w is used to rewrite the file. --check
+lists the files that are not formatted and returns an error if there are any.`,
+		RunE: fmtBlueprints,
+		Args: cobra.MinimumNArgs(1),
+	}
+	fmtWrite bool
+	fmtCheck bool
+)
+
+func init() {
+	fmtCmd.Flags().BoolVarP(&fmtWrite, "write", "w", false, "Write the formatted blueprint back to the file")
+	fmtCmd.Flags().BoolVarP(&fmtCheck, "check", "", false, "List the files that are not formatted")
+	blueprintsCmd.AddCommand(fmtCmd)
+}
+
+// formatBlueprintFile returns the original and the canonical contents of a blueprint file
+func formatBlueprintFile(filename string) (string, string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", "", err
+	}
+	format := root.FormatFromFilename(filename)
+	bp, err := weldr.NewBlueprint(string(data), format)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %s", filename, err)
+	}
+	formatted, err := bp.Canonical().Format(format)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %s", filename, err)
+	}
+	return string(data), formatted, nil
+}
+
+func fmtBlueprints(cmd *cobra.Command, args []string) (rcErr error) {
+	if fmtWrite && fmtCheck {
+		return root.ExecutionError(cmd, "Format Error: -w and --check cannot be used together")
+	}
+	unformatted := false
+	for _, filename := range root.GetCommaArgs(args) {
+		original, formatted, err := formatBlueprintFile(filename)
+		if err != nil {
+			rcErr = root.ExecutionError(cmd, "Format Error: %s", err)
+			continue
+		}
+		switch {
+		case fmtCheck:
+			if original != formatted {
+				fmt.Println(filename)
+				unformatted = true
+			}
+		case fmtWrite:
+			if original == formatted {
+				continue
+			}
+			info, err := os.Stat(filename)
+			if err != nil {
+				rcErr = root.ExecutionError(cmd, "Format Error: %s", err)
+				continue
+			}
+			if err := ioutil.WriteFile(filename, []byte(formatted), info.Mode()); err != nil {
+				rcErr = root.ExecutionError(cmd, "Format Error: %s", err)
+			}
+		default:
+			fmt.Print(formatted)
+		}
+	}
+	if unformatted && rcErr == nil {
+		rcErr = root.ExecutionError(cmd, "")
+	}
+
+	// If there were any errors, even if other files succeeded, it returns an error
+	return rcErr
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unformattedTOML = `description = "unformatted blueprint"
+name = "unformatted"
+
+[[packages]]
+version = "*"
+name = "tmux"
+
+[[packages]]
+name = "bash"
+version = "*"
+
+[customizations]
+hostname = "server"
+`
+
+const formattedTOML = `name = "unformatted"
+description = "unformatted blueprint"
+
+[[packages]]
+  name = "bash"
+  version = "*"
+
+[[packages]]
+  name = "tmux"
+  version = "*"
+
+[customizations]
+  hostname = "server"
+`
+
+func runFmt(t *testing.T, args ...string) (string, string, error) {
+	return executeCmd(t, blueprintTestServer("", new(string)), append([]string{"blueprints", "fmt"}, args...)...)
+}
+
+func TestCmdBlueprintsFmt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-fmt-")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "unformatted.toml")
+	require.Nil(t, ioutil.WriteFile(filename, []byte(unformattedTOML), 0640))
+
+	// Print the formatted blueprint
+	stdout, stderr, err := runFmt(t, "-w=false", "--check=false", filename)
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, formattedTOML, stdout)
+
+	// Check fails when the file is not formatted
+	stdout, _, err = runFmt(t, "-w=false", "--check", filename)
+	require.NotNil(t, err)
+	assert.Equal(t, filename+"\n", stdout)
+
+	// Rewrite the file
+	stdout, _, err = runFmt(t, "-w", "--check=false", filename)
+	require.Nil(t, err)
+	assert.Equal(t, "", stdout)
+	data, err := ioutil.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, formattedTOML, string(data))
+	info, err := os.Stat(filename)
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode())
+
+	// Now it passes the check
+	stdout, _, err = runFmt(t, "-w=false", "--check", filename)
+	require.Nil(t, err)
+	assert.Equal(t, "", stdout)
+}
+
+func TestCmdBlueprintsFmtYAML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-fmt-")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "bp.yaml")
+	require.Nil(t, ioutil.WriteFile(filename, []byte("packages:\n- {name: tmux}\n- {name: bash}\nname: bp\n"), 0600))
+
+	stdout, _, err := runFmt(t, "-w=false", "--check=false", filename)
+	require.Nil(t, err)
+	assert.Equal(t, `name: bp
+description: ""
+packages:
+  - name: bash
+  - name: tmux
+modules: []
+groups: []
+`, stdout)
+}
+
+func TestCmdBlueprintsFmtErrors(t *testing.T) {
+	_, stderr, err := runFmt(t, "-w", "--check", "bp.toml")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Format Error: -w and --check cannot be used together\n", stderr)
+
+	_, stderr, err = runFmt(t, "-w=false", "--check=false", "/missing/bp.toml")
+	require.NotNil(t, err)
+	assert.Contains(t, stderr, "ERROR: Format Error: open /missing/bp.toml")
+}
+
+func TestCmdBlueprintsFmtUnknown(t *testing.T) {
+	// The unknown fields are written after the known fields of their table
+	dir, err := ioutil.TempDir("", "test-fmt-")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "fdo.toml")
+	require.Nil(t, ioutil.WriteFile(filename, []byte(`description = "fdo blueprint"
+name = "fdo"
+
+[customizations.fdo]
+manufacturing_server_url = "https://fdo.example.com"
+
+[customizations]
+hostname = "fdo.example.com"
+
+[[packages]]
+version = "*"
+name = "tmux"
+`), 0600))
+
+	stdout, _, err := runFmt(t, "-w=false", "--check=false", filename)
+	require.Nil(t, err)
+	assert.Equal(t, `name = "fdo"
+description = "fdo blueprint"
+
+[[packages]]
+  name = "tmux"
+  version = "*"
+
+[customizations]
+  hostname = "fdo.example.com"
+  [customizations.fdo]
+    manufacturing_server_url = "https://fdo.example.com"
+`, stdout)
+}
@@ -7,6 +7,7 @@ package blueprints
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -155,16 +156,14 @@ func freezeSave(cmd *cobra.Command, args []string) (rcErr error) {
 			rcErr = root.ExecutionError(cmd, "")
 			continue
 		}
-		f, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		out, err := formatServerBlueprint(bp, "toml")
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR: opening file %s: %s\n", "file.toml", err)
+			fmt.Fprintf(os.Stderr, "ERROR: converting blueprint %s: %s\n", name, err)
 			rcErr = root.ExecutionError(cmd, "")
 			continue
 		}
-		defer f.Close()
-		err = toml.NewEncoder(f).Encode(bp)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR: encoding TOML file: %s\n", err)
+		if err := ioutil.WriteFile(filename, []byte(out), 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: writing file %s: %s\n", filename, err)
 			rcErr = root.ExecutionError(cmd, "")
 		}
 	}
@@ -0,0 +1,94 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	freezeCheckCmd = &cobra.Command{
+		Use:   "check FILE [--update]",
+		Short: "Check a frozen blueprint file against the current depsolve",
+		Long: `Check a frozen blueprint file, saved by freeze save, against the blueprint's current depsolve
+
+The blueprint named in the file is frozen again and the packages and modules
+with a different version, or that are only in one of them, are listed. It
+returns an error if anything has changed. With --update the file is rewritten
+with the new versions instead.`,
+		RunE: freezeCheck,
+		Args: cobra.ExactArgs(1),
+	}
+	freezeUpdate bool
+)
+
+func init() {
+	freezeCheckCmd.Flags().BoolVarP(&freezeUpdate, "update", "", false, "Rewrite the file with the current versions")
+	freezeCmd.AddCommand(freezeCheckCmd)
+}
+
+// frozenVersions returns the versions of the frozen packages and modules
+// The keys are the kind and name, eg. "package bash", so that they sort by kind.
+func frozenVersions(bp weldr.Blueprint) map[string]string {
+	versions := make(map[string]string)
+	for _, p := range bp.Packages {
+		versions["package "+p.Name] = p.Version
+	}
+	for _, m := range bp.Modules {
+		versions["module "+m.Name] = m.Version
+	}
+	return versions
+}
+
+func freezeCheck(cmd *cobra.Command, args []string) error {
+	filename := args[0]
+	frozen, err := root.ReadBlueprintFile(filename)
+	if err != nil {
+		return root.ExecutionError(cmd, "Freeze Error: %s: %s", filename, err)
+	}
+
+	bps, errors, err := root.Client.GetFrozenBlueprintsJSON([]string{frozen.Name})
+	if err != nil {
+		return root.ExecutionError(cmd, "Freeze Error: %s", err)
+	}
+	if len(errors) > 0 {
+		return root.ExecutionErrors(cmd, errors)
+	}
+	if len(bps) != 1 {
+		return root.ExecutionError(cmd, "Freeze Error: missing blueprint %s", frozen.Name)
+	}
+	current, err := decodeServerBlueprint(bps[0])
+	if err != nil {
+		return root.ExecutionError(cmd, "Freeze Error: %s", err)
+	}
+
+	changes := root.CompareVersions(frozenVersions(frozen), frozenVersions(current))
+	if len(changes) == 0 {
+		fmt.Printf("%s matches %s v%s\n", filename, current.Name, current.Version)
+		return nil
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+
+	if !freezeUpdate {
+		return root.ExecutionError(cmd, "Freeze Error: %s has drifted from %s, use --update to rewrite it", filename, current.Name)
+	}
+	out, err := formatServerBlueprint(bps[0], root.FormatFromFilename(filename))
+	if err != nil {
+		return root.ExecutionError(cmd, "Freeze Error: converting blueprint %s: %s", current.Name, err)
+	}
+	if err := ioutil.WriteFile(filename, []byte(out), 0600); err != nil {
+		return root.ExecutionError(cmd, "Freeze Error: writing file %s: %s", filename, err)
+	}
+	fmt.Printf("Updated %s\n", filename)
+	return nil
+}
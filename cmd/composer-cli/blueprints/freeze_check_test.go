@@ -0,0 +1,123 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+const freezeCheckTestJSON = `{"blueprints": [{"blueprint": {
+	"name": "cli-test-bp-1",
+	"description": "Install tmux",
+	"version": "0.0.3",
+	"modules": [],
+	"groups": [],
+	"packages": [
+		{"name": "bash", "version": "5.1.8-2.fc34.x86_64"},
+		{"name": "tmux", "version": "3.1c-2.fc34.x86_64"},
+		{"name": "vim-minimal", "version": "2:8.2.3318-1.fc34.x86_64"}
+	]}}], "errors": []}`
+
+const freezeCheckTestTOML = `name = "cli-test-bp-1"
+description = "Install tmux"
+version = "0.0.3"
+
+[[packages]]
+name = "bash"
+version = "5.1.8-1.fc34.x86_64"
+
+[[packages]]
+name = "tmux"
+version = "3.1c-2.fc34.x86_64"
+
+[[packages]]
+name = "nano"
+version = "5.6.1-1.fc34.x86_64"
+`
+
+func runFreezeCheck(t *testing.T, frozen string, args ...string) (string, string, string, error) {
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(freezeCheckTestJSON))),
+		}, nil
+	})
+
+	tmpBp, err := ioutil.TempFile("", "test-bp-*.frozen.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBp.Name())
+	_, err = tmpBp.Write([]byte(frozen))
+	require.Nil(t, err)
+	tmpBp.Close()
+
+	cmd, out, err := root.ExecuteTest(append([]string{"blueprints", "freeze", "check", tmpBp.Name()}, args...)...)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, cmd)
+	stdout, rerr := ioutil.ReadAll(out.Stdout)
+	require.Nil(t, rerr)
+	stderr, rerr := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, rerr)
+	data, rerr := ioutil.ReadFile(tmpBp.Name())
+	require.Nil(t, rerr)
+	return string(stdout), string(stderr), string(data), err
+}
+
+func TestCmdBlueprintsFreezeCheck(t *testing.T) {
+	stdout, stderr, data, err := runFreezeCheck(t, freezeCheckTestTOML, "--update=false")
+	require.NotNil(t, err)
+	assert.Equal(t, `changed package bash 5.1.8-1.fc34.x86_64 -> 5.1.8-2.fc34.x86_64
+removed package nano 5.6.1-1.fc34.x86_64
+added package vim-minimal 2:8.2.3318-1.fc34.x86_64
+`, stdout)
+	assert.Contains(t, stderr, "has drifted from cli-test-bp-1, use --update to rewrite it\n")
+	assert.Equal(t, freezeCheckTestTOML, data)
+}
+
+func TestCmdBlueprintsFreezeCheckUpdate(t *testing.T) {
+	stdout, stderr, data, err := runFreezeCheck(t, freezeCheckTestTOML, "--update")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Contains(t, stdout, "Updated ")
+
+	bp, err := weldr.NewBlueprintFromTOML(data)
+	require.Nil(t, err)
+	assert.Equal(t, []weldr.Package{
+		{Name: "bash", Version: "5.1.8-2.fc34.x86_64"},
+		{Name: "tmux", Version: "3.1c-2.fc34.x86_64"},
+		{Name: "vim-minimal", Version: "2:8.2.3318-1.fc34.x86_64"},
+	}, bp.Packages)
+}
+
+func TestCmdBlueprintsFreezeCheckMatches(t *testing.T) {
+	frozen := `name = "cli-test-bp-1"
+
+[[packages]]
+name = "bash"
+version = "5.1.8-2.fc34.x86_64"
+
+[[packages]]
+name = "vim-minimal"
+version = "2:8.2.3318-1.fc34.x86_64"
+
+[[packages]]
+name = "tmux"
+version = "3.1c-2.fc34.x86_64"
+`
+	stdout, stderr, _, err := runFreezeCheck(t, frozen, "--update=false")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Contains(t, stdout, "matches cli-test-bp-1 v0.0.3\n")
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+// +build ignore
+
+// gen_examples writes the example blueprints to examples_data.go
+// It is run by go generate, the generated file is committed so that
+// building does not depend on the examples directory.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func main() {
+	files, err := filepath.Glob("../../../examples/*.toml")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+	sort.Strings(files)
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by gen_examples.go; DO NOT EDIT.\n\n")
+	src.WriteString("package blueprints\n\n")
+	src.WriteString("// exampleBlueprints holds the contents of the examples/*.toml files, by filename\n")
+	src.WriteString("var exampleBlueprints = map[string]string{\n")
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		if strings.Contains(string(data), "`") {
+			fmt.Fprintf(&src, "%q: %q,\n", filepath.Base(f), data)
+		} else {
+			fmt.Fprintf(&src, "%q: `%s`,\n", filepath.Base(f), data)
+		}
+	}
+	src.WriteString("}\n")
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile("examples_data.go", out, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	generateCmd = &cobra.Command{
+		Use:   "generate --from-rpm-list FILE [--name NAME] [--pin] [--exclude PATTERN,...]",
+		Short: "Generate a blueprint from a list of installed packages",
+		Long: `Generate a blueprint from the output of 'rpm -qa' on an existing system
+
+Only the packages that are not pulled in as dependencies of other packages in the
+list are added to the blueprint. The new blueprint is written to stdout.`,
+		RunE: generate,
+		Args: cobra.NoArgs,
+	}
+	generateRPMList string
+	generateName    string
+	generatePin     bool
+	generateExclude []string
+	generateDistro  string
+)
+
+func init() {
+	generateCmd.Flags().StringVarP(&generateRPMList, "from-rpm-list", "", "", "File with the output of rpm -qa")
+	generateCmd.Flags().StringVarP(&generateName, "name", "", "generated", "Name of the new blueprint")
+	generateCmd.Flags().BoolVarP(&generatePin, "pin", "", false, "Pin the packages to the versions in the list")
+	generateCmd.Flags().StringSliceVarP(&generateExclude, "exclude", "", []string{"kernel*"}, "Glob patterns of package names to leave out")
+	generateCmd.Flags().StringVarP(&generateDistro, "distro", "", "", "Depsolve using this distribution")
+	blueprintsCmd.AddCommand(generateCmd)
+}
+
+// readRPMList reads the packages from a file with the output of rpm -qa
+// Lines that are not NEVRAs are used as bare package names, eg. from rpm -qa --qf '%{NAME}\n'
+// Only the first entry for each name is kept, multilib packages are listed once per arch.
+func readRPMList(filename string) ([]weldr.PackageNEVRA, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pkgs []weldr.PackageNEVRA
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := weldr.ParseNEVRA(line)
+		if err != nil {
+			if strings.ContainsAny(line, " \t:/") {
+				return nil, fmt.Errorf("cannot parse package: %s", line)
+			}
+			p = weldr.PackageNEVRA{Name: line}
+		}
+		if seen[p.Name] {
+			continue
+		}
+		seen[p.Name] = true
+		pkgs = append(pkgs, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// isExcluded returns true if the package name matches one of the glob patterns
+func isExcluded(name string, patterns []string) bool {
+	// gpg-pubkey entries are the imported signing keys, not packages
+	if strings.HasPrefix(name, "gpg-pubkey") {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// leafPackages returns the packages that are not required by any of the other packages
+// deps holds the sorted dependencies of each package. When packages require each other
+// the one with the lowest name is kept.
+func leafPackages(pkgs []weldr.PackageNEVRA, deps map[string][]string) []weldr.PackageNEVRA {
+	var leaves []weldr.PackageNEVRA
+	for _, p := range pkgs {
+		required := false
+		for _, other := range pkgs {
+			if other.Name == p.Name || !containsName(deps[other.Name], p.Name) {
+				continue
+			}
+			if !containsName(deps[p.Name], other.Name) || other.Name < p.Name {
+				required = true
+				break
+			}
+		}
+		if !required {
+			leaves = append(leaves, p)
+		}
+	}
+	return leaves
+}
+
+// pinnedVersion returns the version to use in the blueprint for a package
+func pinnedVersion(p weldr.PackageNEVRA) string {
+	if len(p.Version) == 0 {
+		return "*"
+	}
+	return p.EVR()
+}
+
+func generate(cmd *cobra.Command, args []string) error {
+	if len(generateRPMList) == 0 {
+		return root.ExecutionError(cmd, "Generate Error: --from-rpm-list is required")
+	}
+	all, err := readRPMList(generateRPMList)
+	if err != nil {
+		return root.ExecutionError(cmd, "Generate Error: %s", err)
+	}
+	exclude := root.GetCommaArgs(generateExclude)
+	var pkgs []weldr.PackageNEVRA
+	for _, p := range all {
+		if !isExcluded(p.Name, exclude) {
+			pkgs = append(pkgs, p)
+		}
+	}
+	if len(pkgs) == 0 {
+		return root.ExecutionError(cmd, "Generate Error: no packages in %s", generateRPMList)
+	}
+
+	// Packages that cannot be depsolved are left out, they are probably from a repository
+	// that is not configured on the server.
+	var names []string
+	for _, p := range pkgs {
+		names = append(names, p.Name)
+	}
+	deps, errs := packageClosures(names, generateDistro)
+	var available []weldr.PackageNEVRA
+	for _, p := range pkgs {
+		if err, ok := errs[p.Name]; ok {
+			fmt.Fprintf(os.Stderr, "WARNING: skipping %s: %s\n", p.Name, err)
+			continue
+		}
+		available = append(available, p)
+	}
+	if len(available) == 0 {
+		return root.ExecutionError(cmd, "Generate Error: none of the packages in %s could be depsolved", generateRPMList)
+	}
+
+	bp := weldr.Blueprint{
+		Name:        generateName,
+		Description: fmt.Sprintf("Generated from %s", path.Base(generateRPMList)),
+		Version:     "0.0.1",
+		Distro:      generateDistro,
+	}
+	for _, p := range leafPackages(available, deps) {
+		version := "*"
+		if generatePin {
+			version = pinnedVersion(p)
+		}
+		bp.Packages = append(bp.Packages, weldr.Package{Name: p.Name, Version: version})
+	}
+	sort.Slice(bp.Packages, func(i, j int) bool { return bp.Packages[i].Name < bp.Packages[j].Name })
+
+	data, err := bp.TOML()
+	if err != nil {
+		return root.ExecutionError(cmd, "Generate Error: encoding TOML: %s", err)
+	}
+	fmt.Print(data)
+	return nil
+}
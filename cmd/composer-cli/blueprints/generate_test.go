@@ -0,0 +1,144 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+func TestLeafPackages(t *testing.T) {
+	pkgs := []weldr.PackageNEVRA{{Name: "bash"}, {Name: "glibc"}, {Name: "tmux"}, {Name: "a"}, {Name: "b"}}
+	deps := map[string][]string{
+		"bash":  {"bash", "glibc"},
+		"glibc": {"glibc"},
+		"tmux":  {"glibc", "tmux"},
+		// a and b require each other
+		"a": {"a", "b", "glibc"},
+		"b": {"a", "b", "glibc"},
+	}
+	leaves := leafPackages(pkgs, deps)
+	assert.Equal(t, []weldr.PackageNEVRA{{Name: "bash"}, {Name: "tmux"}, {Name: "a"}}, leaves)
+}
+
+const generateTestRPMList = `bash-5.1.8-2.fc35.x86_64
+glibc-2.34-8.fc35.x86_64
+glibc-2.34-8.fc35.i686
+kernel-core-5.15.6-200.fc35.x86_64
+gpg-pubkey-9867c58f-601c49ca
+tmux-3.2a-3.fc35.x86_64
+vendor-agent-1.0-1.x86_64
+shadow-utils-2:4.9-8.fc35.x86_64
+`
+
+// generateTestServer depsolves single packages using the deps map
+// The packages are depsolved concurrently, so the queries are recorded in any order.
+func generateTestServer(deps map[string][]string, queries *[]string) func(request *http.Request) (*http.Response, error) {
+	var lock sync.Mutex
+	return func(request *http.Request) (*http.Response, error) {
+		name := strings.TrimPrefix(request.URL.Path, "/api/v1/projects/depsolve/")
+		lock.Lock()
+		*queries = append(*queries, name)
+		lock.Unlock()
+		var body string
+		if d, ok := deps[name]; ok {
+			var projects []string
+			for _, n := range d {
+				projects = append(projects, fmt.Sprintf(`{"name": "%s", "epoch": 0, "version": "1.0", "release": "1", "arch": "x86_64"}`, n))
+			}
+			body = fmt.Sprintf(`{"projects": [%s]}`, strings.Join(projects, ","))
+		} else {
+			body = fmt.Sprintf(`{"projects": [], "errors": [{"id": "ProjectsError", "msg": "No match for %s"}]}`, name)
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+func runGenerate(t *testing.T, args ...string) (string, string, []string, error) {
+	f, err := ioutil.TempFile("", "rpm-list-*.txt")
+	require.Nil(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.Write([]byte(generateTestRPMList))
+	require.Nil(t, err)
+	f.Close()
+
+	deps := map[string][]string{
+		"bash":         {"bash", "glibc"},
+		"glibc":        {"glibc"},
+		"tmux":         {"tmux", "glibc"},
+		"shadow-utils": {"shadow-utils", "glibc"},
+	}
+	var queries []string
+	stdout, stderr, err := executeCmd(t, generateTestServer(deps, &queries), append([]string{"blueprints", "generate", "--from-rpm-list", f.Name()}, args...)...)
+	return stdout, stderr, queries, err
+}
+
+func TestCmdBlueprintsGenerate(t *testing.T) {
+	// The depsolved packages are cached, use a distro that the other tests do not use
+	stdout, stderr, queries, err := runGenerate(t, "--name", "golden", "--pin=false", "--exclude", "kernel*", "--distro", "fedora-generate")
+	require.Nil(t, err)
+	assert.Equal(t, "WARNING: skipping vendor-agent: ProjectsError: No match for vendor-agent\n", stderr)
+	// Each package is only depsolved once, excluded packages are not depsolved
+	sort.Strings(queries)
+	assert.Equal(t, []string{"bash", "glibc", "shadow-utils", "tmux", "vendor-agent"}, queries)
+
+	bp, err := weldr.NewBlueprintFromTOML(stdout)
+	require.Nil(t, err)
+	assert.Equal(t, "golden", bp.Name)
+	assert.Equal(t, "0.0.1", bp.Version)
+	assert.Equal(t, []weldr.Package{
+		{Name: "bash", Version: "*"},
+		{Name: "shadow-utils", Version: "*"},
+		{Name: "tmux", Version: "*"},
+	}, bp.Packages)
+}
+
+func TestCmdBlueprintsGeneratePinExclude(t *testing.T) {
+	stdout, _, _, err := runGenerate(t, "--name", "golden", "--pin", "--exclude", "kernel*,vendor-*,tmux")
+	require.Nil(t, err)
+	bp, err := weldr.NewBlueprintFromTOML(stdout)
+	require.Nil(t, err)
+	assert.Equal(t, []weldr.Package{
+		{Name: "bash", Version: "5.1.8-2.fc35"},
+		{Name: "shadow-utils", Version: "2:4.9-8.fc35"},
+	}, bp.Packages)
+}
+
+func TestCmdBlueprintsGenerateMissingFile(t *testing.T) {
+	root.SetupCmdTest(generateTestServer(nil, new([]string)))
+	cmd, out, err := root.ExecuteTest("blueprints", "generate", "--from-rpm-list", "/missing/rpm-list.txt")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, err)
+	assert.Contains(t, string(stderr), "ERROR: Generate Error: open /missing/rpm-list.txt")
+}
+
+func TestCmdBlueprintsGenerateNoneAvailable(t *testing.T) {
+	// The slice flag appends to the previous test's patterns
+	generateExclude = nil
+	stdout, stderr, _, err := runGenerate(t, "--exclude", "kernel*,bash,glibc,tmux,shadow-utils", "--distro", "fedora-none")
+	require.NotNil(t, err)
+	assert.Equal(t, "", stdout)
+	assert.Contains(t, stderr, "ERROR: Generate Error: none of the packages in ")
+}
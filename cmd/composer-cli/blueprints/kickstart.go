@@ -0,0 +1,689 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	importKickstartCmd = &cobra.Command{
+		Use:   "import-kickstart KICKSTART [--name NAME]",
+		Short: "Convert a kickstart to a blueprint",
+		Long: `Convert the packages and the supported commands of a kickstart to a blueprint
+
+The new blueprint is written to stdout. Anything that cannot be translated is
+reported as a warning.`,
+		RunE: importKickstart,
+		Args: cobra.ExactArgs(1),
+	}
+	exportKickstartCmd = &cobra.Command{
+		Use:   "export-kickstart BLUEPRINT",
+		Short: "Convert a blueprint to a kickstart",
+		Long: `Convert the packages and customizations of a blueprint to kickstart commands
+
+The kickstart is written to stdout. Anything that cannot be translated is reported
+as a warning.`,
+		RunE: exportKickstart,
+		Args: cobra.ExactArgs(1),
+	}
+	kickstartName string
+)
+
+func init() {
+	importKickstartCmd.Flags().StringVarP(&kickstartName, "name", "", "", "Name of the new blueprint, defaults to the kickstart's filename")
+	blueprintsCmd.AddCommand(importKickstartCmd)
+	blueprintsCmd.AddCommand(exportKickstartCmd)
+}
+
+// ksValueOptions lists the options that take a value for each kickstart command
+// Values may also be passed as --option=value
+var ksValueOptions = map[string][]string{
+	"user":       {"name", "password", "groups", "homedir", "shell", "uid", "gid", "gecos"},
+	"group":      {"name", "gid"},
+	"rootpw":     {},
+	"sshkey":     {"username"},
+	"services":   {"enabled", "disabled"},
+	"firewall":   {"port", "service", "remove-service", "trust"},
+	"timezone":   {"ntpservers"},
+	"lang":       {"addsupport"},
+	"keyboard":   {"vckeymap", "xlayouts", "switch"},
+	"bootloader": {"append", "location", "boot-drive", "driveorder", "password", "timeout"},
+	"network":    {"hostname", "device", "bootproto", "ip", "netmask", "gateway", "nameserver", "onboot"},
+}
+
+// ksCommand is a kickstart command with its options and arguments
+type ksCommand struct {
+	line int
+	name string
+	opts map[string]string
+	args []string
+	used map[string]bool
+}
+
+// opt returns the value of an option and marks it as translated
+func (c *ksCommand) opt(name string) (string, bool) {
+	v, ok := c.opts[name]
+	if ok {
+		c.used[name] = true
+	}
+	return v, ok
+}
+
+// unused returns the options that were not translated
+func (c *ksCommand) unused() []string {
+	var unused []string
+	for k := range c.opts {
+		if !c.used[k] {
+			unused = append(unused, "--"+k)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// ksSplit splits a kickstart line into words
+// It handles single and double quotes, and backslash escapes outside of single quotes.
+func ksSplit(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// parseKSCommand splits a kickstart command into its options and arguments
+func parseKSCommand(lineno int, words []string) *ksCommand {
+	c := &ksCommand{line: lineno, name: words[0], opts: make(map[string]string), used: make(map[string]bool)}
+	for i := 1; i < len(words); i++ {
+		w := words[i]
+		if !strings.HasPrefix(w, "--") {
+			c.args = append(c.args, w)
+			continue
+		}
+		name := strings.TrimPrefix(w, "--")
+		if j := strings.Index(name, "="); j >= 0 {
+			c.opts[name[:j]] = name[j+1:]
+			continue
+		}
+		if isStringInList(ksValueOptions[c.name], name) && i+1 < len(words) {
+			c.opts[name] = words[i+1]
+			i++
+			continue
+		}
+		c.opts[name] = ""
+	}
+	return c
+}
+
+// splitList splits a comma separated kickstart value, skipping empty entries
+func splitList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// ksToBlueprint translates a kickstart into a blueprint
+// It returns warnings for everything that could not be translated.
+func ksToBlueprint(r io.Reader, name string) (weldr.Blueprint, []string, error) {
+	bp := weldr.Blueprint{
+		Name:        name,
+		Description: "Imported from a kickstart",
+		Version:     "0.0.1",
+	}
+	c := &weldr.Customizations{}
+	var warnings []string
+	warn := func(line int, format string, a ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf("line %d: %s", line, fmt.Sprintf(format, a...)))
+	}
+
+	section := ""
+	scanner := bufio.NewScanner(r)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "%end" {
+			section = ""
+			continue
+		}
+		if section == "%packages" {
+			ksPackage(&bp, line, func(format string, a ...interface{}) { warn(lineno, format, a...) })
+			continue
+		} else if len(section) > 0 {
+			continue
+		}
+
+		words, err := ksSplit(line)
+		if err != nil {
+			return weldr.Blueprint{}, nil, fmt.Errorf("line %d: %s", lineno, err)
+		}
+		cmd := parseKSCommand(lineno, words)
+		if strings.HasPrefix(cmd.name, "%") {
+			section = cmd.name
+			if section == "%packages" {
+				for _, o := range cmd.unused() {
+					warn(lineno, "%%packages option %s is not supported", o)
+				}
+			} else if section == "%include" || section == "%ksappend" {
+				section = ""
+				warn(lineno, "%s is not supported", cmd.name)
+			} else {
+				warn(lineno, "%s section is not supported", cmd.name)
+			}
+			continue
+		}
+		if err := ksCustomization(c, cmd); err != nil {
+			warn(lineno, "%s", err)
+			continue
+		}
+		for _, o := range cmd.unused() {
+			warn(lineno, "%s option %s is not supported", cmd.name, o)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return weldr.Blueprint{}, nil, err
+	}
+	bp.Customizations = pruneCustomizations(c)
+	return bp, warnings, nil
+}
+
+// ksPackage adds an entry from the %packages section to the blueprint
+func ksPackage(bp *weldr.Blueprint, line string, warn func(string, ...interface{})) {
+	switch {
+	case strings.HasPrefix(line, "@^"):
+		warn("environment group %s is not supported", line[2:])
+	case strings.HasPrefix(line, "@"):
+		fields := strings.Fields(line[1:])
+		if len(fields) == 0 {
+			warn("missing group name")
+			return
+		}
+		name := fields[0]
+		for _, o := range fields[1:] {
+			warn("group option %s is not supported", o)
+		}
+		if strings.Contains(name, ":") {
+			warn("module stream %s is not supported", name)
+			return
+		}
+		for _, g := range bp.Groups {
+			if g.Name == name {
+				return
+			}
+		}
+		bp.Groups = append(bp.Groups, weldr.Group{Name: name})
+	case strings.HasPrefix(line, "-"):
+		warn("excluding %s is not supported", line[1:])
+	default:
+		name := strings.Fields(line)[0]
+		for _, p := range bp.Packages {
+			if p.Name == name {
+				return
+			}
+		}
+		bp.Packages = append(bp.Packages, weldr.Package{Name: name, Version: "*"})
+	}
+}
+
+// ksCustomization translates a kickstart command into the blueprint's customizations
+func ksCustomization(c *weldr.Customizations, cmd *ksCommand) error {
+	switch cmd.name {
+	case "user":
+		return ksUser(c, cmd)
+	case "rootpw":
+		return ksRootpw(c, cmd)
+	case "group":
+		name, _ := cmd.opt("name")
+		if err := validateUserName(name); err != nil {
+			return err
+		}
+		group := weldr.GroupCustomization{Name: name}
+		if gid, ok := cmd.opt("gid"); ok {
+			n, err := strconv.Atoi(gid)
+			if err != nil {
+				return fmt.Errorf("invalid gid for group %s: %s", name, gid)
+			}
+			group.GID = &n
+		}
+		c.Group = append(c.Group, group)
+	case "sshkey":
+		user, _ := cmd.opt("username")
+		if len(user) == 0 || len(cmd.args) != 1 {
+			return fmt.Errorf("sshkey needs --username and a key")
+		}
+		for i := range c.SSHKey {
+			if c.SSHKey[i].User == user {
+				c.SSHKey[i].Key += "\n" + cmd.args[0]
+				return nil
+			}
+		}
+		c.SSHKey = append(c.SSHKey, weldr.SSHKeyCustomization{User: user, Key: cmd.args[0]})
+	case "services":
+		enabled, disabled := serviceLists(c)
+		if v, ok := cmd.opt("enabled"); ok {
+			*enabled = appendUnique(*enabled, splitList(v)...)
+		}
+		if v, ok := cmd.opt("disabled"); ok {
+			*disabled = appendUnique(*disabled, splitList(v)...)
+		}
+	case "firewall":
+		if _, ok := cmd.opt("disabled"); ok {
+			return fmt.Errorf("disabling the firewall is not supported")
+		}
+		cmd.opt("enabled")
+		if c.Firewall == nil {
+			c.Firewall = &weldr.FirewallCustomization{}
+		}
+		if v, ok := cmd.opt("port"); ok {
+			for _, p := range splitList(v) {
+				port, err := normalizePort(p)
+				if err != nil {
+					return err
+				}
+				c.Firewall.Ports = appendUnique(c.Firewall.Ports, port)
+			}
+		}
+		enabled, disabled := firewallServiceLists(c)
+		if v, ok := cmd.opt("service"); ok {
+			*enabled = appendUnique(*enabled, splitList(v)...)
+		}
+		if v, ok := cmd.opt("remove-service"); ok {
+			*disabled = appendUnique(*disabled, splitList(v)...)
+		}
+		// The old style service flags
+		for _, s := range []string{"ssh", "smtp", "http", "ftp", "telnet"} {
+			if _, ok := cmd.opt(s); ok {
+				*enabled = appendUnique(*enabled, s)
+			}
+		}
+	case "timezone":
+		if len(cmd.args) > 1 {
+			return fmt.Errorf("timezone has too many arguments")
+		}
+		c.Timezone = &weldr.TimezoneCustomization{}
+		if len(cmd.args) == 1 {
+			c.Timezone.Timezone = cmd.args[0]
+		}
+		if v, ok := cmd.opt("ntpservers"); ok {
+			c.Timezone.NTPServers = splitList(v)
+		}
+	case "lang":
+		if len(cmd.args) != 1 {
+			return fmt.Errorf("lang needs one language")
+		}
+		if c.Locale == nil {
+			c.Locale = &weldr.LocaleCustomization{}
+		}
+		c.Locale.Languages = []string{cmd.args[0]}
+		if v, ok := cmd.opt("addsupport"); ok {
+			c.Locale.Languages = appendUnique(c.Locale.Languages, splitList(v)...)
+		}
+	case "keyboard":
+		if c.Locale == nil {
+			c.Locale = &weldr.LocaleCustomization{}
+		}
+		if v, ok := cmd.opt("vckeymap"); ok {
+			c.Locale.Keyboard = v
+		} else if len(cmd.args) == 1 {
+			c.Locale.Keyboard = cmd.args[0]
+		} else {
+			return fmt.Errorf("keyboard needs --vckeymap or a keymap")
+		}
+	case "bootloader":
+		if v, ok := cmd.opt("append"); ok {
+			if c.Kernel == nil {
+				c.Kernel = &weldr.KernelCustomization{}
+			}
+			c.Kernel.Append = strings.TrimSpace(c.Kernel.Append + " " + v)
+		}
+	case "network":
+		if v, ok := cmd.opt("hostname"); ok {
+			c.Hostname = v
+		}
+	default:
+		return fmt.Errorf("%s is not supported", cmd.name)
+	}
+	return nil
+}
+
+// ksPassword returns the password from a user or rootpw command
+// Plain text passwords are hashed so that they are not stored in the blueprint.
+func ksPassword(cmd *ksCommand, password string) (string, error) {
+	cmd.opt("plaintext")
+	if _, ok := cmd.opt("iscrypted"); ok {
+		return password, nil
+	}
+	return hashPassword(password)
+}
+
+func ksUser(c *weldr.Customizations, cmd *ksCommand) error {
+	name, _ := cmd.opt("name")
+	if err := validateUserName(name); err != nil {
+		return err
+	}
+	user := weldr.UserCustomization{Name: name}
+	user.Description, _ = cmd.opt("gecos")
+	user.Home, _ = cmd.opt("homedir")
+	user.Shell, _ = cmd.opt("shell")
+	if v, ok := cmd.opt("groups"); ok {
+		user.Groups = splitList(v)
+	}
+	for _, id := range []struct {
+		opt string
+		ptr **int
+	}{{"uid", &user.UID}, {"gid", &user.GID}} {
+		if v, ok := cmd.opt(id.opt); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s for user %s: %s", id.opt, name, v)
+			}
+			*id.ptr = &n
+		}
+	}
+	if v, ok := cmd.opt("password"); ok {
+		password, err := ksPassword(cmd, v)
+		if err != nil {
+			return err
+		}
+		user.Password = password
+	}
+	if i := findUser(c, name); i >= 0 {
+		c.User[i] = user
+	} else {
+		c.User = append(c.User, user)
+	}
+	return nil
+}
+
+func ksRootpw(c *weldr.Customizations, cmd *ksCommand) error {
+	// A locked root account is the default, a blueprint cannot lock an account with a
+	// password so --lock is reported as unsupported when there is one.
+	if _, ok := cmd.opts["lock"]; ok && len(cmd.args) == 0 {
+		cmd.opt("lock")
+		return nil
+	}
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("rootpw needs a password")
+	}
+	password, err := ksPassword(cmd, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	i := findUser(c, "root")
+	if i < 0 {
+		c.User = append(c.User, weldr.UserCustomization{Name: "root"})
+		i = len(c.User) - 1
+	}
+	c.User[i].Password = password
+	return nil
+}
+
+// ksQuote quotes a kickstart value if it needs it
+func ksQuote(s string) string {
+	if len(s) > 0 && !strings.ContainsAny(s, " \t\"'\\#") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// blueprintToKS translates a blueprint into kickstart commands and a %packages section
+// It returns warnings for everything that could not be translated.
+func blueprintToKS(bp weldr.Blueprint) (string, []string) {
+	var ks strings.Builder
+	var warnings []string
+	fmt.Fprintf(&ks, "# Generated from blueprint %s", bp.Name)
+	if len(bp.Version) > 0 {
+		fmt.Fprintf(&ks, " version %s", bp.Version)
+	}
+	ks.WriteString("\n")
+
+	if c := bp.Customizations; c != nil {
+		if c.Locale != nil {
+			if len(c.Locale.Languages) > 0 {
+				fmt.Fprintf(&ks, "lang %s", ksQuote(c.Locale.Languages[0]))
+				if len(c.Locale.Languages) > 1 {
+					fmt.Fprintf(&ks, " --addsupport=%s", ksQuote(strings.Join(c.Locale.Languages[1:], ",")))
+				}
+				ks.WriteString("\n")
+			}
+			if len(c.Locale.Keyboard) > 0 {
+				fmt.Fprintf(&ks, "keyboard --vckeymap=%s\n", ksQuote(c.Locale.Keyboard))
+			}
+		}
+		if c.Timezone != nil {
+			ks.WriteString("timezone")
+			if len(c.Timezone.Timezone) > 0 {
+				fmt.Fprintf(&ks, " %s", ksQuote(c.Timezone.Timezone))
+			}
+			if len(c.Timezone.NTPServers) > 0 {
+				fmt.Fprintf(&ks, " --ntpservers=%s", ksQuote(strings.Join(c.Timezone.NTPServers, ",")))
+			}
+			ks.WriteString("\n")
+		}
+		if len(c.Hostname) > 0 {
+			fmt.Fprintf(&ks, "network --hostname=%s\n", ksQuote(c.Hostname))
+		}
+		if c.Kernel != nil {
+			if len(c.Kernel.Append) > 0 {
+				fmt.Fprintf(&ks, "bootloader --append=%s\n", ksQuote(c.Kernel.Append))
+			}
+			if len(c.Kernel.Name) > 0 {
+				warnings = append(warnings, fmt.Sprintf("kernel name %s is not supported, add it to %%packages instead", c.Kernel.Name))
+			}
+		}
+		if c.Firewall != nil {
+			ks.WriteString("firewall --enabled")
+			if len(c.Firewall.Ports) > 0 {
+				fmt.Fprintf(&ks, " --port=%s", ksQuote(strings.Join(c.Firewall.Ports, ",")))
+			}
+			if c.Firewall.Services != nil {
+				if len(c.Firewall.Services.Enabled) > 0 {
+					fmt.Fprintf(&ks, " --service=%s", ksQuote(strings.Join(c.Firewall.Services.Enabled, ",")))
+				}
+				if len(c.Firewall.Services.Disabled) > 0 {
+					fmt.Fprintf(&ks, " --remove-service=%s", ksQuote(strings.Join(c.Firewall.Services.Disabled, ",")))
+				}
+			}
+			ks.WriteString("\n")
+		}
+		if c.Services != nil {
+			ks.WriteString("services")
+			if len(c.Services.Enabled) > 0 {
+				fmt.Fprintf(&ks, " --enabled=%s", ksQuote(strings.Join(c.Services.Enabled, ",")))
+			}
+			if len(c.Services.Disabled) > 0 {
+				fmt.Fprintf(&ks, " --disabled=%s", ksQuote(strings.Join(c.Services.Disabled, ",")))
+			}
+			ks.WriteString("\n")
+		}
+		for _, g := range c.Group {
+			fmt.Fprintf(&ks, "group --name=%s", ksQuote(g.Name))
+			if g.GID != nil {
+				fmt.Fprintf(&ks, " --gid=%d", *g.GID)
+			}
+			ks.WriteString("\n")
+		}
+		for _, u := range c.User {
+			warnings = append(warnings, ksWriteUser(&ks, u)...)
+		}
+		for _, k := range c.SSHKey {
+			for _, key := range strings.Split(k.Key, "\n") {
+				fmt.Fprintf(&ks, "sshkey --username=%s %s\n", ksQuote(k.User), ksQuote(key))
+			}
+		}
+		for _, fs := range c.Filesystem {
+			warnings = append(warnings, fmt.Sprintf("filesystem %s is not supported, use part or logvol", fs.Mountpoint))
+		}
+		if len(c.InstallationDevice) > 0 {
+			warnings = append(warnings, "installation_device is not supported, use ignoredisk or clearpart")
+		}
+	}
+
+	ks.WriteString("\n%packages\n")
+	for _, g := range bp.Groups {
+		fmt.Fprintf(&ks, "@%s\n", g.Name)
+	}
+	for _, p := range bp.Packages {
+		if len(p.Version) > 0 && p.Version != "*" {
+			warnings = append(warnings, fmt.Sprintf("version %s of %s is not supported, the latest version will be installed", p.Version, p.Name))
+		}
+		fmt.Fprintf(&ks, "%s\n", p.Name)
+	}
+	// The blueprint's modules are not module streams, they are installed as packages
+	for _, m := range bp.Modules {
+		if len(m.Version) > 0 && m.Version != "*" {
+			warnings = append(warnings, fmt.Sprintf("module %s version %s is not supported, the latest version of the package will be installed", m.Name, m.Version))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("module %s is not supported, it is installed as a package", m.Name))
+		}
+		fmt.Fprintf(&ks, "%s\n", m.Name)
+	}
+	ks.WriteString("%end\n")
+	return ks.String(), warnings
+}
+
+// ksWriteUser writes the commands for a user
+func ksWriteUser(ks *strings.Builder, u weldr.UserCustomization) []string {
+	var warnings []string
+	passwordType := "--iscrypted"
+	if len(u.Password) > 0 && !isCryptHash(u.Password) {
+		passwordType = "--plaintext"
+		warnings = append(warnings, fmt.Sprintf("the password for %s is not a crypt hash", u.Name))
+	}
+	if u.Name == "root" {
+		if len(u.Password) > 0 {
+			fmt.Fprintf(ks, "rootpw %s %s\n", passwordType, ksQuote(u.Password))
+		} else {
+			ks.WriteString("rootpw --lock\n")
+		}
+	} else {
+		fmt.Fprintf(ks, "user --name=%s", ksQuote(u.Name))
+		if len(u.Description) > 0 {
+			fmt.Fprintf(ks, " --gecos=%s", ksQuote(u.Description))
+		}
+		if len(u.Home) > 0 {
+			fmt.Fprintf(ks, " --homedir=%s", ksQuote(u.Home))
+		}
+		if len(u.Shell) > 0 {
+			fmt.Fprintf(ks, " --shell=%s", ksQuote(u.Shell))
+		}
+		if len(u.Groups) > 0 {
+			fmt.Fprintf(ks, " --groups=%s", ksQuote(strings.Join(u.Groups, ",")))
+		}
+		if u.UID != nil {
+			fmt.Fprintf(ks, " --uid=%d", *u.UID)
+		}
+		if u.GID != nil {
+			fmt.Fprintf(ks, " --gid=%d", *u.GID)
+		}
+		if len(u.Password) > 0 {
+			fmt.Fprintf(ks, " --password=%s %s", ksQuote(u.Password), passwordType)
+		}
+		ks.WriteString("\n")
+	}
+	if len(u.Key) > 0 {
+		for _, key := range strings.Split(u.Key, "\n") {
+			fmt.Fprintf(ks, "sshkey --username=%s %s\n", ksQuote(u.Name), ksQuote(key))
+		}
+	}
+	return warnings
+}
+
+func importKickstart(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return root.ExecutionError(cmd, "Kickstart Error: %s", err)
+	}
+	defer f.Close()
+
+	name := kickstartName
+	if len(name) == 0 {
+		name = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+	}
+	bp, warnings, err := ksToBlueprint(f, name)
+	if err != nil {
+		return root.ExecutionError(cmd, "Kickstart Error: %s: %s", args[0], err)
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", w)
+	}
+	data, err := bp.TOML()
+	if err != nil {
+		return root.ExecutionError(cmd, "Kickstart Error: encoding TOML: %s", err)
+	}
+	fmt.Print(data)
+	return nil
+}
+
+func exportKickstart(cmd *cobra.Command, args []string) error {
+	bp, err := getBlueprint(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	ks, warnings := blueprintToKS(bp)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", w)
+	}
+	fmt.Print(ks)
+	return nil
+}
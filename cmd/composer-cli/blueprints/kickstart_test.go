@@ -0,0 +1,211 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+const testKickstart = `# Legacy web server
+lang en_US.UTF-8 --addsupport=de_DE.UTF-8
+keyboard --vckeymap=us --xlayouts='us'
+timezone America/New_York --utc --ntpservers=0.pool.ntp.org,1.pool.ntp.org
+network --hostname=web.example.com --bootproto=dhcp
+bootloader --location=mbr --append="console=ttyS0 nosmt=force"
+firewall --enabled --port=443:tcp,8080/tcp --service=http --remove-service=cockpit
+services --enabled=httpd,sshd --disabled=kdump
+rootpw --iscrypted $6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1
+group --name=web --gid=1100
+user --name=admin --groups=wheel,web --gecos="Web Admin" --uid=1010 --password=secret --plaintext
+sshkey --username=admin "ssh-ed25519 AAAAC3Nza admin@host"
+clearpart --all
+autopart
+
+%packages --excludedocs
+@core
+@web-server --optional
+@^server-product-environment
+httpd
+mod_ssl
+-plymouth
+%end
+
+%post
+echo "not translated"
+%end
+`
+
+func TestKSSplit(t *testing.T) {
+	words, err := ksSplit(`sshkey --username=admin "ssh-rsa AAAA me@host" 'single "quoted"' a\ b`)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"sshkey", "--username=admin", "ssh-rsa AAAA me@host", `single "quoted"`, "a b"}, words)
+
+	_, err = ksSplit(`user --name="admin`)
+	assert.NotNil(t, err)
+}
+
+func TestKSToBlueprint(t *testing.T) {
+	bp, warnings, err := ksToBlueprint(strings.NewReader(testKickstart), "web")
+	require.Nil(t, err)
+	assert.Equal(t, []string{
+		"line 3: keyboard option --xlayouts is not supported",
+		"line 4: timezone option --utc is not supported",
+		"line 5: network option --bootproto is not supported",
+		"line 6: bootloader option --location is not supported",
+		"line 13: clearpart is not supported",
+		"line 14: autopart is not supported",
+		"line 16: %packages option --excludedocs is not supported",
+		"line 18: group option --optional is not supported",
+		"line 19: environment group server-product-environment is not supported",
+		"line 22: excluding plymouth is not supported",
+		"line 25: %post section is not supported",
+	}, warnings)
+
+	assert.Equal(t, "web", bp.Name)
+	assert.Equal(t, []weldr.Package{{Name: "httpd", Version: "*"}, {Name: "mod_ssl", Version: "*"}}, bp.Packages)
+	assert.Equal(t, []weldr.Group{{Name: "core"}, {Name: "web-server"}}, bp.Groups)
+
+	c := bp.Customizations
+	require.NotNil(t, c)
+	assert.Equal(t, "web.example.com", c.Hostname)
+	assert.Equal(t, &weldr.LocaleCustomization{Languages: []string{"en_US.UTF-8", "de_DE.UTF-8"}, Keyboard: "us"}, c.Locale)
+	assert.Equal(t, &weldr.TimezoneCustomization{Timezone: "America/New_York", NTPServers: []string{"0.pool.ntp.org", "1.pool.ntp.org"}}, c.Timezone)
+	assert.Equal(t, &weldr.KernelCustomization{Append: "console=ttyS0 nosmt=force"}, c.Kernel)
+	assert.Equal(t, []string{"443:tcp", "8080:tcp"}, c.Firewall.Ports)
+	assert.Equal(t, &weldr.FirewallServicesCustomization{Enabled: []string{"http"}, Disabled: []string{"cockpit"}}, c.Firewall.Services)
+	assert.Equal(t, &weldr.ServicesCustomization{Enabled: []string{"httpd", "sshd"}, Disabled: []string{"kdump"}}, c.Services)
+	require.Equal(t, 1, len(c.Group))
+	assert.Equal(t, "web", c.Group[0].Name)
+	assert.Equal(t, 1100, *c.Group[0].GID)
+	assert.Equal(t, []weldr.SSHKeyCustomization{{User: "admin", Key: "ssh-ed25519 AAAAC3Nza admin@host"}}, c.SSHKey)
+
+	require.Equal(t, 2, len(c.User))
+	assert.Equal(t, "root", c.User[0].Name)
+	assert.True(t, strings.HasPrefix(c.User[0].Password, "$6$saltstring$"))
+	admin := c.User[1]
+	assert.Equal(t, "admin", admin.Name)
+	assert.Equal(t, "Web Admin", admin.Description)
+	assert.Equal(t, []string{"wheel", "web"}, admin.Groups)
+	assert.Equal(t, 1010, *admin.UID)
+	// Plain text passwords are hashed
+	assert.True(t, isCryptHash(admin.Password))
+	assert.Equal(t, admin.Password, sha512Crypt("secret", strings.Split(admin.Password, "$")[2], 0))
+}
+
+func TestBlueprintToKSRoundTrip(t *testing.T) {
+	bp, _, err := ksToBlueprint(strings.NewReader(testKickstart), "web")
+	require.Nil(t, err)
+	bp.Packages = append(bp.Packages, weldr.Package{Name: "tmux", Version: "3.2*"})
+	bp.Customizations.Filesystem = []weldr.FilesystemCustomization{{Mountpoint: "/var", MinSize: 1024}}
+
+	ks, warnings := blueprintToKS(bp)
+	assert.Equal(t, []string{
+		"filesystem /var is not supported, use part or logvol",
+		"version 3.2* of tmux is not supported, the latest version will be installed",
+	}, warnings)
+	assert.Contains(t, ks, "bootloader --append=\"console=ttyS0 nosmt=force\"\n")
+	assert.Contains(t, ks, "user --name=admin --gecos=\"Web Admin\" --groups=wheel,web --uid=1010 --password=")
+	assert.Contains(t, ks, "rootpw --iscrypted $6$saltstring$")
+
+	// Converting it back gives the same blueprint
+	bp2, warnings, err := ksToBlueprint(strings.NewReader(ks), "web")
+	require.Nil(t, err)
+	assert.Equal(t, []string(nil), warnings)
+	bp.Customizations.Filesystem = nil
+	assert.Equal(t, bp.Groups, bp2.Groups)
+	assert.Equal(t, bp.Customizations, bp2.Customizations)
+	assert.Equal(t, []weldr.Package{{Name: "httpd", Version: "*"}, {Name: "mod_ssl", Version: "*"}, {Name: "tmux", Version: "*"}}, bp2.Packages)
+}
+
+func TestBlueprintToKSModulesLockedRoot(t *testing.T) {
+	bp := weldr.Blueprint{
+		Name:    "modules",
+		Version: "0.0.1",
+		Modules: []weldr.Package{{Name: "nodejs", Version: "*"}, {Name: "python3", Version: "3.9.*"}},
+		Customizations: &weldr.Customizations{
+			User: []weldr.UserCustomization{{Name: "root", Key: "ssh-ed25519 AAAAC3Nza root@host"}},
+		},
+	}
+	ks, warnings := blueprintToKS(bp)
+	// A module with a version gets one warning
+	assert.Equal(t, []string{
+		"module nodejs is not supported, it is installed as a package",
+		"module python3 version 3.9.* is not supported, the latest version of the package will be installed",
+	}, warnings)
+	assert.Contains(t, ks, "rootpw --lock\nsshkey --username=root \"ssh-ed25519 AAAAC3Nza root@host\"\n")
+	assert.Contains(t, ks, "%packages\nnodejs\npython3\n%end\n")
+
+	// The locked root account is the default
+	bp2, warnings, err := ksToBlueprint(strings.NewReader(ks), "modules")
+	require.Nil(t, err)
+	assert.Equal(t, []string(nil), warnings)
+	assert.Equal(t, []weldr.SSHKeyCustomization{{User: "root", Key: "ssh-ed25519 AAAAC3Nza root@host"}}, bp2.Customizations.SSHKey)
+}
+
+func TestKSToBlueprintLockedRootPassword(t *testing.T) {
+	bp, warnings, err := ksToBlueprint(strings.NewReader("rootpw --lock --iscrypted $6$saltstring$hash\n"), "locked")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"line 1: rootpw option --lock is not supported"}, warnings)
+	assert.Equal(t, "$6$saltstring$hash", bp.Customizations.User[0].Password)
+}
+
+func TestCmdBlueprintsImportKickstart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-ks-")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	ksFile := filepath.Join(dir, "web-server.ks")
+	err = ioutil.WriteFile(ksFile, []byte("lang en_US.UTF-8\nautopart\n%packages\nhttpd\n%end\n"), 0600)
+	require.Nil(t, err)
+
+	root.SetupCmdTest(blueprintTestServer("", new(string)))
+	cmd, out, err := root.ExecuteTest("blueprints", "import-kickstart", "--name", "", ksFile)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	require.Nil(t, err)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, err)
+	assert.Equal(t, "WARNING: line 2: autopart is not supported\n", string(stderr))
+	bp, err := weldr.NewBlueprintFromTOML(string(stdout))
+	require.Nil(t, err)
+	assert.Equal(t, "web-server", bp.Name)
+	assert.Equal(t, []weldr.Package{{Name: "httpd", Version: "*"}}, bp.Packages)
+	assert.Equal(t, []string{"en_US.UTF-8"}, bp.Customizations.Locale.Languages)
+}
+
+func TestCmdBlueprintsExportKickstart(t *testing.T) {
+	root.SetupCmdTest(blueprintTestServer(customizeTestTOML, new(string)))
+	cmd, out, err := root.ExecuteTest("blueprints", "export-kickstart", "custom")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	require.Nil(t, err)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, err)
+	assert.Equal(t, "", string(stderr))
+	assert.Equal(t, `# Generated from blueprint custom version 1.0.0
+bootloader --append=nosmt=force
+firewall --enabled --port=22:tcp
+services --enabled=sshd --disabled=telnet
+
+%packages
+bash
+%end
+`, string(stdout))
+}
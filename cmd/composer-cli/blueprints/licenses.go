@@ -0,0 +1,77 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+)
+
+var (
+	licensesCmd = &cobra.Command{
+		Use:   "licenses BLUEPRINT [--policy FILE]",
+		Short: "List the licenses of the packages in the blueprint's depsolve",
+		Long: `List the licenses of the packages in the blueprint's depsolve
+
+The blueprint is depsolved and the packages are grouped by their license. Old
+Fedora license names are replaced by their SPDX identifiers. With --policy the
+licenses are checked against a TOML file with allow and deny lists of licenses,
+eg. allow = ["MIT", "GPL-2.0-or-later"], and it returns an error if any of the
+packages are not allowed. When the allow list is empty every license that is not
+denied is allowed.`,
+		RunE: licenses,
+		Args: cobra.ExactArgs(1),
+	}
+	licensesPolicy string
+)
+
+func init() {
+	licensesCmd.Flags().StringVarP(&licensesPolicy, "policy", "", "", "TOML file with the allowed and denied licenses")
+	blueprintsCmd.AddCommand(licensesCmd)
+}
+
+func licenses(cmd *cobra.Command, args []string) error {
+	var policy *root.LicensePolicy
+	if len(licensesPolicy) > 0 {
+		p, err := root.ReadLicensePolicy(licensesPolicy)
+		if err != nil {
+			return root.ExecutionError(cmd, "Licenses Error: %s: %s", licensesPolicy, err)
+		}
+		policy = &p
+	}
+
+	bps, errors, err := root.Client.DepsolveBlueprints([]string{args[0]})
+	if err != nil {
+		return root.ExecutionError(cmd, "Licenses Error: %s", err)
+	}
+	if len(errors) > 0 {
+		rcErr := root.ExecutionErrors(cmd, errors)
+		root.PrintBlueprintSuggestions(errors, args[:1])
+		return rcErr
+	}
+	if len(bps) != 1 {
+		return root.ExecutionError(cmd, "Licenses Error: missing blueprint %s", args[0])
+	}
+	data, err := json.Marshal(bps[0])
+	if err != nil {
+		return root.ExecutionError(cmd, "Licenses Error: %s", err)
+	}
+	var parts depsolvedBlueprint
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return root.ExecutionError(cmd, "Licenses Error: decoding depsolved blueprint: %s", err)
+	}
+
+	pkgs, err := root.LookupPackages(parts.Dependencies, parts.Blueprint.Distro)
+	if err != nil {
+		return root.ExecutionError(cmd, "Licenses Error: %s", err)
+	}
+	if violations := root.PrintLicenseReport(pkgs, policy); violations > 0 {
+		return root.ExecutionError(cmd, "Licenses Error: %d packages in %s are not allowed by %s", violations, args[0], licensesPolicy)
+	}
+	return nil
+}
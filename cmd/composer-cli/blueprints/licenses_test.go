@@ -0,0 +1,102 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const licensesTestDepsolve = `{"blueprints": [{
+	"blueprint": {"name": "licenses-bp", "version": "0.1.0", "distro": "fedora-licenses",
+		"packages": [{"name": "tmux", "version": "*"}]},
+	"dependencies": [
+		{"name": "tmux", "epoch": 0, "version": "3.2a", "release": "1.fc34", "arch": "x86_64"},
+		{"name": "glibc", "epoch": 0, "version": "2.33", "release": "5.fc34", "arch": "x86_64"},
+		{"name": "ncurses-libs", "epoch": 0, "version": "6.2", "release": "4.20200222.fc34", "arch": "x86_64"},
+		{"name": "libevent", "epoch": 0, "version": "2.1.12", "release": "3.fc34", "arch": "x86_64"}
+	]}], "errors": []}`
+
+const licensesTestProjects = `{"projects": [
+	{"name": "glibc", "builds": [{"arch": "x86_64", "epoch": 0, "release": "5.fc34",
+		"source": {"license": "LGPLv2+ and LGPLv2+ with exceptions and GPLv2+", "version": "2.33"}}]},
+	{"name": "libevent", "builds": [{"arch": "x86_64", "epoch": 0, "release": "3.fc34",
+		"source": {"license": "BSD", "version": "2.1.12"}}]},
+	{"name": "ncurses-libs", "builds": [{"arch": "x86_64", "epoch": 0, "release": "4.20200222.fc34",
+		"source": {"license": "MIT", "version": "6.2"}}]},
+	{"name": "tmux", "builds": [{"arch": "x86_64", "epoch": 0, "release": "1.fc34",
+		"source": {"license": "ISC and BSD", "version": "3.2a"}}]}
+]}`
+
+// licensesTestServer depsolves the blueprint and returns the projects, it records the projects request
+func licensesTestServer(projectsReq **http.Request) func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		body := licensesTestDepsolve
+		if strings.HasPrefix(request.URL.Path, "/api/v1/projects/info/") {
+			*projectsReq = request
+			body = licensesTestProjects
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+func runLicenses(t *testing.T, args ...string) (string, string, *http.Request, error) {
+	var projectsReq *http.Request
+	stdout, stderr, err := executeCmd(t, licensesTestServer(&projectsReq), append([]string{"blueprints", "licenses"}, args...)...)
+	return stdout, stderr, projectsReq, err
+}
+
+func TestCmdBlueprintsLicenses(t *testing.T) {
+	stdout, stderr, req, err := runLicenses(t, "--policy", "", "licenses-bp")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	require.NotNil(t, req)
+	assert.Equal(t, "/api/v1/projects/info/glibc,libevent,ncurses-libs,tmux", req.URL.Path)
+	assert.Equal(t, "fedora-licenses", req.URL.Query().Get("distro"))
+	assert.Equal(t, `BSD: 1 packages
+    libevent-2.1.12-3.fc34.x86_64
+ISC AND BSD: 1 packages
+    tmux-3.2a-1.fc34.x86_64
+LGPL-2.0-or-later AND LGPL-2.0-or-later WITH exceptions AND GPL-2.0-or-later: 1 packages
+    glibc-2.33-5.fc34.x86_64
+MIT: 1 packages
+    ncurses-libs-6.2-4.20200222.fc34.x86_64
+`, stdout)
+}
+
+func TestCmdBlueprintsLicensesPolicy(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "test-policy-*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpFile.Name())
+	_, err = tmpFile.Write([]byte("allow = [\"MIT\", \"ISC\", \"LGPLv2+\", \"GPLv2+\"]\ndeny = [\"BSD\"]\n"))
+	require.Nil(t, err)
+	tmpFile.Close()
+
+	stdout, stderr, _, err := runLicenses(t, "--policy", tmpFile.Name(), "licenses-bp")
+	require.NotNil(t, err)
+	assert.Contains(t, stdout, "BSD: 1 packages, not allowed\n")
+	assert.Contains(t, stdout, "ISC AND BSD: 1 packages, not allowed\n")
+	assert.Contains(t, stdout, "AND GPL-2.0-or-later: 1 packages\n")
+	assert.Contains(t, stdout, "MIT: 1 packages\n")
+	assert.Equal(t, "ERROR: Licenses Error: 2 packages in licenses-bp are not allowed by "+tmpFile.Name()+"\n", stderr)
+}
+
+func TestCmdBlueprintsLicensesBadPolicy(t *testing.T) {
+	stdout, stderr, req, err := runLicenses(t, "--policy", "/tmp/missing-policy-file.toml", "licenses-bp")
+	require.NotNil(t, err)
+	assert.Nil(t, req)
+	assert.Equal(t, "", stdout)
+	assert.Contains(t, stderr, "ERROR: Licenses Error: /tmp/missing-policy-file.toml: ")
+}
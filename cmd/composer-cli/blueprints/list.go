@@ -6,18 +6,20 @@ package blueprints
 
 import (
 	"fmt"
+	"os"
 	"sort"
 
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
 )
 
 var (
 	listCmd = &cobra.Command{
 		Use:   "list",
 		Short: "List all of the blueprint names",
-		Long:  "List all of the blueprint names",
+		Long:  "List all of the blueprint names, marking the ones with uncommitted workspace changes",
 		RunE:  list,
 	}
 )
@@ -35,9 +37,27 @@ func list(cmd *cobra.Command, args []string) error {
 		return root.ExecutionErrors(cmd, resp.Errors)
 	}
 
+	// Mark the blueprints with workspace changes, the raw JSON output only has the list
+	// The list is still printed if the changes cannot be read, but without the marks.
+	var changed map[string]bool
+	if !root.JSONOutput && len(blueprints) > 0 {
+		var errors []weldr.APIErrorMsg
+		changed, errors, err = workspaceChanges(blueprints)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: cannot read the workspace changes: %s\n", err)
+		}
+		for _, e := range errors {
+			fmt.Fprintf(os.Stderr, "WARNING: cannot read the workspace changes: %s: %s\n", e.ID, e.Msg)
+		}
+	}
+
 	sort.Strings(blueprints)
 	for i := range blueprints {
-		fmt.Println(blueprints[i])
+		if changed[blueprints[i]] {
+			fmt.Printf("%s (workspace changes)\n", blueprints[i])
+		} else {
+			fmt.Println(blueprints[i])
+		}
 	}
 
 	return nil
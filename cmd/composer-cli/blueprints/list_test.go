@@ -24,7 +24,9 @@ func TestCmdBlueprintsList(t *testing.T) {
 		v := query.Get("limit")
 		limit, _ := strconv.ParseUint(v, 10, 64)
 		var json string
-		if limit == 0 {
+		if request.URL.Path == "/api/v1/blueprints/info/http-server-prod,nfs-server-test" {
+			json = `{"blueprints": [], "changes": [{"name": "http-server-prod", "changed": false}, {"name": "nfs-server-test", "changed": true}], "errors": []}`
+		} else if limit == 0 {
 			json = `{"blueprints": [], "total": 2, "offset": 0, "limit": 0}`
 		} else {
 			json = `{"blueprints": ["http-server-prod", "nfs-server-test"], "total": 2, "offset": 0, "limit": 2}`
@@ -47,13 +49,44 @@ func TestCmdBlueprintsList(t *testing.T) {
 	stdout, err := ioutil.ReadAll(out.Stdout)
 	assert.Nil(t, err)
 	assert.NotContains(t, string(stdout), "{")
-	assert.Contains(t, string(stdout), "http-server-prod")
-	assert.Contains(t, string(stdout), "nfs-server-test")
+	assert.Equal(t, "http-server-prod\nnfs-server-test (workspace changes)\n", string(stdout))
 	stderr, err := ioutil.ReadAll(out.Stderr)
 	assert.Nil(t, err)
 	assert.Equal(t, []byte(""), stderr)
 	assert.Equal(t, "GET", mc.Req.Method)
-	assert.Equal(t, "/api/v1/blueprints/list", mc.Req.URL.Path)
+	assert.Equal(t, "/api/v1/blueprints/info/http-server-prod,nfs-server-test", mc.Req.URL.Path)
+}
+
+func TestCmdBlueprintsListChangesError(t *testing.T) {
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		query := request.URL.Query()
+		v := query.Get("limit")
+		limit, _ := strconv.ParseUint(v, 10, 64)
+		var json string
+		if request.URL.Path == "/api/v1/blueprints/info/http-server-prod,nfs-server-test" {
+			json = `{"blueprints": [], "changes": [{"name": "http-server-prod", "changed": false}], "errors": [{"id": "UnknownBlueprint", "msg": "nfs-server-test: blueprint not found"}]}`
+		} else if limit == 0 {
+			json = `{"blueprints": [], "total": 2, "offset": 0, "limit": 0}`
+		} else {
+			json = `{"blueprints": ["http-server-prod", "nfs-server-test"], "total": 2, "offset": 0, "limit": 2}`
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	_, out, err := root.ExecuteTest("blueprints", "list")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "http-server-prod\nnfs-server-test\n", string(stdout))
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "WARNING: cannot read the workspace changes: UnknownBlueprint: nfs-server-test: blueprint not found\n", string(stderr))
 }
 
 func TestCmdBlueprintsListJSON(t *testing.T) {
@@ -0,0 +1,247 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	addPackageCmd = &cobra.Command{
+		Use:   "add-package BLUEPRINT PACKAGE[@VERSION-GLOB],...",
+		Short: "Add packages to a blueprint",
+		Long:  "Add packages to a blueprint, or change their version, and commit it with the patch version bumped. The version defaults to *. The changes are printed, the server does not store a commit message.",
+		RunE:  addPackage,
+		Args:  cobra.MinimumNArgs(2),
+	}
+	removePackageCmd = &cobra.Command{
+		Use:   "remove-package BLUEPRINT PACKAGE,...",
+		Short: "Remove packages from a blueprint",
+		Long:  "Remove packages from a blueprint and commit it with the patch version bumped. The changes are printed, the server does not store a commit message.",
+		RunE:  removePackage,
+		Args:  cobra.MinimumNArgs(2),
+	}
+	addGroupCmd = &cobra.Command{
+		Use:   "add-group BLUEPRINT GROUP,...",
+		Short: "Add package groups to a blueprint",
+		Long:  "Add package groups to a blueprint and commit it with the patch version bumped. The changes are printed, the server does not store a commit message.",
+		RunE:  addGroup,
+		Args:  cobra.MinimumNArgs(2),
+	}
+	addModuleCmd = &cobra.Command{
+		Use:   "add-module BLUEPRINT MODULE[@VERSION-GLOB],...",
+		Short: "Add modules to a blueprint",
+		Long:  "Add modules to a blueprint, or change their version, and commit it with the patch version bumped. The version defaults to *. The changes are printed, the server does not store a commit message.",
+		RunE:  addModule,
+		Args:  cobra.MinimumNArgs(2),
+	}
+)
+
+func init() {
+	blueprintsCmd.AddCommand(addPackageCmd)
+	blueprintsCmd.AddCommand(removePackageCmd)
+	blueprintsCmd.AddCommand(addGroupCmd)
+	blueprintsCmd.AddCommand(addModuleCmd)
+}
+
+// parsePackageSpecs splits NAME@VERSION-GLOB strings into Packages
+// If there is no version it is set to *
+func parsePackageSpecs(specs []string) []weldr.Package {
+	var pkgs []weldr.Package
+	for _, s := range specs {
+		fields := strings.SplitN(s, "@", 2)
+		if len(fields) == 1 || len(fields[1]) == 0 {
+			pkgs = append(pkgs, weldr.Package{Name: fields[0], Version: "*"})
+		} else {
+			pkgs = append(pkgs, weldr.Package{Name: fields[0], Version: fields[1]})
+		}
+	}
+	return pkgs
+}
+
+// packageNames returns the names of the packages
+func packageNames(pkgs []weldr.Package) []string {
+	var names []string
+	for _, p := range pkgs {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// mergePackages adds new packages to the list, or updates the version of existing ones
+// It returns the new list and a description of each change
+func mergePackages(current, add []weldr.Package) ([]weldr.Package, []string) {
+	var changes []string
+	for _, a := range add {
+		found := false
+		for i := range current {
+			if current[i].Name != a.Name {
+				continue
+			}
+			found = true
+			if current[i].Version != a.Version {
+				changes = append(changes, fmt.Sprintf("changed %s from %s to %s", a.Name, current[i].Version, a.Version))
+				current[i].Version = a.Version
+			}
+			break
+		}
+		if !found {
+			current = append(current, a)
+			changes = append(changes, fmt.Sprintf("added %s %s", a.Name, a.Version))
+		}
+	}
+	return current, changes
+}
+
+// checkAvailable makes sure the projects (or modules) have a build matching their version glob
+func checkAvailable(pkgs []weldr.Package, distro string, modules bool) error {
+	names := packageNames(pkgs)
+	var projects []weldr.ProjectV0
+	var resp *weldr.APIResponse
+	var err error
+	if modules {
+		projects, resp, err = root.Client.ModulesInfo(names, distro)
+	} else {
+		projects, resp, err = root.Client.ProjectsInfo(names, distro)
+	}
+	if err != nil {
+		return err
+	}
+	if resp != nil && !resp.Status {
+		return fmt.Errorf("%s", strings.Join(resp.AllErrors(), "; "))
+	}
+
+	found := make(map[string]weldr.ProjectV0)
+	for _, p := range projects {
+		found[p.Name] = p
+	}
+	var missing []string
+	for _, pkg := range pkgs {
+		p, ok := found[pkg.Name]
+		if !ok {
+			missing = append(missing, pkg.Name)
+		} else if len(projectVersions(pkg.Version, p)) == 0 {
+			missing = append(missing, pkg.Name+"@"+pkg.Version)
+		}
+	}
+	if len(missing) > 0 {
+		if len(distro) > 0 {
+			return fmt.Errorf("not available for %s: %s", distro, strings.Join(missing, ", "))
+		}
+		return fmt.Errorf("not available: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func addPackage(cmd *cobra.Command, args []string) error {
+	return addPackagesOrModules(cmd, args[0], root.GetCommaArgs(args[1:]), false)
+}
+
+func addModule(cmd *cobra.Command, args []string) error {
+	return addPackagesOrModules(cmd, args[0], root.GetCommaArgs(args[1:]), true)
+}
+
+// addPackagesOrModules adds the packages, or modules, to the blueprint and commits it
+func addPackagesOrModules(cmd *cobra.Command, name string, specs []string, modules bool) error {
+	if len(specs) == 0 {
+		return root.ExecutionError(cmd, "Missing package names")
+	}
+	bp, err := getBlueprintToCommit(cmd, name)
+	if err != nil {
+		return err
+	}
+
+	pkgs := parsePackageSpecs(specs)
+	if err := checkAvailable(pkgs, bp.Distro, modules); err != nil {
+		return root.ExecutionError(cmd, "Package Error: %s", err)
+	}
+
+	var changes []string
+	if modules {
+		bp.Modules, changes = mergePackages(bp.Modules, pkgs)
+	} else {
+		bp.Packages, changes = mergePackages(bp.Packages, pkgs)
+	}
+	if len(changes) == 0 {
+		fmt.Printf("No changes made to %s\n", bp.Name)
+		return nil
+	}
+	return commitBlueprint(cmd, bp, strings.Join(changes, ", "))
+}
+
+func removePackage(cmd *cobra.Command, args []string) (rcErr error) {
+	names := root.GetCommaArgs(args[1:])
+	if len(names) == 0 {
+		return root.ExecutionError(cmd, "Missing package names")
+	}
+	bp, err := getBlueprintToCommit(cmd, args[0])
+	if err != nil {
+		return err
+	}
+
+	var changes []string
+	for _, n := range names {
+		found := false
+		for i := range bp.Packages {
+			if bp.Packages[i].Name == n {
+				bp.Packages = append(bp.Packages[:i], bp.Packages[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if found {
+			changes = append(changes, fmt.Sprintf("removed %s", n))
+		} else {
+			fmt.Fprintf(os.Stderr, "ERROR: %s is not in %s\n", n, bp.Name)
+			rcErr = root.ExecutionError(cmd, "")
+		}
+	}
+	if len(changes) == 0 {
+		return rcErr
+	}
+	if err := commitBlueprint(cmd, bp, strings.Join(changes, ", ")); err != nil {
+		return err
+	}
+	return rcErr
+}
+
+func addGroup(cmd *cobra.Command, args []string) error {
+	names := root.GetCommaArgs(args[1:])
+	if len(names) == 0 {
+		return root.ExecutionError(cmd, "Missing group names")
+	}
+	bp, err := getBlueprintToCommit(cmd, args[0])
+	if err != nil {
+		return err
+	}
+
+	// The server cannot list groups so they cannot be checked before committing
+	var changes []string
+	for _, n := range names {
+		found := false
+		for _, g := range bp.Groups {
+			if g.Name == n {
+				found = true
+				break
+			}
+		}
+		if !found {
+			bp.Groups = append(bp.Groups, weldr.Group{Name: n})
+			changes = append(changes, fmt.Sprintf("added group %s", n))
+		}
+	}
+	if len(changes) == 0 {
+		fmt.Printf("No changes made to %s\n", bp.Name)
+		return nil
+	}
+	return commitBlueprint(cmd, bp, strings.Join(changes, ", "))
+}
@@ -0,0 +1,260 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+const packagesTestTOML = `name = "simple"
+description = "simple blueprint"
+version = "0.1.0"
+distro = "fedora-34"
+groups = []
+modules = []
+
+[[packages]]
+name = "bash"
+version = "*"
+
+[[packages]]
+name = "tmux"
+version = "3.*"
+`
+
+// packagesTestVersions are the versions of the projects returned by packagesTestServer
+var packagesTestVersions = map[string]string{
+	"nodejs":       "14.17.0",
+	"tmux":         "3.2a",
+	"vim-enhanced": "8.2.3318",
+}
+
+// packagesTestServer returns the blueprint, the project info for the projects in
+// available, and records the pushed blueprint.
+func packagesTestServer(available []string, pushed *string, infoQuery *string) func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		var body string
+		switch {
+		case request.Method == "POST":
+			data, _ := ioutil.ReadAll(request.Body)
+			*pushed = string(data)
+			body = `{"status": true}`
+		case strings.HasPrefix(request.URL.Path, "/api/v1/projects/info/"),
+			strings.HasPrefix(request.URL.Path, "/api/v1/modules/info/"):
+			*infoQuery = request.URL.RawQuery
+			var projects []string
+			for _, p := range available {
+				projects = append(projects, `{"name": "`+p+`", "summary": "`+p+` package", "builds": [
+					{"arch": "x86_64", "epoch": 0, "release": "1.fc34", "source": {"version": "`+packagesTestVersions[p]+`"}}]}`)
+			}
+			if strings.Contains(request.URL.Path, "/modules/") {
+				body = `{"modules": [` + strings.Join(projects, ",") + `]}`
+			} else {
+				body = `{"projects": [` + strings.Join(projects, ",") + `]}`
+			}
+		case request.URL.Query().Get("format") != "toml":
+			body = blueprintInfoBody(packagesTestTOML, false)
+		default:
+			body = packagesTestTOML
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+func TestParsePackageSpecs(t *testing.T) {
+	assert.Equal(t, []weldr.Package{
+		{Name: "bash", Version: "*"},
+		{Name: "tmux", Version: "3.2*"},
+		{Name: "vim", Version: "*"},
+	}, parsePackageSpecs([]string{"bash", "tmux@3.2*", "vim@"}))
+}
+
+func TestCmdBlueprintsAddPackage(t *testing.T) {
+	// Test the "blueprints add-package" command
+	var pushed, query string
+	mc := root.SetupCmdTest(packagesTestServer([]string{"vim-enhanced", "tmux"}, &pushed, &query))
+
+	cmd, out, err := root.ExecuteTest("blueprints", "add-package", "simple", "vim-enhanced@8.*,tmux@*")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, out.Stdout)
+	require.NotNil(t, out.Stderr)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, addPackageCmd)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "simple v0.1.1: added vim-enhanced 8.*, changed tmux from 3.* to *\n", string(stdout))
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+	assert.Equal(t, "POST", mc.Req.Method)
+	assert.Equal(t, "/api/v1/blueprints/new", mc.Req.URL.Path)
+	assert.Equal(t, "distro=fedora-34", query)
+
+	bp, err := weldr.NewBlueprintFromTOML(pushed)
+	require.Nil(t, err)
+	assert.Equal(t, "0.1.1", bp.Version)
+	assert.Equal(t, []weldr.Package{
+		{Name: "bash", Version: "*"},
+		{Name: "tmux", Version: "*"},
+		{Name: "vim-enhanced", Version: "8.*"},
+	}, bp.Packages)
+}
+
+func TestCmdBlueprintsAddPackageUnavailable(t *testing.T) {
+	// Test the "blueprints add-package" command with a package that isn't available
+	var pushed, query string
+	mc := root.SetupCmdTest(packagesTestServer([]string{"tmux"}, &pushed, &query))
+
+	cmd, out, err := root.ExecuteTest("blueprints", "add-package", "simple", "tmux", "vim-enchanted")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, addPackageCmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "ERROR: Package Error: not available for fedora-34: vim-enchanted\n", string(stderr))
+	assert.Equal(t, "GET", mc.Req.Method)
+	assert.Equal(t, "", pushed)
+}
+
+func TestCmdBlueprintsAddPackageVersionUnavailable(t *testing.T) {
+	// Test the "blueprints add-package" command with a version that isn't available
+	var pushed, query string
+	root.SetupCmdTest(packagesTestServer([]string{"vim-enhanced", "tmux"}, &pushed, &query))
+
+	cmd, out, err := root.ExecuteTest("blueprints", "add-package", "simple", "vim-enhanced@9.*,tmux@3.2*")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "ERROR: Package Error: not available for fedora-34: vim-enhanced@9.*\n", string(stderr))
+	assert.Equal(t, "", pushed)
+}
+
+func TestCmdBlueprintsAddPackageWorkspaceChanged(t *testing.T) {
+	// Test the "blueprints add-package" command with uncommitted workspace changes
+	var pushed, query string
+	server := packagesTestServer([]string{"tmux"}, &pushed, &query)
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		if strings.HasPrefix(request.URL.Path, "/api/v1/blueprints/info/") && request.URL.Query().Get("format") != "toml" {
+			return &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(blueprintInfoBody(packagesTestTOML, true)))),
+			}, nil
+		}
+		return server(request)
+	})
+
+	cmd, out, err := root.ExecuteTest("blueprints", "add-package", "simple", "tmux")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "ERROR: Blueprint Error: simple has uncommitted workspace changes, commit or discard them with blueprints workspace first\n", string(stderr))
+	assert.Equal(t, "", pushed)
+}
+
+func TestCmdBlueprintsAddModule(t *testing.T) {
+	// Test the "blueprints add-module" command
+	var pushed, query string
+	mc := root.SetupCmdTest(packagesTestServer([]string{"nodejs"}, &pushed, &query))
+
+	cmd, out, err := root.ExecuteTest("blueprints", "add-module", "simple", "nodejs@14.*")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, addModuleCmd)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "simple v0.1.1: added nodejs 14.*\n", string(stdout))
+	assert.Equal(t, "/api/v1/blueprints/new", mc.Req.URL.Path)
+
+	bp, err := weldr.NewBlueprintFromTOML(pushed)
+	require.Nil(t, err)
+	assert.Equal(t, []weldr.Package{{Name: "nodejs", Version: "14.*"}}, bp.Modules)
+}
+
+func TestCmdBlueprintsRemovePackage(t *testing.T) {
+	// Test the "blueprints remove-package" command
+	var pushed, query string
+	mc := root.SetupCmdTest(packagesTestServer(nil, &pushed, &query))
+
+	cmd, out, err := root.ExecuteTest("blueprints", "remove-package", "simple", "tmux")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, removePackageCmd)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "simple v0.1.1: removed tmux\n", string(stdout))
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+	assert.Equal(t, "/api/v1/blueprints/new", mc.Req.URL.Path)
+
+	bp, err := weldr.NewBlueprintFromTOML(pushed)
+	require.Nil(t, err)
+	assert.Equal(t, []weldr.Package{{Name: "bash", Version: "*"}}, bp.Packages)
+}
+
+func TestCmdBlueprintsRemovePackageMissing(t *testing.T) {
+	// Test the "blueprints remove-package" command with a package not in the blueprint
+	var pushed, query string
+	root.SetupCmdTest(packagesTestServer(nil, &pushed, &query))
+
+	cmd, out, err := root.ExecuteTest("blueprints", "remove-package", "simple", "vim")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, removePackageCmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "ERROR: vim is not in simple\n", string(stderr))
+	assert.Equal(t, "", pushed)
+}
+
+func TestCmdBlueprintsAddGroup(t *testing.T) {
+	// Test the "blueprints add-group" command
+	var pushed, query string
+	mc := root.SetupCmdTest(packagesTestServer(nil, &pushed, &query))
+
+	cmd, out, err := root.ExecuteTest("blueprints", "add-group", "simple", "development-tools")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, cmd, addGroupCmd)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "simple v0.1.1: added group development-tools\n", string(stdout))
+	assert.Equal(t, "/api/v1/blueprints/new", mc.Req.URL.Path)
+
+	bp, err := weldr.NewBlueprintFromTOML(pushed)
+	require.Nil(t, err)
+	assert.Equal(t, []weldr.Group{{Name: "development-tools"}}, bp.Groups)
+}
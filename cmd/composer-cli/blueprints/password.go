@@ -0,0 +1,93 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	hashPasswordCmd = &cobra.Command{
+		Use:   "hash-password",
+		Short: "Hash a password for use in a blueprint",
+		Long:  "Prompt for a password and print its SHA-512 crypt hash, suitable for a blueprint user's password",
+		RunE:  hashPasswordRun,
+		Args:  cobra.NoArgs,
+	}
+	setPasswordCmd = &cobra.Command{
+		Use:   "set-password BLUEPRINT USER",
+		Short: "Set the password of a user in the blueprint",
+		Long:  "Prompt for a password and set the SHA-512 crypt hash of it as the user's password in the blueprint",
+		RunE:  setPassword,
+		Args:  cobra.ExactArgs(2),
+	}
+)
+
+func init() {
+	root.AddRootCommand(hashPasswordCmd)
+	blueprintsCmd.AddCommand(setPasswordCmd)
+}
+
+// readPasswordHash prompts for a password and returns its hash
+func readPasswordHash() (string, error) {
+	password, err := root.PromptPassword("Password: ")
+	if err != nil {
+		return "", err
+	}
+	if len(password) == 0 {
+		return "", fmt.Errorf("empty password")
+	}
+	return hashPassword(password)
+}
+
+func hashPasswordRun(cmd *cobra.Command, args []string) error {
+	hash, err := readPasswordHash()
+	if err != nil {
+		return root.ExecutionError(cmd, "Password Error: %s", err)
+	}
+	fmt.Println(hash)
+	return nil
+}
+
+// warnPlaintextPasswords prints a warning for each user with a password that is not a crypt hash
+func warnPlaintextPasswords(name string, c *weldr.Customizations) {
+	for _, u := range c.User {
+		if len(u.Password) > 0 && !isCryptHash(u.Password) {
+			fmt.Fprintf(os.Stderr, "WARNING: the password for %s in %s does not look like a crypt hash\n", u.Name, name)
+		}
+	}
+}
+
+func setPassword(cmd *cobra.Command, args []string) error {
+	name := args[1]
+	if err := validateUserName(name); err != nil {
+		return root.ExecutionError(cmd, "Customize Error: %s", err)
+	}
+	hash, err := readPasswordHash()
+	if err != nil {
+		return root.ExecutionError(cmd, "Password Error: %s", err)
+	}
+
+	return customizeBlueprint(cmd, args[0], func(c *weldr.Customizations) (string, error) {
+		i := findUser(c, name)
+		if i < 0 {
+			// root always exists in the image, other users need to be added first
+			if name != "root" {
+				return "", fmt.Errorf("user %s does not exist, add it with 'blueprints customize user add'", name)
+			}
+			c.User = append(c.User, weldr.UserCustomization{Name: name})
+			i = len(c.User) - 1
+		}
+		c.User[i].Password = hash
+		warnPlaintextPasswords(args[0], c)
+		return fmt.Sprintf("set password for %s", name), nil
+	})
+}
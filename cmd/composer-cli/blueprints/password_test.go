@@ -0,0 +1,120 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+const passwordTestTOML = `name = "users"
+description = "blueprint with users"
+version = "0.1.0"
+
+[[customizations.user]]
+name = "admin"
+password = "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"
+
+[[customizations.user]]
+name = "guest"
+password = "guest"
+`
+
+// runSetPassword runs set-password with the password piped to stdin
+func runSetPassword(t *testing.T, password string, args ...string) (string, string, *weldr.Blueprint, error) {
+	restore, err := root.SetupStdin(password + "\n")
+	require.Nil(t, err)
+	defer restore()
+
+	var pushed string
+	root.SetupCmdTest(blueprintTestServer(passwordTestTOML, &pushed))
+	cmd, out, err := root.ExecuteTest(append([]string{"blueprints", "set-password"}, args...)...)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, cmd)
+	stdout, rerr := ioutil.ReadAll(out.Stdout)
+	require.Nil(t, rerr)
+	stderr, rerr := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, rerr)
+
+	if len(pushed) == 0 {
+		return string(stdout), string(stderr), nil, err
+	}
+	bp, perr := weldr.NewBlueprintFromTOML(pushed)
+	require.Nil(t, perr)
+	return string(stdout), string(stderr), &bp, err
+}
+
+func TestCmdHashPassword(t *testing.T) {
+	restore, err := root.SetupStdin("secret\n")
+	require.Nil(t, err)
+	defer restore()
+
+	root.SetupCmdTest(blueprintTestServer("", new(string)))
+	cmd, out, err := root.ExecuteTest("hash-password")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	require.Nil(t, err)
+	hash := strings.TrimSpace(string(stdout))
+	assert.True(t, isCryptHash(hash))
+	salt := strings.Split(hash, "$")[2]
+	assert.Equal(t, hash, sha512Crypt("secret", salt, 0))
+}
+
+func TestCmdHashPasswordEmpty(t *testing.T) {
+	restore, err := root.SetupStdin("\n")
+	require.Nil(t, err)
+	defer restore()
+
+	root.SetupCmdTest(blueprintTestServer("", new(string)))
+	cmd, out, err := root.ExecuteTest("hash-password")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, err)
+	assert.Equal(t, "ERROR: Password Error: empty password\n", string(stderr))
+}
+
+func TestCmdBlueprintsSetPassword(t *testing.T) {
+	stdout, stderr, bp, err := runSetPassword(t, "secret", "users", "admin")
+	require.Nil(t, err)
+	assert.Equal(t, "users v0.1.1: set password for admin\n", stdout)
+	assert.Equal(t, "WARNING: the password for guest in users does not look like a crypt hash\n", stderr)
+	require.NotNil(t, bp)
+	require.Equal(t, 2, len(bp.Customizations.User))
+	hash := bp.Customizations.User[0].Password
+	assert.NotContains(t, hash, "saltstring")
+	assert.Equal(t, hash, sha512Crypt("secret", strings.Split(hash, "$")[2], 0))
+	assert.Equal(t, "guest", bp.Customizations.User[1].Password)
+}
+
+func TestCmdBlueprintsSetPasswordRoot(t *testing.T) {
+	stdout, _, bp, err := runSetPassword(t, "secret", "users", "root")
+	require.Nil(t, err)
+	assert.Equal(t, "users v0.1.1: set password for root\n", stdout)
+	require.NotNil(t, bp)
+	require.Equal(t, 3, len(bp.Customizations.User))
+	assert.Equal(t, "root", bp.Customizations.User[2].Name)
+	assert.True(t, isCryptHash(bp.Customizations.User[2].Password))
+}
+
+func TestCmdBlueprintsSetPasswordUnknownUser(t *testing.T) {
+	_, stderr, bp, err := runSetPassword(t, "secret", "users", "nobody")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Customize Error: user nobody does not exist, add it with 'blueprints customize user add'\n", stderr)
+	assert.Nil(t, bp)
+}
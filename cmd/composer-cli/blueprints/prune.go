@@ -0,0 +1,143 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	pruneCmd = &cobra.Command{
+		Use:   "prune-redundant BLUEPRINT [--apply]",
+		Short: "List the blueprint's packages that are pulled in by its other packages",
+		Long: `List the blueprint's packages and modules that are pulled in as dependencies of its other packages
+
+Each package and module is depsolved on its own and the ones that are in the
+dependencies of another one are listed. With --apply they are removed and the
+blueprint is committed with the patch version bumped. Entries with a version pin
+are never removed.`,
+		RunE: prune,
+		Args: cobra.ExactArgs(1),
+	}
+	pruneApply bool
+)
+
+func init() {
+	pruneCmd.Flags().BoolVarP(&pruneApply, "apply", "", false, "Remove the redundant packages and commit the blueprint")
+	blueprintsCmd.AddCommand(pruneCmd)
+}
+
+// redundantEntry is a top level package that another top level package pulls in
+type redundantEntry struct {
+	pkg    weldr.Package
+	by     string
+	pinned bool
+}
+
+// isPinned returns true if the package has an explicit version
+func isPinned(p weldr.Package) bool {
+	return len(p.Version) > 0 && p.Version != "*"
+}
+
+// findRedundant returns the entries that are pulled in by one of the other entries
+// Entries are checked in order and ones already found to be redundant cannot make
+// another one redundant, so packages that depend on each other are not all removed.
+func findRedundant(entries []weldr.Package, deps map[string][]string) []redundantEntry {
+	removed := make(map[string]bool)
+	var redundant []redundantEntry
+	for _, e := range entries {
+		for _, other := range entries {
+			if other.Name == e.Name || removed[other.Name] {
+				continue
+			}
+			if d, ok := deps[other.Name]; ok && containsName(d, e.Name) {
+				pinned := isPinned(e)
+				if !pinned {
+					removed[e.Name] = true
+				}
+				redundant = append(redundant, redundantEntry{pkg: e, by: other.Name, pinned: pinned})
+				break
+			}
+		}
+	}
+	return redundant
+}
+
+// removeEntries returns the packages without the redundant, unpinned, ones
+func removeEntries(pkgs []weldr.Package, redundant []redundantEntry) []weldr.Package {
+	remove := make(map[string]bool)
+	for _, r := range redundant {
+		if !r.pinned {
+			remove[r.pkg.Name] = true
+		}
+	}
+	var kept []weldr.Package
+	for _, p := range pkgs {
+		if !remove[p.Name] {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+func prune(cmd *cobra.Command, args []string) error {
+	// Only committing the changes needs a clean workspace, listing them works with the workspace copy
+	var bp weldr.Blueprint
+	var err error
+	if pruneApply {
+		bp, err = getBlueprintToCommit(cmd, args[0])
+	} else {
+		bp, err = getBlueprint(cmd, args[0])
+	}
+	if err != nil {
+		return err
+	}
+	entries := append(append([]weldr.Package{}, bp.Packages...), bp.Modules...)
+	names := topLevelNames(bp)
+	if len(names) == 0 {
+		fmt.Printf("%s has no packages or modules\n", bp.Name)
+		return nil
+	}
+
+	deps, errs := packageClosures(names, bp.Distro)
+	if len(errs) > 0 {
+		// A package that cannot be depsolved could be pulling in any of the others
+		var failed []string
+		for _, name := range names {
+			if err, ok := errs[name]; ok {
+				failed = append(failed, fmt.Sprintf("%s: %s", name, err))
+			}
+		}
+		return root.ExecutionError(cmd, "Prune Error: cannot depsolve %s", strings.Join(failed, "; "))
+	}
+
+	redundant := findRedundant(entries, deps)
+	if len(redundant) == 0 {
+		fmt.Printf("No redundant packages in %s\n", bp.Name)
+		return nil
+	}
+	var changes []string
+	for _, r := range redundant {
+		if r.pinned {
+			fmt.Printf("%s (%s) is pulled in by %s, it is kept because of its version pin\n", r.pkg.Name, r.pkg.Version, r.by)
+		} else {
+			fmt.Printf("%s is pulled in by %s\n", r.pkg.Name, r.by)
+			changes = append(changes, fmt.Sprintf("removed %s", r.pkg.Name))
+		}
+	}
+	if !pruneApply || len(changes) == 0 {
+		return nil
+	}
+
+	bp.Packages = removeEntries(bp.Packages, redundant)
+	bp.Modules = removeEntries(bp.Modules, redundant)
+	return commitBlueprint(cmd, bp, strings.Join(changes, ", "))
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+const pruneTestTOML = `name = "prune-bp"
+description = "redundant packages"
+version = "0.1.0"
+distro = "fedora-prune"
+
+[[packages]]
+name = "ncurses-libs"
+version = "*"
+
+[[packages]]
+name = "tmux"
+version = "*"
+
+[[packages]]
+name = "glibc"
+version = "2.33"
+
+[[modules]]
+name = "libevent"
+version = "*"
+`
+
+var pruneTestClosures = map[string][]string{
+	"tmux":         {"glibc", "libevent", "ncurses-libs", "tmux"},
+	"ncurses-libs": {"glibc", "ncurses-libs"},
+	"glibc":        {"glibc"},
+	"libevent":     {"glibc", "libevent"},
+}
+
+// pruneTestServer returns the blueprint, the dependencies of each package and records
+// the pushed blueprint. Packages without dependencies cannot be depsolved.
+func pruneTestServer(bpTOML string, changed bool, pushed *string) func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		var body []byte
+		switch {
+		case request.Method == "POST":
+			data, _ := ioutil.ReadAll(request.Body)
+			*pushed = string(data)
+			body = []byte(`{"status": true}`)
+		case strings.HasPrefix(request.URL.Path, "/api/v1/projects/depsolve/"):
+			name := strings.TrimPrefix(request.URL.Path, "/api/v1/projects/depsolve/")
+			if _, ok := pruneTestClosures[name]; !ok {
+				return &http.Response{
+					Request:    request,
+					StatusCode: 400,
+					Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"status": false, "errors": [{"id": "ProjectsError", "msg": "no package matches ` + name + `"}]}`))),
+				}, nil
+			}
+			var projects []map[string]string
+			for _, d := range pruneTestClosures[name] {
+				projects = append(projects, map[string]string{"name": d})
+			}
+			body, _ = json.Marshal(map[string]interface{}{"projects": projects})
+		case request.URL.Query().Get("format") != "toml":
+			body = []byte(blueprintInfoBody(bpTOML, changed))
+		default:
+			body = []byte(bpTOML)
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		}, nil
+	}
+}
+
+func runPrune(t *testing.T, args ...string) (string, string, string, error) {
+	return runPruneServer(t, pruneTestTOML, false, args...)
+}
+
+func runPruneServer(t *testing.T, bpTOML string, changed bool, args ...string) (string, string, string, error) {
+	var pushed string
+	stdout, stderr, err := executeCmd(t, pruneTestServer(bpTOML, changed, &pushed), append([]string{"blueprints", "prune-redundant"}, args...)...)
+	return stdout, stderr, pushed, err
+}
+
+func TestCmdBlueprintsPrune(t *testing.T) {
+	stdout, stderr, pushed, err := runPrune(t, "--apply=false", "prune-bp")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, `ncurses-libs is pulled in by tmux
+glibc (2.33) is pulled in by tmux, it is kept because of its version pin
+libevent is pulled in by tmux
+`, stdout)
+	assert.Equal(t, "", pushed)
+}
+
+func TestCmdBlueprintsPruneApply(t *testing.T) {
+	stdout, stderr, pushed, err := runPrune(t, "--apply", "prune-bp")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Contains(t, stdout, "prune-bp v0.1.1: removed ncurses-libs, removed libevent\n")
+
+	bp, err := weldr.NewBlueprintFromTOML(pushed)
+	require.Nil(t, err)
+	assert.Equal(t, "0.1.1", bp.Version)
+	assert.Equal(t, []weldr.Package{{Name: "tmux", Version: "*"}, {Name: "glibc", Version: "2.33"}}, bp.Packages)
+	assert.Equal(t, 0, len(bp.Modules))
+}
+
+func TestCmdBlueprintsPruneWorkspace(t *testing.T) {
+	// Listing the redundant packages works with workspace changes
+	stdout, stderr, _, err := runPruneServer(t, pruneTestTOML, true, "--apply=false", "prune-bp")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Contains(t, stdout, "ncurses-libs is pulled in by tmux\n")
+
+	// Applying them would commit the workspace changes too
+	_, stderr, pushed, err := runPruneServer(t, pruneTestTOML, true, "--apply", "prune-bp")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Blueprint Error: prune-bp has uncommitted workspace changes, commit or discard them with blueprints workspace first\n", stderr)
+	assert.Equal(t, "", pushed)
+}
+
+func TestCmdBlueprintsPruneDepsolveError(t *testing.T) {
+	bpTOML := pruneTestTOML + "\n[[packages]]\nname = \"missing-a\"\n\n[[packages]]\nname = \"missing-b\"\n"
+	stdout, stderr, pushed, err := runPruneServer(t, bpTOML, false, "--apply", "prune-bp")
+	require.NotNil(t, err)
+	assert.Equal(t, "", stdout)
+	assert.Equal(t, "ERROR: Prune Error: cannot depsolve missing-a: ProjectsError: no package matches missing-a; "+
+		"missing-b: ProjectsError: no package matches missing-b\n", stderr)
+	assert.Equal(t, "", pushed)
+}
+
+func TestFindRedundantCycle(t *testing.T) {
+	// Packages that pull in each other are not both removed
+	entries := []weldr.Package{{Name: "a"}, {Name: "b"}}
+	deps := map[string][]string{"a": {"a", "b"}, "b": {"a", "b"}}
+	redundant := findRedundant(entries, deps)
+	require.Equal(t, 1, len(redundant))
+	assert.Equal(t, "a", redundant[0].pkg.Name)
+	assert.Equal(t, "b", redundant[0].by)
+}
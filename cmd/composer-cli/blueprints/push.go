@@ -8,27 +8,133 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
 )
 
 var (
 	pushCmd = &cobra.Command{
-		Use:   "push BLUEPRINT",
-		Short: "Push the TOML blueprint file to the server",
-		Long:  "Push the TOML blueprint file to the server, overwriting the previous version",
-		RunE:  push,
-		Args:  cobra.MinimumNArgs(1),
+		Use:   "push BLUEPRINT [--force] [--bump patch|minor|major|auto]",
+		Short: "Push the blueprint file to the server",
+		Long: `Push the TOML, JSON (.json), or YAML (.yaml, .yml) blueprint file to the server, overwriting the previous version
+
+Blueprints that are the same as the server's copy, ignoring the version, are
+skipped unless --force is used.
+
+--bump sets the version to the next semantic version after the server's copy and
+writes it back to the file. auto bumps the minor version when packages, modules,
+groups, or customizations are added, and the patch version for anything else,
+eg. pinning a package version. New blueprints are pushed with their own version.`,
+		RunE: push,
+		Args: cobra.MinimumNArgs(1),
 	}
+	pushForce bool
+	pushBump  string
 )
 
+var bumpParts = []string{"patch", "minor", "major", "auto"}
+
 func init() {
+	pushCmd.Flags().BoolVarP(&pushForce, "force", "", false, "Push the blueprints even if they have not changed")
+	pushCmd.Flags().StringVarP(&pushBump, "bump", "", "", "Bump the version: patch, minor, major, or auto")
 	blueprintsCmd.AddCommand(pushCmd)
 }
 
+// serverBlueprint returns the server's copy of the blueprint
+// It returns nil when the blueprint is new, and true if it has uncommitted workspace changes.
+func serverBlueprint(name string) (*weldr.Blueprint, bool, error) {
+	bps, changes, errors, err := root.Client.GetBlueprintsInfoJSON([]string{name})
+	if err != nil {
+		return nil, false, err
+	}
+	for _, e := range errors {
+		if e.ID != "UnknownBlueprint" {
+			return nil, false, fmt.Errorf("%s: %s", e.ID, e.Msg)
+		}
+	}
+	if len(bps) == 0 {
+		return nil, false, nil
+	}
+	changed := false
+	for _, c := range changes {
+		if c.Changed {
+			changed = true
+		}
+	}
+	current, err := decodeServerBlueprint(bps[0])
+	if err != nil {
+		return nil, false, err
+	}
+	return &current, changed, nil
+}
+
+var (
+	tomlTableRegex   = regexp.MustCompile(`(?m)^\s*\[`)
+	tomlVersionRegex = regexp.MustCompile(`(?m)^(\s*version\s*=\s*)("[^"]*"|'[^']*')`)
+	tomlNameLine     = regexp.MustCompile(`(?m)^\s*name\s*=.*\n`)
+	yamlVersionRegex = regexp.MustCompile(`(?m)^version:.*$`)
+	yamlNameLine     = regexp.MustCompile(`(?m)^name:.*\n`)
+)
+
+// setBlueprintVersion returns the blueprint file data with the version changed
+// TOML and YAML files are edited in place to keep their comments and layout, JSON
+// files are rewritten from the blueprint.
+func setBlueprintVersion(data, format string, bp weldr.Blueprint) (string, error) {
+	switch format {
+	case "toml":
+		// Only look at the top level keys, the packages have versions too
+		end := len(data)
+		if loc := tomlTableRegex.FindStringIndex(data); loc != nil {
+			end = loc[0]
+		}
+		top, rest := data[:end], data[end:]
+		if tomlVersionRegex.MatchString(top) {
+			top = tomlVersionRegex.ReplaceAllString(top, fmt.Sprintf("${1}%q", bp.Version))
+		} else {
+			top = insertAfter(top, tomlNameLine, fmt.Sprintf("version = %q\n", bp.Version))
+		}
+		return top + rest, nil
+	case "yaml":
+		if yamlVersionRegex.MatchString(data) {
+			return yamlVersionRegex.ReplaceAllString(data, "version: "+bp.Version), nil
+		}
+		return insertAfter(data, yamlNameLine, "version: "+bp.Version+"\n"), nil
+	}
+	return bp.Format(format)
+}
+
+// insertAfter inserts line after the first match of the regex, or at the start
+func insertAfter(data string, r *regexp.Regexp, line string) string {
+	loc := r.FindStringIndex(data)
+	if loc == nil {
+		return line + data
+	}
+	return data[:loc[1]] + line + data[loc[1]:]
+}
+
+// bumpVersion sets the blueprint's version to the next one after the server's version
+func bumpVersion(bp *weldr.Blueprint, current weldr.Blueprint) error {
+	part := pushBump
+	if part == "auto" {
+		part = weldr.AutoBumpPart(current, *bp)
+	}
+	version, err := weldr.BumpVersion(current.Version, part)
+	if err != nil {
+		return err
+	}
+	bp.Version = version
+	return nil
+}
+
 func push(cmd *cobra.Command, args []string) (rcErr error) {
+	if len(pushBump) > 0 && !isStringInList(bumpParts, pushBump) {
+		return root.ExecutionError(cmd, "Blueprint Error: unknown --bump %s, it should be one of: %s", pushBump, strings.Join(bumpParts, ", "))
+	}
 	files := root.GetCommaArgs(args)
 	for _, filename := range files {
 		data, err := ioutil.ReadFile(filename)
@@ -36,6 +142,56 @@ func push(cmd *cobra.Command, args []string) (rcErr error) {
 			rcErr = root.ExecutionError(cmd, "Missing blueprint file: %s\n", filename)
 			continue
 		}
+		// TOML blueprints are pushed as-is, the server reports any errors in them.
+		// The version can only be bumped if the blueprint can be parsed.
+		format := root.FormatFromFilename(filename)
+		bp, parseErr := weldr.NewBlueprint(string(data), format)
+		if parseErr != nil && (format != "toml" || len(pushBump) > 0) {
+			rcErr = root.ExecutionError(cmd, "Blueprint Error: %s: %s", filename, parseErr)
+			continue
+		}
+		if unknown := bp.UnknownFields(); parseErr == nil && len(unknown) > 0 {
+			fmt.Fprintf(os.Stderr, "WARNING: %s: unknown blueprint fields: %s\n", filename, strings.Join(unknown, ", "))
+		}
+		if parseErr == nil && bp.Customizations != nil {
+			warnPlaintextPasswords(filename, bp.Customizations)
+		}
+		bumped := ""
+		if parseErr == nil && (!pushForce || len(pushBump) > 0) {
+			current, changed, err := serverBlueprint(bp.Name)
+			if err != nil && len(pushBump) > 0 {
+				rcErr = root.ExecutionError(cmd, "Blueprint Error: %s: cannot read the server's copy to bump the version: %s", bp.Name, err)
+				continue
+			} else if err != nil {
+				fmt.Fprintf(os.Stderr, "WARNING: %s: cannot compare it with the server's copy: %s\n", bp.Name, err)
+			}
+			// A newer version is pushed even when the content is the same
+			if !pushForce && current != nil && !changed && !weldr.NewerVersion(bp.Version, current.Version) &&
+				bp.SameContent(*current) {
+				fmt.Printf("Skipping %s, it has not changed (use --force to push it anyway)\n", bp.Name)
+				continue
+			}
+			if len(pushBump) > 0 && current != nil {
+				if err := bumpVersion(&bp, *current); err != nil {
+					rcErr = root.ExecutionError(cmd, "Blueprint Error: %s: %s", bp.Name, err)
+					continue
+				}
+				if bumped, err = setBlueprintVersion(string(data), format, bp); err != nil {
+					rcErr = root.ExecutionError(cmd, "Blueprint Error: %s: %s", filename, err)
+					continue
+				}
+				data = []byte(bumped)
+			}
+		}
+		// JSON and YAML blueprints are converted to TOML for the server
+		if format != "toml" {
+			toml, err := bp.TOML()
+			if err != nil {
+				rcErr = root.ExecutionError(cmd, "Blueprint Error: %s: %s", filename, err)
+				continue
+			}
+			data = []byte(toml)
+		}
 		resp, err := root.Client.PushBlueprintTOML(string(data))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: Push TOML: %s\n", err)
@@ -44,6 +200,19 @@ func push(cmd *cobra.Command, args []string) (rcErr error) {
 		}
 		if resp != nil && !resp.Status {
 			rcErr = root.ExecutionErrors(cmd, resp.Errors)
+			continue
+		}
+		if len(bumped) > 0 {
+			info, err := os.Stat(filename)
+			if err != nil {
+				rcErr = root.ExecutionError(cmd, "Blueprint Error: %s", err)
+				continue
+			}
+			if err := ioutil.WriteFile(filename, []byte(bumped), info.Mode()); err != nil {
+				rcErr = root.ExecutionError(cmd, "Blueprint Error: %s", err)
+				continue
+			}
+			fmt.Printf("Pushed %s version %s\n", bp.Name, bp.Version)
 		}
 	}
 
@@ -6,15 +6,18 @@ package blueprints
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
 )
 
 func TestCmdBlueprintsPush(t *testing.T) {
@@ -204,3 +207,347 @@ version = "*"`))
 	assert.Equal(t, "POST", mc.Req.Method)
 	assert.Equal(t, "/api/v1/blueprints/new", mc.Req.URL.Path)
 }
+
+func TestCmdBlueprintsPushYAML(t *testing.T) {
+	// Test the "blueprints push" command with a YAML file
+	var pushed string
+	root.SetupCmdTest(blueprintTestServer("", &pushed))
+
+	tmpBp, err := ioutil.TempFile("", "test-bp-*.yaml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBp.Name())
+	_, err = tmpBp.Write([]byte(`name: test-bp-yaml
+description: A test yaml file
+version: 0.0.1
+packages:
+  - name: bash
+    version: "*"
+customizations:
+  services:
+    enabled: [sshd]
+`))
+	require.Nil(t, err)
+
+	cmd, out, err := root.ExecuteTest("blueprints", "push", tmpBp.Name())
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+
+	// It is pushed to the server as TOML
+	bp, err := weldr.NewBlueprintFromTOML(pushed)
+	require.Nil(t, err)
+	assert.Equal(t, "test-bp-yaml", bp.Name)
+	assert.Equal(t, []string{"sshd"}, bp.Customizations.Services.Enabled)
+}
+
+func TestCmdBlueprintsPushJSONUnknownField(t *testing.T) {
+	var pushed string
+	root.SetupCmdTest(blueprintTestServer("", &pushed))
+
+	tmpBp, err := ioutil.TempFile("", "test-bp-*.json")
+	require.Nil(t, err)
+	defer os.Remove(tmpBp.Name())
+	_, err = tmpBp.Write([]byte(`{"name": "test-bp-json", "pakages": []}`))
+	require.Nil(t, err)
+
+	_, out, err := root.ExecuteTest("blueprints", "push", tmpBp.Name())
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "WARNING: "+tmpBp.Name()+": unknown blueprint fields: pakages\n", string(stderr))
+
+	// The unknown field is kept, the server decides what to do with it
+	assert.Contains(t, pushed, "pakages = []")
+}
+
+// pushInfoServer returns the blueprint info for GET requests and records the pushed blueprint
+func pushInfoServer(changed bool, pushed *string) func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		body := fmt.Sprintf(`{
+    "blueprints": [
+        {
+            "name": "test-bp-push",
+            "description": "A test blueprint",
+            "version": "0.0.2",
+            "packages": [{"name": "tmux", "version": "*"}, {"name": "bash", "version": "*"}],
+            "modules": [],
+            "groups": []
+        }
+    ],
+    "changes": [{"name": "test-bp-push", "changed": %v}],
+    "errors": []
+}`, changed)
+		if request.Method == "POST" {
+			data, _ := ioutil.ReadAll(request.Body)
+			*pushed = string(data)
+			body = `{"status": true}`
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+func runPushUnchanged(t *testing.T, changed bool, bpTOML string, args ...string) (string, string) {
+	tmpBp, err := ioutil.TempFile("", "test-bp-*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBp.Name())
+	_, err = tmpBp.Write([]byte(bpTOML))
+	require.Nil(t, err)
+
+	var pushed string
+	root.SetupCmdTest(pushInfoServer(changed, &pushed))
+	cmd, out, err := root.ExecuteTest(append([]string{"blueprints", "push", "--bump=", tmpBp.Name()}, args...)...)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	require.Nil(t, err)
+	return string(stdout), pushed
+}
+
+const pushUnchangedTOML = `name = "test-bp-push"
+description = "A test blueprint"
+version = "0.0.1"
+
+[[packages]]
+name = "bash"
+
+[[packages]]
+name = "tmux"
+version = "*"
+`
+
+func TestCmdBlueprintsPushUnchanged(t *testing.T) {
+	stdout, pushed := runPushUnchanged(t, false, pushUnchangedTOML, "--force=false")
+	assert.Equal(t, "Skipping test-bp-push, it has not changed (use --force to push it anyway)\n", stdout)
+	assert.Equal(t, "", pushed)
+}
+
+func TestCmdBlueprintsPushUnchangedForce(t *testing.T) {
+	stdout, pushed := runPushUnchanged(t, false, pushUnchangedTOML, "--force")
+	assert.Equal(t, "", stdout)
+	assert.Equal(t, pushUnchangedTOML, pushed)
+}
+
+func TestCmdBlueprintsPushUnchangedWorkspace(t *testing.T) {
+	// The server's copy has workspace changes, so the commit may be different
+	stdout, pushed := runPushUnchanged(t, true, pushUnchangedTOML, "--force=false")
+	assert.Equal(t, "", stdout)
+	assert.Equal(t, pushUnchangedTOML, pushed)
+}
+
+func TestCmdBlueprintsPushChanged(t *testing.T) {
+	changed := pushUnchangedTOML + "\n[customizations]\nhostname = \"server\"\n"
+	stdout, pushed := runPushUnchanged(t, false, changed, "--force=false")
+	assert.Equal(t, "", stdout)
+	assert.Equal(t, changed, pushed)
+}
+
+func TestCmdBlueprintsPushVersionChanged(t *testing.T) {
+	// A new version with the same content is pushed
+	bumped := strings.Replace(pushUnchangedTOML, `version = "0.0.1"`, `version = "0.1.0"`, 1)
+	stdout, pushed := runPushUnchanged(t, false, bumped, "--force=false")
+	assert.Equal(t, "", stdout)
+	assert.Equal(t, bumped, pushed)
+
+	// An older version with the same content is skipped
+	stdout, pushed = runPushUnchanged(t, false, pushUnchangedTOML, "--force=false")
+	assert.Equal(t, "Skipping test-bp-push, it has not changed (use --force to push it anyway)\n", stdout)
+	assert.Equal(t, "", pushed)
+
+	// Without a version it is only compared by content
+	noVersion := strings.Replace(pushUnchangedTOML, "version = \"0.0.1\"\n", "", 1)
+	stdout, pushed = runPushUnchanged(t, false, noVersion, "--force=false")
+	assert.Equal(t, "Skipping test-bp-push, it has not changed (use --force to push it anyway)\n", stdout)
+	assert.Equal(t, "", pushed)
+}
+
+func runPushBump(t *testing.T, suffix, bp string, args ...string) (string, string, string) {
+	tmpBp, err := ioutil.TempFile("", "test-bp-*"+suffix)
+	require.Nil(t, err)
+	defer os.Remove(tmpBp.Name())
+	_, err = tmpBp.Write([]byte(bp))
+	require.Nil(t, err)
+
+	var pushed string
+	root.SetupCmdTest(pushInfoServer(false, &pushed))
+	cmd, out, err := root.ExecuteTest(append([]string{"blueprints", "push", "--force=false", tmpBp.Name()}, args...)...)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	require.Nil(t, err)
+	data, err := ioutil.ReadFile(tmpBp.Name())
+	require.Nil(t, err)
+	return string(stdout), pushed, string(data)
+}
+
+func TestCmdBlueprintsPushBumpAuto(t *testing.T) {
+	// Adding a package bumps the minor version of the server's 0.0.2
+	local := "# My blueprint\n" + pushUnchangedTOML + "\n[[packages]]\nname = \"vim\"\nversion = \"*\"\n"
+	expected := strings.Replace(local, `version = "0.0.1"`, `version = "0.1.0"`, 1)
+	stdout, pushed, data := runPushBump(t, ".toml", local, "--bump", "auto")
+	assert.Equal(t, "Pushed test-bp-push version 0.1.0\n", stdout)
+	assert.Equal(t, expected, pushed)
+	assert.Equal(t, expected, data)
+
+	// Pinning a version bumps the patch version
+	local = strings.Replace(pushUnchangedTOML, `version = "*"`, `version = "3.2"`, 1)
+	expected = strings.Replace(local, `version = "0.0.1"`, `version = "0.0.3"`, 1)
+	stdout, _, data = runPushBump(t, ".toml", local, "--bump", "auto")
+	assert.Equal(t, "Pushed test-bp-push version 0.0.3\n", stdout)
+	assert.Equal(t, expected, data)
+}
+
+func TestCmdBlueprintsPushBumpMajor(t *testing.T) {
+	local := strings.Replace(pushUnchangedTOML, `version = "*"`, `version = "3.2"`, 1)
+	stdout, _, data := runPushBump(t, ".toml", local, "--bump", "major")
+	assert.Equal(t, "Pushed test-bp-push version 1.0.0\n", stdout)
+	assert.Contains(t, data, `version = "1.0.0"`)
+
+	// Unchanged blueprints are still skipped
+	stdout, pushed, data := runPushBump(t, ".toml", pushUnchangedTOML, "--bump", "major")
+	assert.Equal(t, "Skipping test-bp-push, it has not changed (use --force to push it anyway)\n", stdout)
+	assert.Equal(t, "", pushed)
+	assert.Equal(t, pushUnchangedTOML, data)
+}
+
+func TestCmdBlueprintsPushBumpYAML(t *testing.T) {
+	local := "name: test-bp-push\npackages:\n  - name: bash\n  - name: tmux\n  - name: vim\n"
+	stdout, pushed, data := runPushBump(t, ".yaml", local, "--bump", "patch")
+	assert.Equal(t, "Pushed test-bp-push version 0.0.3\n", stdout)
+	assert.Equal(t, "name: test-bp-push\nversion: 0.0.3\npackages:\n  - name: bash\n  - name: tmux\n  - name: vim\n", data)
+	bp, err := weldr.NewBlueprintFromTOML(pushed)
+	require.Nil(t, err)
+	assert.Equal(t, "0.0.3", bp.Version)
+}
+
+func TestCmdBlueprintsPushBumpUnknown(t *testing.T) {
+	// Reset the flag for the other tests
+	defer func() { pushBump = "" }()
+
+	root.SetupCmdTest(pushInfoServer(false, new(string)))
+	cmd, out, err := root.ExecuteTest("blueprints", "push", "--bump", "tiny", "bp.toml")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, err)
+	assert.Equal(t, "ERROR: Blueprint Error: unknown --bump tiny, it should be one of: patch, minor, major, auto\n", string(stderr))
+}
+
+func TestSetBlueprintVersion(t *testing.T) {
+	bp := weldr.Blueprint{Name: "test-bp", Version: "1.2.3"}
+	data, err := setBlueprintVersion("name = \"test-bp\"\n\n[[packages]]\nname = \"bash\"\nversion = \"*\"\n", "toml", bp)
+	require.Nil(t, err)
+	assert.Equal(t, "name = \"test-bp\"\nversion = \"1.2.3\"\n\n[[packages]]\nname = \"bash\"\nversion = \"*\"\n", data)
+
+	data, err = setBlueprintVersion("version = '0.1.0'\nname = \"test-bp\"\n", "toml", bp)
+	require.Nil(t, err)
+	assert.Equal(t, "version = \"1.2.3\"\nname = \"test-bp\"\n", data)
+
+	data, err = setBlueprintVersion("name: test-bp\nversion: 0.1.0\n", "yaml", bp)
+	require.Nil(t, err)
+	assert.Equal(t, "name: test-bp\nversion: 1.2.3\n", data)
+}
+
+func TestCmdBlueprintsPushPlaintextPassword(t *testing.T) {
+	var pushed string
+	root.SetupCmdTest(blueprintTestServer("", &pushed))
+
+	tmpBp, err := ioutil.TempFile("", "test-bp-*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBp.Name())
+	_, err = tmpBp.Write([]byte(passwordTestTOML))
+	require.Nil(t, err)
+
+	_, out, err := root.ExecuteTest("blueprints", "push", tmpBp.Name())
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "WARNING: the password for guest in "+tmpBp.Name()+" does not look like a crypt hash\n", string(stderr))
+	assert.Equal(t, passwordTestTOML, pushed)
+}
+
+// pushErrorServer returns an error for the blueprint info and records the pushed blueprint
+func pushErrorServer(pushed *string) func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		body := `{"blueprints": [], "changes": [], "errors": [{"id": "InternalError", "msg": "database is locked"}]}`
+		if request.Method == "POST" {
+			data, _ := ioutil.ReadAll(request.Body)
+			*pushed = string(data)
+			body = `{"status": true}`
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+func TestCmdBlueprintsPushServerError(t *testing.T) {
+	tmpBp, err := ioutil.TempFile("", "test-bp-*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBp.Name())
+	_, err = tmpBp.Write([]byte(pushUnchangedTOML))
+	require.Nil(t, err)
+
+	// Without --bump it is pushed, and the error is reported
+	var pushed string
+	root.SetupCmdTest(pushErrorServer(&pushed))
+	_, out, err := root.ExecuteTest("blueprints", "push", "--force=false", "--bump=", tmpBp.Name())
+	require.NotNil(t, out)
+	require.Nil(t, err)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, err)
+	out.Close()
+	assert.Equal(t, "WARNING: test-bp-push: cannot compare it with the server's copy: InternalError: database is locked\n", string(stderr))
+	assert.Equal(t, pushUnchangedTOML, pushed)
+
+	// The version cannot be bumped without the server's copy
+	pushed = ""
+	_, out, err = root.ExecuteTest("blueprints", "push", "--force=false", "--bump", "patch", tmpBp.Name())
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	stderr, err = ioutil.ReadAll(out.Stderr)
+	require.Nil(t, err)
+	assert.Equal(t, "ERROR: Blueprint Error: test-bp-push: cannot read the server's copy to bump the version: InternalError: database is locked\n", string(stderr))
+	assert.Equal(t, "", pushed)
+	data, err := ioutil.ReadFile(tmpBp.Name())
+	require.Nil(t, err)
+	assert.Equal(t, pushUnchangedTOML, string(data))
+}
+
+func TestCmdBlueprintsPushBumpNew(t *testing.T) {
+	// New blueprints are pushed with their own version
+	tmpBp, err := ioutil.TempFile("", "test-bp-*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBp.Name())
+	_, err = tmpBp.Write([]byte(pushUnchangedTOML))
+	require.Nil(t, err)
+
+	var pushed string
+	root.SetupCmdTest(blueprintTestServer("", &pushed))
+	_, out, err := root.ExecuteTest("blueprints", "push", "--force=false", "--bump", "patch", tmpBp.Name())
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, err)
+	assert.Equal(t, "", string(stderr))
+	assert.Equal(t, pushUnchangedTOML, pushed)
+}
@@ -5,32 +5,65 @@
 package blueprints
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/BurntSushi/toml"
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
 )
 
 var (
 	saveCmd = &cobra.Command{
-		Use:   "save BLUEPRINT,...",
-		Short: "Save the blueprints to TOML files",
-		Long:  "Save the blueprints to TOML files named BLUEPRINT-NAME.toml",
+		Use:   "save BLUEPRINT,... [--format toml|json|yaml]",
+		Short: "Save the blueprints to files",
+		Long:  "Save the blueprints to TOML, JSON, or YAML files named BLUEPRINT-NAME.toml, .json, or .yaml",
 		RunE:  saveToml,
 		Args:  cobra.MinimumNArgs(1),
 	}
+	saveFormat string
 )
 
 func init() {
+	saveCmd.Flags().StringVarP(&saveFormat, "format", "", "toml", "Format of the saved files, toml, json, or yaml")
 	blueprintsCmd.AddCommand(saveCmd)
 }
 
+// decodeServerBlueprint converts a blueprint from the server's JSON response to the blueprint schema
+func decodeServerBlueprint(bp interface{}) (weldr.Blueprint, error) {
+	data, err := json.Marshal(bp)
+	if err != nil {
+		return weldr.Blueprint{}, err
+	}
+	return weldr.NewBlueprintFromJSON(string(data))
+}
+
+// formatServerBlueprint returns a blueprint from the server's JSON response in the canonical order
+// It is converted to the blueprint schema so that every format has the same fields. Blueprints
+// that do not match the schema are written the way the server returned them.
+func formatServerBlueprint(bp interface{}, format string) (string, error) {
+	schema, err := decodeServerBlueprint(bp)
+	if err != nil {
+		m, _ := bp.(map[string]interface{})
+		name, ok := m["name"].(string)
+		if !ok {
+			name = "the server's blueprint"
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: %s does not match the blueprint schema, writing it unchanged: %s\n", name, err)
+		return weldr.FormatDocument(bp, format)
+	}
+	return schema.Canonical().Format(format)
+}
+
 func saveToml(cmd *cobra.Command, args []string) (rcErr error) {
+	if err := checkFormat(saveFormat); err != nil {
+		return root.ExecutionError(cmd, "Save Error: %s", err)
+	}
 	names := root.GetCommaArgs(args)
 	bps, errors, err := root.Client.GetBlueprintsJSON(names)
 	if err != nil {
@@ -50,26 +83,28 @@ func saveToml(cmd *cobra.Command, args []string) (rcErr error) {
 
 		// Save to a file in the current directory, replace spaces with - and
 		// remove anything that looks like path separators or path traversal.
-		filename := strings.ReplaceAll(name, " ", "-") + ".toml"
+		filename := strings.ReplaceAll(name, " ", "-") + "." + saveFormat
 		filename = filepath.Base(filename)
 		if filename == "/" || filename == "." || filename == ".." {
 			fmt.Fprintf(os.Stderr, "ERROR: Invalid blueprint filename: %s\n", name)
 			rcErr = root.ExecutionError(cmd, "")
 			continue
 		}
-		f, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+
+		out, err := formatServerBlueprint(bp, saveFormat)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR: opening file %s: %s\n", "file.toml", err)
+			fmt.Fprintf(os.Stderr, "ERROR: converting blueprint %s: %s\n", name, err)
 			rcErr = root.ExecutionError(cmd, "")
 			continue
 		}
-		defer f.Close()
-		err = toml.NewEncoder(f).Encode(bp)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR: encoding TOML file: %s\n", err)
+		if err := ioutil.WriteFile(filename, []byte(out), 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: writing file %s: %s\n", filename, err)
 			rcErr = root.ExecutionError(cmd, "")
+			continue
+		}
+		if schema, err := decodeServerBlueprint(bp); err == nil && schema.Customizations != nil {
+			warnPlaintextPasswords(filename, schema.Customizations)
 		}
-		f.Close()
 	}
 
 	// If there were any errors, even if other blueprints succeeded, it returns an error
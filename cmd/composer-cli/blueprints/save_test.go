@@ -257,3 +257,155 @@ func TestCmdBlueprintsSaveUnknownJSON(t *testing.T) {
 	_, err = os.Stat("test-no-bp.toml")
 	assert.NotNil(t, err)
 }
+
+func TestCmdBlueprintsSaveYAML(t *testing.T) {
+	// Test the "blueprints save --format yaml" command
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{
+    "blueprints": [
+        {
+            "description": "simple blueprint",
+            "groups": [],
+            "modules": [],
+            "name": "simple",
+            "packages": [
+                {
+                    "name": "bash",
+                    "version": "*"
+                }
+            ],
+            "customizations": {
+                "hostname": "simple"
+            },
+            "version": "0.1.0"
+        }
+    ],
+    "changes": [],
+    "errors": []
+}`
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	dir, err := ioutil.TempDir("", "test-bp-save-*")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	prevDir, _ := os.Getwd()
+	err = os.Chdir(dir)
+	require.Nil(t, err)
+	//nolint:errcheck
+	defer os.Chdir(prevDir)
+
+	cmd, out, err := root.ExecuteTest("blueprints", "save", "--format", "yaml", "simple")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	require.NotNil(t, cmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stderr)
+
+	data, err := ioutil.ReadFile("simple.yaml")
+	require.Nil(t, err)
+	assert.Equal(t, `name: simple
+description: simple blueprint
+version: 0.1.0
+packages:
+  - name: bash
+    version: '*'
+modules: []
+groups: []
+customizations:
+  hostname: simple
+`, string(data))
+
+	// Unknown formats are an error
+	_, out, err = root.ExecuteTest("blueprints", "save", "--format", "xml", "simple")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	stderr, err = ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "ERROR: Save Error: unknown format xml, it should be one of: toml, json, yaml\n", string(stderr))
+
+	// Reset the format for the other tests
+	_, out, err = root.ExecuteTest("blueprints", "save", "--format", "toml", "simple")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+}
+
+func TestCmdBlueprintsSavePlaintextPassword(t *testing.T) {
+	root.SetupCmdTest(blueprintTestServer(passwordTestTOML, new(string)))
+
+	dir, err := ioutil.TempDir("", "test-bp-save-*")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	prevDir, _ := os.Getwd()
+	err = os.Chdir(dir)
+	require.Nil(t, err)
+	//nolint:errcheck
+	defer os.Chdir(prevDir)
+
+	_, out, err := root.ExecuteTest("blueprints", "save", "--format", "toml", "users")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "WARNING: the password for guest in users.toml does not look like a crypt hash\n", string(stderr))
+	_, err = os.Stat("users.toml")
+	assert.Nil(t, err)
+}
+
+func TestCmdBlueprintsSaveSchemaMismatch(t *testing.T) {
+	// The server's blueprint is saved unchanged when it does not match the client's schema
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		json := `{"blueprints": [{"name": "future", "version": "0.1.0",
+			"customizations": {"user": [{"name": "admin", "uid": "1010"}]}}],
+			"changes": [{"name": "future", "changed": false}], "errors": []}`
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	dir, err := ioutil.TempDir("", "test-bp-save-*")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	prevDir, _ := os.Getwd()
+	err = os.Chdir(dir)
+	require.Nil(t, err)
+	//nolint:errcheck
+	defer os.Chdir(prevDir)
+
+	_, out, err := root.ExecuteTest("blueprints", "save", "--format", "toml", "future")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), "WARNING: future does not match the blueprint schema, writing it unchanged: ")
+	data, err := ioutil.ReadFile("future.toml")
+	require.Nil(t, err)
+	assert.Contains(t, string(data), "uid = \"1010\"")
+}
+
+func TestFormatServerBlueprintNotObject(t *testing.T) {
+	// A server value that is not an object is reported, not a panic
+	out, err := root.NewOutputCapture()
+	require.Nil(t, err)
+	defer out.Close()
+	_, err = formatServerBlueprint([]interface{}{"not", "a", "blueprint"}, "toml")
+	assert.NotNil(t, err)
+	require.Nil(t, out.Rewind())
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, err)
+	assert.Contains(t, string(stderr), "WARNING: the server's blueprint does not match the blueprint schema, writing it unchanged: ")
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// cryptAlphabet is the base64 alphabet used by crypt(3)
+const cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const (
+	sha512SaltLength    = 16
+	sha512DefaultRounds = 5000
+	sha512MinRounds     = 1000
+	sha512MaxRounds     = 999999999
+)
+
+// sha512CryptOrder is the order the bytes of the final digest are encoded in
+var sha512CryptOrder = [][3]int{
+	{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4},
+	{47, 5, 26}, {6, 27, 48}, {28, 49, 7}, {50, 8, 29}, {9, 30, 51},
+	{31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13}, {56, 14, 35},
+	{15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19},
+	{62, 20, 41},
+}
+
+// cryptHashRegex matches the $id$[params$]salt$hash format used by crypt(3)
+var cryptHashRegex = regexp.MustCompile(`^\$(1|2[abxy]|5|6|7|y|gy|sha1)\$([^$]+\$){1,2}[./0-9A-Za-z]{22,}$`)
+
+// isCryptHash returns true if the string looks like a crypt(3) password hash
+func isCryptHash(s string) bool {
+	return cryptHashRegex.MatchString(s)
+}
+
+// sha512Crypt returns the crypt(3) SHA-512 ($6$) hash of the password
+// This follows Ulrich Drepper's "Unix crypt using SHA-256 and SHA-512" specification.
+// The salt is truncated to 16 characters, rounds of 0 uses the default of 5000.
+func sha512Crypt(password, salt string, rounds int) string {
+	if len(salt) > sha512SaltLength {
+		salt = salt[:sha512SaltLength]
+	}
+	customRounds := rounds != 0
+	if !customRounds {
+		rounds = sha512DefaultRounds
+	} else if rounds < sha512MinRounds {
+		rounds = sha512MinRounds
+	} else if rounds > sha512MaxRounds {
+		rounds = sha512MaxRounds
+	}
+	p := []byte(password)
+	s := []byte(salt)
+
+	// Digest B is password, salt, password
+	h := sha512.New()
+	h.Write(p)
+	h.Write(s)
+	h.Write(p)
+	b := h.Sum(nil)
+
+	// Digest A is password, salt, and bytes from B for the length of the password
+	h.Reset()
+	h.Write(p)
+	h.Write(s)
+	cnt := len(p)
+	for ; cnt > sha512.Size; cnt -= sha512.Size {
+		h.Write(b)
+	}
+	h.Write(b[:cnt])
+	for cnt = len(p); cnt > 0; cnt >>= 1 {
+		if cnt&1 != 0 {
+			h.Write(b)
+		} else {
+			h.Write(p)
+		}
+	}
+	a := h.Sum(nil)
+
+	// Byte sequence P is made from the digest of the password repeated once for each byte
+	h.Reset()
+	for i := 0; i < len(p); i++ {
+		h.Write(p)
+	}
+	pSeq := repeatBytes(h.Sum(nil), len(p))
+
+	// Byte sequence S is made from the digest of the salt repeated 16 + A[0] times
+	h.Reset()
+	for i := 0; i < 16+int(a[0]); i++ {
+		h.Write(s)
+	}
+	sSeq := repeatBytes(h.Sum(nil), len(s))
+
+	for i := 0; i < rounds; i++ {
+		h.Reset()
+		if i&1 != 0 {
+			h.Write(pSeq)
+		} else {
+			h.Write(a)
+		}
+		if i%3 != 0 {
+			h.Write(sSeq)
+		}
+		if i%7 != 0 {
+			h.Write(pSeq)
+		}
+		if i&1 != 0 {
+			h.Write(a)
+		} else {
+			h.Write(pSeq)
+		}
+		a = h.Sum(nil)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("$6$")
+	if customRounds {
+		fmt.Fprintf(&sb, "rounds=%d$", rounds)
+	}
+	sb.WriteString(salt)
+	sb.WriteString("$")
+	for _, o := range sha512CryptOrder {
+		cryptBase64(&sb, uint(a[o[0]])<<16|uint(a[o[1]])<<8|uint(a[o[2]]), 4)
+	}
+	cryptBase64(&sb, uint(a[63]), 2)
+	return sb.String()
+}
+
+// repeatBytes returns length bytes made by repeating the digest
+func repeatBytes(digest []byte, length int) []byte {
+	result := make([]byte, 0, length)
+	for len(result) < length {
+		n := length - len(result)
+		if n > len(digest) {
+			n = len(digest)
+		}
+		result = append(result, digest[:n]...)
+	}
+	return result
+}
+
+// cryptBase64 writes n characters encoding the low bits of w
+func cryptBase64(sb *strings.Builder, w uint, n int) {
+	for ; n > 0; n-- {
+		sb.WriteByte(cryptAlphabet[w&0x3f])
+		w >>= 6
+	}
+}
+
+// hashPassword returns the SHA-512 crypt hash of the password using a random salt
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, sha512SaltLength)
+	max := big.NewInt(int64(len(cryptAlphabet)))
+	for i := range salt {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		salt[i] = cryptAlphabet[n.Int64()]
+	}
+	return sha512Crypt(password, string(salt), 0), nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSHA512Crypt(t *testing.T) {
+	// Test vectors from the "Unix crypt using SHA-256 and SHA-512" specification
+	tests := []struct {
+		salt     string
+		rounds   int
+		password string
+		expected string
+	}{
+		{"saltstring", 0, "Hello world!",
+			"$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"},
+		{"saltstringsaltstring", 10000, "Hello world!",
+			"$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v."},
+		{"roundstoolow", 10, "the minimum number is still observed",
+			"$6$rounds=1000$roundstoolow$kUMsbe306n21p9R.FRkW3IGn.S9NPN0x50YhH1xhLsPuWGsUSklZt58jaTfF4ZEQpyUNGc0dqbpBYYBaHHrsX."},
+		{"toolongsaltstring", 0, "This is just a test",
+			"$6$toolongsaltstrin$lQ8jolhgVRVhY4b5pZKaysCLi0QBxGoNeKQzQ3glMhwllF7oGDZxUhx1yxdYcz/e1JSbq3y6JMxxl8audkUEm0"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, sha512Crypt(tt.password, tt.salt, tt.rounds))
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := hashPassword("secret")
+	require.Nil(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$6$"))
+	assert.True(t, isCryptHash(hash))
+
+	// The salt is used to check the password
+	fields := strings.Split(hash, "$")
+	require.Equal(t, 4, len(fields))
+	assert.Equal(t, 16, len(fields[2]))
+	assert.Equal(t, hash, sha512Crypt("secret", fields[2], 0))
+
+	// Each hash uses a different salt
+	hash2, err := hashPassword("secret")
+	require.Nil(t, err)
+	assert.NotEqual(t, hash, hash2)
+}
+
+func TestIsCryptHash(t *testing.T) {
+	assert.True(t, isCryptHash("$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"))
+	assert.True(t, isCryptHash("$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v."))
+	assert.True(t, isCryptHash("$y$j9T$F5Jx5fExrKuPp53xLKQ..1$X3DX6M94c7o.9agCG9G317fhZg9SqC.5i5rd.RhAtQ7"))
+	assert.True(t, isCryptHash("$2b$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"))
+	assert.False(t, isCryptHash("qweqweqwe"))
+	assert.False(t, isCryptHash("$6$"))
+	assert.False(t, isCryptHash("$6$salt$short"))
+	assert.False(t, isCryptHash(""))
+}
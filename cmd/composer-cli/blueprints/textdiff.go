@@ -0,0 +1,149 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// diffLine is a single line of a diff, Op is one of ' ', '-', or '+'
+type diffLine struct {
+	Op   byte
+	Text string
+}
+
+// diffLines returns the edit script that turns a into b
+// It uses the longest common subsequence of the lines, which is fine for
+// blueprint sized files.
+func diffLines(a, b []string) []diffLine {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		} else {
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j]})
+	}
+	return lines
+}
+
+// splitLines splits text into lines, ignoring a trailing newline
+func splitLines(text string) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// unifiedDiff returns a unified diff of the changes from a to b
+// fromName and toName are used for the --- and +++ header lines. If there are no
+// differences an empty string is returned.
+func unifiedDiff(fromName, toName, a, b string) string {
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	// Find the ranges of lines to output, changes plus surrounding context
+	type hunk struct{ start, end int }
+	var hunks []hunk
+	for i, l := range lines {
+		if l.Op == ' ' {
+			continue
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i + diffContext + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1].end {
+			hunks[len(hunks)-1].end = end
+		} else {
+			hunks = append(hunks, hunk{start, end})
+		}
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	// Track the line numbers in a and b for the hunk headers
+	aLine, bLine, pos := 0, 0, 0
+	for _, h := range hunks {
+		for ; pos < h.start; pos++ {
+			aLine++
+			bLine++
+		}
+		var aCount, bCount int
+		for _, l := range lines[h.start:h.end] {
+			if l.Op != '+' {
+				aCount++
+			}
+			if l.Op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+		for _, l := range lines[h.start:h.end] {
+			fmt.Fprintf(&sb, "%c%s\n", l.Op, l.Text)
+			if l.Op != '+' {
+				aLine++
+			}
+			if l.Op != '-' {
+				bLine++
+			}
+		}
+		pos = h.end
+	}
+	return sb.String()
+}
+
+// hunkRange formats the start,count part of a hunk header
+// start is the 0 based index of the line before the hunk
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
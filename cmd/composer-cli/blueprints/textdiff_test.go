@@ -0,0 +1,59 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiffNone(t *testing.T) {
+	assert.Equal(t, "", unifiedDiff("a", "b", "one\ntwo\n", "one\ntwo\n"))
+	assert.Equal(t, "", unifiedDiff("a", "b", "", ""))
+}
+
+func TestUnifiedDiffChange(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	b := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n"
+	expected := `--- a
++++ b
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`
+	assert.Equal(t, expected, unifiedDiff("a", "b", a, b))
+}
+
+func TestUnifiedDiffHunks(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n"
+	// The first hunk has no context before it
+	expected := `--- a
++++ b
+@@ -1,3 +1,4 @@
++0
+ 1
+ 2
+ 3
+@@ -9,4 +10,3 @@
+ 9
+ 10
+ 11
+-12
+`
+	assert.Equal(t, expected, unifiedDiff("a", "b", a, b))
+}
+
+func TestUnifiedDiffAddRemove(t *testing.T) {
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+one\n+two\n", unifiedDiff("a", "b", "", "one\ntwo\n"))
+	assert.Equal(t, "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-one\n-two\n", unifiedDiff("a", "b", "one\ntwo", ""))
+}
@@ -5,6 +5,8 @@
 package blueprints
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
@@ -12,26 +14,79 @@ import (
 
 var (
 	undoCmd = &cobra.Command{
-		Use:   "undo BLUEPRINT COMMIT",
+		Use:   "undo BLUEPRINT COMMIT [--yes]",
 		Short: "Undo a blueprint change",
-		Long:  "Undo a blueprint change and revert to COMMIT",
-		RunE:  undo,
-		Args:  cobra.ExactArgs(2),
+		Long: `Undo a blueprint change and revert to COMMIT
+
+It lists the changes from the newest commit to COMMIT and asks for confirmation
+unless --yes is used.`,
+		RunE: undo,
+		Args: cobra.ExactArgs(2),
 	}
+	undoYes bool
 )
 
 func init() {
+	undoCmd.Flags().BoolVarP(&undoYes, "yes", "y", false, "Undo the change without asking for confirmation")
 	blueprintsCmd.AddCommand(undoCmd)
 }
 
 func undo(cmd *cobra.Command, args []string) error {
-	resp, err := root.Client.UndoBlueprint(args[0], args[1])
+	name, commit := args[0], args[1]
+	if root.JSONOutput {
+		// The preview and prompt cannot be shown with the raw JSON output
+		if !undoYes {
+			return root.ExecutionError(cmd, "Undo Error: --yes is required with --json")
+		}
+		return undoBlueprint(cmd, name, commit)
+	}
+
+	// The newest commit is needed to reverse the undo
+	changes, errors, err := root.Client.GetBlueprintsChanges([]string{name})
+	if err != nil {
+		return root.ExecutionError(cmd, "Undo Error: %s", err)
+	}
+	if len(errors) > 0 {
+		return root.ExecutionErrors(cmd, errors)
+	}
+	if len(changes) != 1 || len(changes[0].Changes) == 0 {
+		return root.ExecutionError(cmd, "Undo Error: no changes for %s", name)
+	}
+	head := changes[0].Changes[0].Commit
+
+	diff, errors, err := root.Client.GetBlueprintDiff(name, "NEWEST", commit)
+	if err != nil {
+		return root.ExecutionError(cmd, "Undo Error: %s", err)
+	}
+	if len(errors) > 0 {
+		return root.ExecutionErrors(cmd, errors)
+	}
+	if len(diff) == 0 {
+		fmt.Printf("%s at %s is the same as the newest commit\n", name, commit)
+	} else {
+		fmt.Printf("Undoing %s to %s will make these changes:\n", name, commit)
+		for _, d := range diff {
+			fmt.Printf("    %s\n", describeDiff(d))
+		}
+	}
+	if !undoYes && !root.Confirm(fmt.Sprintf("Undo %s to %s?", name, commit)) {
+		return root.ExecutionError(cmd, "Undo cancelled")
+	}
+
+	if err := undoBlueprint(cmd, name, commit); err != nil {
+		return err
+	}
+	fmt.Printf("To reverse this run: composer-cli blueprints undo %s %s\n", name, head)
+	return nil
+}
+
+func undoBlueprint(cmd *cobra.Command, name, commit string) error {
+	resp, err := root.Client.UndoBlueprint(name, commit)
 	if err != nil {
 		return root.ExecutionError(cmd, "Undo Error: %s", err)
 	}
 	if resp != nil && !resp.Status {
 		return root.ExecutionErrors(cmd, resp.Errors)
 	}
-
 	return nil
 }
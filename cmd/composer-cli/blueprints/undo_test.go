@@ -16,18 +16,40 @@ import (
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
 )
 
-func TestCmdBlueprintsUndo(t *testing.T) {
-	// Test the "blueprints undo" command
-	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
-		json := `{"status": true}`
-
+// undoTestServer returns the changes and the diff for cli-test-bp-1
+// Other blueprints are unknown.
+func undoTestServer(request *http.Request) (*http.Response, error) {
+	var json string
+	switch request.URL.Path {
+	case "/api/v1/blueprints/changes/cli-test-bp-1":
+		json = `{"blueprints": [{"changes": [
+			{"commit": "9d519a60b9006f8510c2c6b1a417f7807546bb62", "message": "saved", "revision": null, "timestamp": "2021-02-08T15:44:35Z"},
+			{"commit": "f1da83187730c5e65d5931e2811481c5fe3407e5", "message": "saved", "revision": null, "timestamp": "2021-02-04T14:48:08Z"}
+			], "name": "cli-test-bp-1", "total": 2}], "errors": [], "limit": 2, "offset": 0}`
+	case "/api/v1/blueprints/diff/cli-test-bp-1/NEWEST/f1da83187730c5e65d5931e2811481c5fe3407e5":
+		json = `{"diff": [{"old": {"Version": "0.0.2"}, "new": {"Version": "0.0.1"}},
+			{"old": {"Package": {"name": "tmux", "version": "*"}}, "new": null}]}`
+	case "/api/v1/blueprints/undo/cli-test-bp-1/f1da83187730c5e65d5931e2811481c5fe3407e5":
+		json = `{"status": true}`
+	default:
+		json = `{"status": false, "errors": [{"id": "UnknownCommit", "msg": "Unknown blueprint"}]}`
 		return &http.Response{
-			StatusCode: 200,
+			Request:    request,
+			StatusCode: 400,
 			Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
 		}, nil
-	})
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
+	}, nil
+}
+
+func TestCmdBlueprintsUndo(t *testing.T) {
+	// Test the "blueprints undo" command
+	mc := root.SetupCmdTest(undoTestServer)
 
-	cmd, out, err := root.ExecuteTest("blueprints", "undo", "cli-test-bp-1", "f1da83187730c5e65d5931e2811481c5fe3407e5")
+	cmd, out, err := root.ExecuteTest("blueprints", "undo", "--yes", "cli-test-bp-1", "f1da83187730c5e65d5931e2811481c5fe3407e5")
 	require.NotNil(t, out)
 	defer out.Close()
 	require.Nil(t, err)
@@ -37,7 +59,11 @@ func TestCmdBlueprintsUndo(t *testing.T) {
 	assert.Equal(t, cmd, undoCmd)
 	stdout, err := ioutil.ReadAll(out.Stdout)
 	assert.Nil(t, err)
-	assert.Equal(t, []byte(""), stdout)
+	assert.Equal(t, `Undoing cli-test-bp-1 to f1da83187730c5e65d5931e2811481c5fe3407e5 will make these changes:
+    Changed Version 0.0.2 -> 0.0.1
+    Removed Package tmux *
+To reverse this run: composer-cli blueprints undo cli-test-bp-1 9d519a60b9006f8510c2c6b1a417f7807546bb62
+`, string(stdout))
 	stderr, err := ioutil.ReadAll(out.Stderr)
 	assert.Nil(t, err)
 	assert.Equal(t, []byte(""), stderr)
@@ -45,6 +71,47 @@ func TestCmdBlueprintsUndo(t *testing.T) {
 	assert.Equal(t, "/api/v1/blueprints/undo/cli-test-bp-1/f1da83187730c5e65d5931e2811481c5fe3407e5", mc.Req.URL.Path)
 }
 
+func TestCmdBlueprintsUndoConfirm(t *testing.T) {
+	// Answering yes undoes the change, no cancels it
+	for _, answer := range []string{"y", "n"} {
+		restore, err := root.SetupStdin(answer + "\n")
+		require.Nil(t, err)
+		mc := root.SetupCmdTest(undoTestServer)
+		cmd, out, err := root.ExecuteTest("blueprints", "undo", "--yes=false", "cli-test-bp-1", "f1da83187730c5e65d5931e2811481c5fe3407e5")
+		restore()
+		require.NotNil(t, out)
+		require.NotNil(t, cmd)
+		stdout, rerr := ioutil.ReadAll(out.Stdout)
+		assert.Nil(t, rerr)
+		stderr, rerr := ioutil.ReadAll(out.Stderr)
+		assert.Nil(t, rerr)
+		out.Close()
+		assert.Contains(t, string(stdout), "Undo cli-test-bp-1 to f1da83187730c5e65d5931e2811481c5fe3407e5? [y/N] ")
+		if answer == "y" {
+			require.Nil(t, err)
+			assert.Equal(t, "POST", mc.Req.Method)
+			assert.Contains(t, string(stdout), "To reverse this run")
+		} else {
+			require.NotNil(t, err)
+			assert.Equal(t, "GET", mc.Req.Method)
+			assert.Equal(t, "ERROR: Undo cancelled\n", string(stderr))
+		}
+	}
+}
+
+func TestCmdBlueprintsUndoJSONNoYes(t *testing.T) {
+	// --json cannot prompt, it requires --yes
+	root.SetupCmdTest(undoTestServer)
+	cmd, out, err := root.ExecuteTest("--json", "blueprints", "undo", "--yes=false", "cli-test-bp-1", "f1da83187730c5e65d5931e2811481c5fe3407e5")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "ERROR: Undo Error: --yes is required with --json\n", string(stderr))
+}
+
 func TestCmdBlueprintsUndoJSON(t *testing.T) {
 	// Test the "blueprints undo" command
 	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
@@ -56,7 +123,7 @@ func TestCmdBlueprintsUndoJSON(t *testing.T) {
 		}, nil
 	})
 
-	cmd, out, err := root.ExecuteTest("--json", "blueprints", "undo", "cli-test-bp-1", "f1da83187730c5e65d5931e2811481c5fe3407e5")
+	cmd, out, err := root.ExecuteTest("--json", "blueprints", "undo", "--yes", "cli-test-bp-1", "f1da83187730c5e65d5931e2811481c5fe3407e5")
 	require.NotNil(t, out)
 	defer out.Close()
 	require.Nil(t, err)
@@ -78,24 +145,9 @@ func TestCmdBlueprintsUndoJSON(t *testing.T) {
 
 func TestCmdBlueprintsUndoUnknownBlueprint(t *testing.T) {
 	// Test the "blueprints undo" command with an unknown blueprint
-	mc := root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
-		json := `{
-    "status": false,
-    "errors": [
-        {
-            "id": "UnknownCommit",
-            "msg": "Unknown blueprint"
-        }
-    ]
-}`
-		return &http.Response{
-			Request:    request,
-			StatusCode: 400,
-			Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
-		}, nil
-	})
+	mc := root.SetupCmdTest(undoTestServer)
 
-	cmd, out, err := root.ExecuteTest("blueprints", "undo", "foo-bp-1", "f1da83187730c5e65d5931e2811481c5fe3407e5")
+	cmd, out, err := root.ExecuteTest("blueprints", "undo", "--yes", "foo-bp-1", "f1da83187730c5e65d5931e2811481c5fe3407e5")
 	require.NotNil(t, out)
 	defer out.Close()
 	require.NotNil(t, err)
@@ -109,8 +161,24 @@ func TestCmdBlueprintsUndoUnknownBlueprint(t *testing.T) {
 	stderr, err := ioutil.ReadAll(out.Stderr)
 	assert.Nil(t, err)
 	assert.Contains(t, string(stderr), "Unknown blueprint")
-	assert.Equal(t, "POST", mc.Req.Method)
-	assert.Equal(t, "/api/v1/blueprints/undo/foo-bp-1/f1da83187730c5e65d5931e2811481c5fe3407e5", mc.Req.URL.Path)
+	assert.Equal(t, "GET", mc.Req.Method)
+	assert.Equal(t, "/api/v1/blueprints/changes/foo-bp-1", mc.Req.URL.Path)
+}
+
+func TestCmdBlueprintsUndoUnknownCommit(t *testing.T) {
+	// The preview fails for an unknown commit, nothing is undone
+	mc := root.SetupCmdTest(undoTestServer)
+
+	cmd, out, err := root.ExecuteTest("blueprints", "undo", "--yes", "cli-test-bp-1", "0123456789")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	require.NotNil(t, cmd)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Equal(t, "ERROR: UnknownCommit: Unknown blueprint\n", string(stderr))
+	assert.Equal(t, "GET", mc.Req.Method)
+	assert.Equal(t, "/api/v1/blueprints/diff/cli-test-bp-1/NEWEST/0123456789", mc.Req.URL.Path)
 }
 
 func TestCmdBlueprintsUndoUnknownBlueprintJSON(t *testing.T) {
@@ -132,7 +200,7 @@ func TestCmdBlueprintsUndoUnknownBlueprintJSON(t *testing.T) {
 		}, nil
 	})
 
-	cmd, out, err := root.ExecuteTest("--json", "blueprints", "undo", "foo-bp-1", "f1da83187730c5e65d5931e2811481c5fe3407e5")
+	cmd, out, err := root.ExecuteTest("--json", "blueprints", "undo", "--yes", "foo-bp-1", "f1da83187730c5e65d5931e2811481c5fe3407e5")
 	require.NotNil(t, out)
 	defer out.Close()
 	require.NotNil(t, err)
@@ -152,6 +220,3 @@ func TestCmdBlueprintsUndoUnknownBlueprintJSON(t *testing.T) {
 	assert.Equal(t, "POST", mc.Req.Method)
 	assert.Equal(t, "/api/v1/blueprints/undo/foo-bp-1/f1da83187730c5e65d5931e2811481c5fe3407e5", mc.Req.URL.Path)
 }
-
-// NOTE: Unknown commit and unknown blueprint differ only in the message string
-//       No need to test unknown commit with the mock setup
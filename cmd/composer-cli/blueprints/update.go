@@ -0,0 +1,78 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+// getBlueprint retrieves a blueprint from the server and parses it
+// Errors are printed and the returned error is suitable for returning from a command.
+func getBlueprint(cmd *cobra.Command, name string) (weldr.Blueprint, error) {
+	bps, resp, err := root.Client.GetBlueprintsTOML([]string{name})
+	if err != nil {
+		return weldr.Blueprint{}, root.ExecutionError(cmd, "Blueprint Error: %s", err)
+	}
+	if resp != nil && !resp.Status {
+		return weldr.Blueprint{}, root.ExecutionErrors(cmd, resp.Errors)
+	}
+	if len(bps) == 0 {
+		return weldr.Blueprint{}, root.ExecutionError(cmd, "Blueprint Error: %s was not found", name)
+	}
+	bp, err := weldr.NewBlueprintFromTOML(bps[0])
+	if err != nil {
+		return weldr.Blueprint{}, root.ExecutionError(cmd, "Blueprint Error: %s: %s", name, err)
+	}
+	return bp, nil
+}
+
+// getBlueprintToCommit retrieves a blueprint that is going to be changed and committed
+// Committing it would also commit any uncommitted workspace changes, so they have to be
+// committed or discarded first.
+func getBlueprintToCommit(cmd *cobra.Command, name string) (weldr.Blueprint, error) {
+	changed, errors, err := workspaceChanges([]string{name})
+	if err != nil {
+		return weldr.Blueprint{}, root.ExecutionError(cmd, "Blueprint Error: %s", err)
+	}
+	if len(errors) > 0 {
+		return weldr.Blueprint{}, root.ExecutionErrors(cmd, errors)
+	}
+	if changed[name] {
+		return weldr.Blueprint{}, root.ExecutionError(cmd, "Blueprint Error: %s has uncommitted workspace changes, commit or discard them with blueprints workspace first", name)
+	}
+	return getBlueprint(cmd, name)
+}
+
+// commitBlueprint bumps the patch version of the blueprint and pushes it as a new commit
+// The server does not store a commit message, the message describing the change is
+// only printed after it has been committed.
+func commitBlueprint(cmd *cobra.Command, bp weldr.Blueprint, message string) error {
+	version, err := weldr.BumpVersion(bp.Version, "patch")
+	if err != nil {
+		return root.ExecutionError(cmd, "Blueprint Error: %s", err)
+	}
+	bp.Version = version
+
+	data, err := bp.TOML()
+	if err != nil {
+		return root.ExecutionError(cmd, "Blueprint Error: encoding TOML: %s", err)
+	}
+	resp, err := root.Client.PushBlueprintTOML(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: Push TOML: %s\n", err)
+		return root.ExecutionError(cmd, "")
+	}
+	if resp != nil && !resp.Status {
+		return root.ExecutionErrors(cmd, resp.Errors)
+	}
+	fmt.Printf("%s v%s: %s\n", bp.Name, bp.Version, message)
+	return nil
+}
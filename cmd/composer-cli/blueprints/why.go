@@ -0,0 +1,230 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	whyCmd = &cobra.Command{
+		Use:   "why BLUEPRINT PACKAGE | why --inverse BLUEPRINT",
+		Short: "Explain which of the blueprint's packages pull in a dependency",
+		Long: `Explain which of the blueprint's packages and modules pull in a dependency
+
+Each package and module in the blueprint is depsolved on its own and the ones
+with PACKAGE in their dependencies are listed. With --inverse the number of
+packages each one pulls in is listed, along with the packages that none of the
+others pull in. Groups are not depsolved.`,
+		RunE: why,
+		Args: cobra.RangeArgs(1, 2),
+	}
+	whyInverse bool
+)
+
+// whyWorkers is the number of packages that are depsolved at the same time
+const whyWorkers = 4
+
+func init() {
+	whyCmd.Flags().BoolVarP(&whyInverse, "inverse", "", false, "List the packages pulled in by each of the blueprint's packages")
+	blueprintsCmd.AddCommand(whyCmd)
+}
+
+// closureCache holds the dependencies of the packages that have been depsolved
+// It is safe to use from more than one goroutine.
+type closureCache struct {
+	sync.Mutex
+	deps map[string][]string
+	errs map[string]error
+}
+
+var closures = closureCache{deps: make(map[string][]string), errs: make(map[string]error)}
+
+// get returns the cached dependencies of the package and true if it has been depsolved
+func (c *closureCache) get(key string) ([]string, bool, error) {
+	c.Lock()
+	defer c.Unlock()
+	if deps, ok := c.deps[key]; ok {
+		return deps, true, nil
+	}
+	if err, ok := c.errs[key]; ok {
+		return nil, true, err
+	}
+	return nil, false, nil
+}
+
+func (c *closureCache) set(key string, deps []string, err error) {
+	c.Lock()
+	defer c.Unlock()
+	if err != nil {
+		c.errs[key] = err
+	} else {
+		c.deps[key] = deps
+	}
+}
+
+// packageClosure returns the sorted names of the package and its dependencies
+func packageClosure(name, distro string) ([]string, error) {
+	key := distro + "/" + name
+	if deps, ok, err := closures.get(key); ok {
+		return deps, err
+	}
+
+	projects, errors, err := root.Client.DepsolveProjects([]string{name}, distro)
+	if err == nil && len(errors) > 0 {
+		err = fmt.Errorf("%s: %s", errors[0].ID, errors[0].Msg)
+	}
+	var deps []string
+	if err == nil {
+		seen := make(map[string]bool)
+		for _, p := range projects {
+			m, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if n, ok := m["name"].(string); ok && !seen[n] {
+				seen[n] = true
+				deps = append(deps, n)
+			}
+		}
+		sort.Strings(deps)
+	}
+	closures.set(key, deps, err)
+	return deps, err
+}
+
+// packageClosures depsolves the packages concurrently
+// It returns the dependencies of the packages that could be depsolved and the errors
+// for the ones that could not.
+func packageClosures(names []string, distro string) (map[string][]string, map[string]error) {
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	deps := make(map[string][]string)
+	errs := make(map[string]error)
+	workers := make(chan struct{}, whyWorkers)
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			workers <- struct{}{}
+			d, err := packageClosure(name, distro)
+			<-workers
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errs[name] = err
+			} else {
+				deps[name] = d
+			}
+		}(name)
+	}
+	wg.Wait()
+	return deps, errs
+}
+
+// topLevelNames returns the names of the blueprint's packages and modules, without duplicates
+func topLevelNames(bp weldr.Blueprint) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, p := range append(append([]weldr.Package{}, bp.Packages...), bp.Modules...) {
+		if !seen[p.Name] {
+			seen[p.Name] = true
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// containsName returns true if the sorted names include the name
+func containsName(names []string, name string) bool {
+	i := sort.SearchStrings(names, name)
+	return i < len(names) && names[i] == name
+}
+
+func why(cmd *cobra.Command, args []string) (rcErr error) {
+	if whyInverse && len(args) != 1 {
+		return root.ExecutionError(cmd, "Why Error: --inverse only takes the blueprint name")
+	}
+	if !whyInverse && len(args) != 2 {
+		return root.ExecutionError(cmd, "Why Error: missing the package name")
+	}
+	bp, err := loadBlueprint(args[0])
+	if err != nil {
+		return root.ExecutionError(cmd, "Why Error: %s: %s", args[0], err)
+	}
+	names := topLevelNames(bp)
+	if len(names) == 0 {
+		return root.ExecutionError(cmd, "Why Error: %s has no packages or modules", args[0])
+	}
+
+	deps, errs := packageClosures(names, bp.Distro)
+	for _, name := range names {
+		if err, ok := errs[name]; ok {
+			rcErr = root.ExecutionError(cmd, "Why Error: %s: %s", name, err)
+		}
+	}
+
+	if whyInverse {
+		printContributions(names, deps)
+		return rcErr
+	}
+
+	pkgName := args[1]
+	var found []string
+	for _, name := range names {
+		if d, ok := deps[name]; ok && containsName(d, pkgName) {
+			if name == pkgName {
+				found = append(found, name+" (requested)")
+			} else {
+				found = append(found, name)
+			}
+		}
+	}
+	if len(found) == 0 {
+		return root.ExecutionError(cmd, "Why Error: %s is not pulled in by any of the packages in %s", pkgName, args[0])
+	}
+	fmt.Printf("%s is pulled in by:\n", pkgName)
+	for _, name := range found {
+		fmt.Printf("    %s\n", name)
+	}
+
+	return rcErr
+}
+
+// printContributions prints the number of packages each package pulls in
+// The packages that are not pulled in by any of the others are listed below it.
+func printContributions(names []string, deps map[string][]string) {
+	count := make(map[string]int)
+	for _, name := range names {
+		for _, d := range deps[name] {
+			count[d]++
+		}
+	}
+	for _, name := range names {
+		d, ok := deps[name]
+		if !ok {
+			continue
+		}
+		var only []string
+		for _, n := range d {
+			if count[n] == 1 {
+				only = append(only, n)
+			}
+		}
+		fmt.Printf("%s: %d packages, %d only pulled in by %s\n", name, len(d), len(only), name)
+		if len(only) > 0 {
+			fmt.Printf("    %s\n", strings.Join(only, " "))
+		}
+	}
+}
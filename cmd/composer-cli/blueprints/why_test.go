@@ -0,0 +1,125 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+)
+
+const whyTestBlueprint = `{"blueprints": [{
+	"name": "why-bp",
+	"distro": "fedora-why",
+	"packages": [{"name": "tmux"}, {"name": "vim-enhanced"}, {"name": "broken"}],
+	"modules": [{"name": "glibc"}]
+}], "changes": [], "errors": []}`
+
+var whyTestClosures = map[string][]string{
+	"tmux":         {"tmux", "libevent", "glibc", "ncurses-libs"},
+	"vim-enhanced": {"vim-enhanced", "vim-common", "gpm-libs", "glibc", "ncurses-libs"},
+	"glibc":        {"glibc"},
+}
+
+func whyTestServer(request *http.Request) (*http.Response, error) {
+	path := strings.TrimPrefix(request.URL.Path, "/api/v1")
+	var body []byte
+	switch {
+	case path == "/blueprints/info/why-bp":
+		body = []byte(whyTestBlueprint)
+	case strings.HasPrefix(path, "/projects/depsolve/"):
+		name := strings.TrimPrefix(path, "/projects/depsolve/")
+		deps, ok := whyTestClosures[name]
+		if !ok {
+			return &http.Response{
+				Request:    request,
+				StatusCode: 400,
+				Body: ioutil.NopCloser(bytes.NewReader([]byte(`{"status": false, "errors": [{"id": "ProjectsError",
+					"msg": "BadRequest: DNF error occured: MarkingErrors: missing packages: ` + name + `"}]}`))),
+			}, nil
+		}
+		var projects []map[string]interface{}
+		for _, d := range deps {
+			projects = append(projects, map[string]interface{}{"name": d, "epoch": 0, "version": "1", "release": "1", "arch": "x86_64"})
+		}
+		body, _ = json.Marshal(map[string]interface{}{"projects": projects})
+	default:
+		return &http.Response{
+			Request:    request,
+			StatusCode: 400,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"status": false, "errors": [{"id": "UnknownBlueprint", "msg": "unknown-bp: "}]}`))),
+		}, nil
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+	}, nil
+}
+
+func runWhy(t *testing.T, args ...string) (string, string, error) {
+	return executeCmd(t, whyTestServer, append([]string{"blueprints", "why"}, args...)...)
+}
+
+func TestCmdBlueprintsWhy(t *testing.T) {
+	stdout, stderr, err := runWhy(t, "--inverse=false", "why-bp", "ncurses-libs")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Why Error: broken: ProjectsError: BadRequest: DNF error occured: MarkingErrors: missing packages: broken\n", stderr)
+	assert.Equal(t, `ncurses-libs is pulled in by:
+    tmux
+    vim-enhanced
+`, stdout)
+
+	stdout, _, _ = runWhy(t, "--inverse=false", "why-bp", "glibc")
+	assert.Equal(t, `glibc is pulled in by:
+    tmux
+    vim-enhanced
+    glibc (requested)
+`, stdout)
+
+	stdout, stderr, err = runWhy(t, "--inverse=false", "why-bp", "httpd")
+	require.NotNil(t, err)
+	assert.Equal(t, "", stdout)
+	assert.Contains(t, stderr, "ERROR: Why Error: httpd is not pulled in by any of the packages in why-bp\n")
+}
+
+func TestCmdBlueprintsWhyInverse(t *testing.T) {
+	stdout, _, err := runWhy(t, "--inverse", "why-bp")
+	require.NotNil(t, err)
+	assert.Equal(t, `tmux: 4 packages, 2 only pulled in by tmux
+    libevent tmux
+vim-enhanced: 5 packages, 3 only pulled in by vim-enhanced
+    gpm-libs vim-common vim-enhanced
+glibc: 1 packages, 0 only pulled in by glibc
+`, stdout)
+
+	_, stderr, err := runWhy(t, "--inverse", "why-bp", "glibc")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Why Error: --inverse only takes the blueprint name\n", stderr)
+}
+
+func TestPackageClosuresCache(t *testing.T) {
+	var requests int
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"projects": [{"name": "bash"}, {"name": "glibc"}]}`))),
+		}, nil
+	})
+	deps, errs := packageClosures([]string{"bash"}, "fedora-cache")
+	assert.Equal(t, 0, len(errs))
+	assert.Equal(t, []string{"bash", "glibc"}, deps["bash"])
+	deps, _ = packageClosures([]string{"bash"}, "fedora-cache")
+	assert.Equal(t, []string{"bash", "glibc"}, deps["bash"])
+	assert.Equal(t, 1, requests)
+}
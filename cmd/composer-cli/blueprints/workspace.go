@@ -16,11 +16,14 @@ import (
 
 var (
 	workspaceCmd = &cobra.Command{
-		Use:   "workspace BLUEPRINT",
-		Short: "Push the TOML blueprint to the workspace",
-		Long:  "Push the TOML blueprint to the temporary workspace storage",
-		RunE:  workspace,
-		Args:  cobra.MinimumNArgs(1),
+		Use:   "workspace BLUEPRINT | list | show | diff | commit | discard",
+		Short: "Push the TOML blueprint to the workspace, or manage the workspace changes",
+		Long: `Push the TOML blueprint to the temporary workspace storage
+
+The list, show, diff, commit, and discard commands inspect and manage the
+uncommitted workspace changes.`,
+		RunE: workspace,
+		Args: cobra.MinimumNArgs(1),
 	}
 )
 
@@ -0,0 +1,258 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	workspaceListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the blueprints with workspace changes",
+		Long:  "List the blueprints that have uncommitted changes in the workspace",
+		RunE:  workspaceList,
+		Args:  cobra.NoArgs,
+	}
+	workspaceShowCmd = &cobra.Command{
+		Use:   "show BLUEPRINT,...",
+		Short: "Show the workspace copy of the blueprints",
+		Long:  "Show the uncommitted workspace copy of the blueprints as TOML",
+		RunE:  workspaceShow,
+		Args:  cobra.MinimumNArgs(1),
+	}
+	workspaceDiffCmd = &cobra.Command{
+		Use:   "diff BLUEPRINT,...",
+		Short: "List the workspace changes",
+		Long:  "List the differences between the most recent commit of the blueprints and their workspace copy",
+		RunE:  workspaceDiff,
+		Args:  cobra.MinimumNArgs(1),
+	}
+	workspaceCommitCmd = &cobra.Command{
+		Use:   "commit BLUEPRINT,...",
+		Short: "Commit the workspace changes",
+		Long:  "Commit the workspace copy of the blueprints as their newest version",
+		RunE:  workspaceCommit,
+		Args:  cobra.MinimumNArgs(1),
+	}
+	workspaceDiscardCmd = &cobra.Command{
+		Use:   "discard BLUEPRINT,...",
+		Short: "Discard the workspace changes",
+		Long:  "Discard the uncommitted workspace changes, leaving the most recent commit of the blueprints",
+		RunE:  workspaceDiscard,
+		Args:  cobra.MinimumNArgs(1),
+	}
+)
+
+func init() {
+	workspaceCmd.AddCommand(workspaceListCmd)
+	workspaceCmd.AddCommand(workspaceShowCmd)
+	workspaceCmd.AddCommand(workspaceDiffCmd)
+	workspaceCmd.AddCommand(workspaceCommitCmd)
+	workspaceCmd.AddCommand(workspaceDiscardCmd)
+}
+
+// workspaceChanges returns a map of the blueprint names to whether they have workspace changes
+func workspaceChanges(names []string) (map[string]bool, []weldr.APIErrorMsg, error) {
+	_, changes, errors, err := root.Client.GetBlueprintsInfoJSON(names)
+	if err != nil {
+		return nil, nil, err
+	}
+	changed := make(map[string]bool, len(changes))
+	for _, c := range changes {
+		changed[c.Name] = c.Changed
+	}
+	return changed, errors, nil
+}
+
+// changedBlueprints returns the blueprints that have workspace changes
+// The blueprints without changes are reported as errors.
+func changedBlueprints(cmd *cobra.Command, names []string) ([]string, error) {
+	changed, errors, err := workspaceChanges(names)
+	if err != nil {
+		return nil, root.ExecutionError(cmd, "Workspace Error: %s", err)
+	}
+	var rcErr error
+	if len(errors) > 0 {
+		rcErr = root.ExecutionErrors(cmd, errors)
+	}
+	var result []string
+	for _, name := range names {
+		c, ok := changed[name]
+		if !ok {
+			continue
+		}
+		if !c {
+			rcErr = root.ExecutionError(cmd, "Workspace Error: %s has no workspace changes", name)
+			continue
+		}
+		result = append(result, name)
+	}
+	return result, rcErr
+}
+
+func workspaceList(cmd *cobra.Command, args []string) error {
+	names, resp, err := root.Client.ListBlueprints()
+	if err != nil {
+		return root.ExecutionError(cmd, "List Error: %s", err)
+	}
+	if resp != nil && !resp.Status {
+		return root.ExecutionErrors(cmd, resp.Errors)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	changed, errors, err := workspaceChanges(names)
+	if err != nil {
+		return root.ExecutionError(cmd, "List Error: %s", err)
+	}
+	if len(errors) > 0 {
+		return root.ExecutionErrors(cmd, errors)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if changed[name] {
+			fmt.Println(name)
+		}
+	}
+	return nil
+}
+
+func workspaceShow(cmd *cobra.Command, args []string) error {
+	names, rcErr := changedBlueprints(cmd, root.GetCommaArgs(args))
+	if len(names) == 0 {
+		return rcErr
+	}
+	blueprints, resp, err := root.Client.GetBlueprintsTOML(names)
+	if err != nil {
+		return root.ExecutionError(cmd, "Show Error: %s", err)
+	}
+	if resp != nil && !resp.Status {
+		return root.ExecutionErrors(cmd, resp.Errors)
+	}
+	for _, bp := range blueprints {
+		fmt.Println(bp)
+	}
+	return rcErr
+}
+
+// describeDiffField returns the name of the blueprint field and a short description of its value
+func describeDiffField(field map[string]interface{}) (string, string) {
+	for name, value := range field {
+		switch v := value.(type) {
+		case string:
+			return name, v
+		case map[string]interface{}:
+			if n, ok := v["name"].(string); ok {
+				if version, ok := v["version"].(string); ok {
+					return name, n + " " + version
+				}
+				return name, n
+			}
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return name, fmt.Sprintf("%v", value)
+		}
+		return name, string(data)
+	}
+	return "", ""
+}
+
+// describeDiff returns a line describing a blueprint change
+func describeDiff(d weldr.BlueprintDiffV0) string {
+	switch {
+	case d.Old == nil:
+		name, value := describeDiffField(d.New)
+		return fmt.Sprintf("Added %s %s", name, value)
+	case d.New == nil:
+		name, value := describeDiffField(d.Old)
+		return fmt.Sprintf("Removed %s %s", name, value)
+	}
+	name, oldValue := describeDiffField(d.Old)
+	_, newValue := describeDiffField(d.New)
+	return fmt.Sprintf("Changed %s %s -> %s", name, oldValue, newValue)
+}
+
+func workspaceDiff(cmd *cobra.Command, args []string) (rcErr error) {
+	names := root.GetCommaArgs(args)
+	for _, name := range names {
+		diff, errors, err := root.Client.GetBlueprintDiff(name, "NEWEST", "WORKSPACE")
+		if err != nil {
+			rcErr = root.ExecutionError(cmd, "Diff Error: %s", err)
+			continue
+		}
+		if len(errors) > 0 {
+			rcErr = root.ExecutionErrors(cmd, errors)
+			continue
+		}
+		if len(names) > 1 {
+			fmt.Printf("%s:\n", name)
+		}
+		for _, d := range diff {
+			fmt.Println(describeDiff(d))
+		}
+	}
+
+	// If there were any errors, even if other blueprints succeeded, it returns an error
+	return rcErr
+}
+
+func workspaceCommit(cmd *cobra.Command, args []string) error {
+	names, rcErr := changedBlueprints(cmd, root.GetCommaArgs(args))
+	for _, name := range names {
+		// The server returns the workspace copy, pushing it commits it and clears the workspace
+		blueprints, resp, err := root.Client.GetBlueprintsTOML([]string{name})
+		if err != nil {
+			rcErr = root.ExecutionError(cmd, "Commit Error: %s", err)
+			continue
+		}
+		if resp != nil && !resp.Status {
+			rcErr = root.ExecutionErrors(cmd, resp.Errors)
+			continue
+		}
+		if len(blueprints) != 1 {
+			rcErr = root.ExecutionError(cmd, "Commit Error: missing workspace copy of %s", name)
+			continue
+		}
+		resp, err = root.Client.PushBlueprintTOML(blueprints[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Push TOML: %s\n", err)
+			rcErr = root.ExecutionError(cmd, "")
+			continue
+		}
+		if resp != nil && !resp.Status {
+			rcErr = root.ExecutionErrors(cmd, resp.Errors)
+		}
+	}
+
+	// If there were any errors, even if other blueprints succeeded, it returns an error
+	return rcErr
+}
+
+func workspaceDiscard(cmd *cobra.Command, args []string) (rcErr error) {
+	for _, name := range root.GetCommaArgs(args) {
+		resp, err := root.Client.DeleteBlueprintWorkspace(name)
+		if err != nil {
+			rcErr = root.ExecutionError(cmd, "Discard Error: %s", err)
+			continue
+		}
+		if resp != nil && !resp.Status {
+			rcErr = root.ExecutionErrors(cmd, resp.Errors)
+		}
+	}
+
+	// If there were any errors, even if other blueprints succeeded, it returns an error
+	return rcErr
+}
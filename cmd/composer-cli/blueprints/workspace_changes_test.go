@@ -0,0 +1,125 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package blueprints
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+const workspaceTestTOML = `name = "bp-a"
+description = "workspace copy"
+version = "0.0.2"
+`
+
+// workspaceTestServer has two blueprints, only bp-a has workspace changes
+// It records the requests that change the server.
+func workspaceTestServer(requests *[]string) func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		path := strings.TrimPrefix(request.URL.Path, "/api/v1")
+		var body string
+		switch {
+		case strings.HasPrefix(path, "/blueprints/list"):
+			body = `{"blueprints": ["bp-b", "bp-a"], "total": 2, "offset": 0, "limit": 2}`
+		case strings.HasPrefix(path, "/blueprints/info/") && request.URL.Query().Get("format") == "toml":
+			body = workspaceTestTOML
+		case strings.HasPrefix(path, "/blueprints/info/"):
+			body = `{"blueprints": [], "changes": [{"name": "bp-a", "changed": true}, {"name": "bp-b", "changed": false}], "errors": []}`
+		case path == "/blueprints/diff/bp-a/NEWEST/WORKSPACE":
+			body = `{"diff": [
+				{"old": {"Version": "0.0.1"}, "new": {"Version": "0.0.2"}},
+				{"old": null, "new": {"Package": {"name": "tmux", "version": "*"}}},
+				{"old": {"Module": {"name": "nodejs", "version": "*"}}, "new": null},
+				{"old": {"Package": {"name": "bash", "version": "4.*"}}, "new": {"Package": {"name": "bash", "version": "5.*"}}}
+			]}`
+		case strings.HasPrefix(path, "/blueprints/diff/"):
+			return &http.Response{
+				Request:    request,
+				StatusCode: 400,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"status": false, "errors": [{"id": "UnknownBlueprint", "msg": "unknown-bp: "}]}`))),
+			}, nil
+		default:
+			data, _ := ioutil.ReadAll(request.Body)
+			*requests = append(*requests, request.Method+" "+path+" "+string(data))
+			body = `{"status": true}`
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+func runWorkspace(t *testing.T, args ...string) (string, string, []string, error) {
+	var requests []string
+	stdout, stderr, err := executeCmd(t, workspaceTestServer(&requests), append([]string{"blueprints", "workspace"}, args...)...)
+	return stdout, stderr, requests, err
+}
+
+func TestCmdBlueprintsWorkspaceList(t *testing.T) {
+	stdout, stderr, _, err := runWorkspace(t, "list")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, "bp-a\n", stdout)
+}
+
+func TestCmdBlueprintsWorkspaceShow(t *testing.T) {
+	stdout, stderr, _, err := runWorkspace(t, "show", "bp-a")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, workspaceTestTOML+"\n", stdout)
+
+	stdout, stderr, _, err = runWorkspace(t, "show", "bp-b")
+	require.NotNil(t, err)
+	assert.Equal(t, "", stdout)
+	assert.Equal(t, "ERROR: Workspace Error: bp-b has no workspace changes\n", stderr)
+}
+
+func TestCmdBlueprintsWorkspaceDiff(t *testing.T) {
+	stdout, stderr, _, err := runWorkspace(t, "diff", "bp-a")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, `Changed Version 0.0.1 -> 0.0.2
+Added Package tmux *
+Removed Module nodejs *
+Changed Package bash 4.* -> bash 5.*
+`, stdout)
+
+	_, stderr, _, err = runWorkspace(t, "diff", "unknown-bp")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: UnknownBlueprint: unknown-bp: \n", stderr)
+}
+
+func TestCmdBlueprintsWorkspaceCommit(t *testing.T) {
+	stdout, stderr, requests, err := runWorkspace(t, "commit", "bp-a,bp-b")
+	require.NotNil(t, err)
+	assert.Equal(t, "", stdout)
+	assert.Equal(t, "ERROR: Workspace Error: bp-b has no workspace changes\n", stderr)
+	assert.Equal(t, []string{"POST /blueprints/new " + workspaceTestTOML}, requests)
+}
+
+func TestCmdBlueprintsWorkspaceDiscard(t *testing.T) {
+	stdout, stderr, requests, err := runWorkspace(t, "discard", "bp-a")
+	require.Nil(t, err)
+	assert.Equal(t, "", stdout)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, []string{"DELETE /blueprints/workspace/bp-a "}, requests)
+}
+
+func TestDescribeDiff(t *testing.T) {
+	d := weldr.BlueprintDiffV0{
+		Old: nil,
+		New: map[string]interface{}{"Customizations": map[string]interface{}{"hostname": "server"}},
+	}
+	assert.Equal(t, `Added Customizations {"hostname":"server"}`, describeDiff(d))
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+)
+
+// routeServer returns a mock server that responds with the body of the request's path
+// A path ending in / matches every path that starts with it. Requests for other paths
+// get a 400 response with the error body.
+func routeServer(routes map[string]string, errorBody string) func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		body, ok := routes[request.URL.Path]
+		if !ok {
+			for path, b := range routes {
+				if strings.HasSuffix(path, "/") && strings.HasPrefix(request.URL.Path, path) {
+					body, ok = b, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return &http.Response{
+				Request:    request,
+				StatusCode: 400,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(errorBody))),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+// executeCmd runs the command with the mock server and returns its stdout and stderr
+func executeCmd(t *testing.T, server func(request *http.Request) (*http.Response, error), args ...string) (string, string, error) {
+	root.SetupCmdTest(server)
+	cmd, out, err := root.ExecuteTest(args...)
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, cmd)
+	stdout, rerr := ioutil.ReadAll(out.Stdout)
+	require.Nil(t, rerr)
+	stderr, rerr := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, rerr)
+	return string(stdout), string(stderr), err
+}
+
+// inTempDir runs the function with a temporary directory as the current directory
+func inTempDir(t *testing.T, f func()) {
+	dir, err := ioutil.TempDir("", "test-compose-*")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	prevDir, _ := os.Getwd()
+	err = os.Chdir(dir)
+	require.Nil(t, err)
+	//nolint:errcheck
+	defer os.Chdir(prevDir)
+	f()
+}
@@ -0,0 +1,234 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	diffCmd = &cobra.Command{
+		Use:   "diff UUID1 UUID2 [--format table|json]",
+		Short: "Compare the packages of two composes",
+		Long: `Compare the packages of two composes
+
+The packages that were added, removed, upgraded, or downgraded between the first
+and the second compose are listed, along with any change to the blueprint
+version or the compose type. Versions are compared the same way as rpm. Packages
+with more than one architecture are compared per architecture, and packages with
+more than one version installed, like the kernel, report each version that was
+added or removed, or an upgrade when one version replaced another. Use --format json
+to output the differences as JSON, --json outputs the server's responses.`,
+		RunE: diff,
+		Args: cobra.ExactArgs(2),
+	}
+	diffFormat string
+)
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffFormat, "format", "", "table", "Format of the output, table or json")
+	composeCmd.AddCommand(diffCmd)
+}
+
+// composeSide describes one of the composes being compared
+type composeSide struct {
+	ID               string `json:"id"`
+	Blueprint        string `json:"blueprint"`
+	BlueprintVersion string `json:"blueprint_version"`
+	ComposeType      string `json:"compose_type"`
+}
+
+// packageChange is a package that is different in the two composes
+type packageChange struct {
+	Change string `json:"change"`
+	Name   string `json:"name"`
+	Arch   string `json:"arch"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+}
+
+// composeDiff is the difference between two composes
+type composeDiff struct {
+	Old      composeSide     `json:"old"`
+	New      composeSide     `json:"new"`
+	Packages []packageChange `json:"packages"`
+}
+
+// withoutVersions returns the packages that do not have the same EVR as one of the others
+func withoutVersions(pkgs, others []weldr.PackageNEVRA) []weldr.PackageNEVRA {
+	var result []weldr.PackageNEVRA
+	for _, p := range pkgs {
+		found := false
+		for _, o := range others {
+			if weldr.CompareEVR(p, o) == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// diffVersions returns the changes between the versions of one name and arch
+// Packages like the kernel can have more than one version installed. The versions in
+// both composes are unchanged, when one version was replaced by another it is an
+// upgrade or a downgrade, otherwise each version is reported as added or removed.
+func diffVersions(before, after []weldr.PackageNEVRA) []packageChange {
+	removed := withoutVersions(before, after)
+	added := withoutVersions(after, before)
+
+	var changes []packageChange
+	if len(removed) == 1 && len(added) == 1 {
+		o, n := removed[0], added[0]
+		change := "upgraded"
+		if weldr.CompareEVR(o, n) > 0 {
+			change = "downgraded"
+		}
+		return append(changes, packageChange{Change: change, Name: o.Name, Arch: o.Arch, Old: o.EVR(), New: n.EVR()})
+	}
+	for _, o := range removed {
+		changes = append(changes, packageChange{Change: "removed", Name: o.Name, Arch: o.Arch, Old: o.EVR()})
+	}
+	for _, n := range added {
+		changes = append(changes, packageChange{Change: "added", Name: n.Name, Arch: n.Arch, New: n.EVR()})
+	}
+	return changes
+}
+
+// nameArch identifies the versions of a package that are compared with each other
+type nameArch struct {
+	name string
+	arch string
+}
+
+// diffPackages returns the packages that are different, sorted by name and arch
+// The versions of each name and arch are sorted from the oldest to the newest.
+func diffPackages(before, after []weldr.PackageNEVRA) []packageChange {
+	byNameArch := func(pkgs []weldr.PackageNEVRA) map[nameArch][]weldr.PackageNEVRA {
+		sorted := append([]weldr.PackageNEVRA{}, pkgs...)
+		weldr.SortNEVRAs(sorted)
+		m := make(map[nameArch][]weldr.PackageNEVRA)
+		for _, p := range sorted {
+			k := nameArch{p.Name, p.Arch}
+			m[k] = append(m[k], p)
+		}
+		return m
+	}
+	oldPkgs := byNameArch(before)
+	newPkgs := byNameArch(after)
+
+	var keys []nameArch
+	for k := range oldPkgs {
+		keys = append(keys, k)
+	}
+	for k := range newPkgs {
+		if _, ok := oldPkgs[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].arch < keys[j].arch
+	})
+
+	changes := []packageChange{}
+	for _, k := range keys {
+		changes = append(changes, diffVersions(oldPkgs[k], newPkgs[k])...)
+	}
+	return changes
+}
+
+// sideChange returns the value, or the old and the new value if they are different
+func sideChange(before, after string) string {
+	if before == after {
+		return before
+	}
+	return fmt.Sprintf("%s -> %s", before, after)
+}
+
+// printDiffTable prints the differences as a table
+func printDiffTable(d composeDiff) {
+	fmt.Printf("Blueprint: %s\n", sideChange(
+		fmt.Sprintf("%s v%s", d.Old.Blueprint, d.Old.BlueprintVersion),
+		fmt.Sprintf("%s v%s", d.New.Blueprint, d.New.BlueprintVersion)))
+	fmt.Printf("Type: %s\n", sideChange(d.Old.ComposeType, d.New.ComposeType))
+	if len(d.Packages) == 0 {
+		fmt.Println("No package changes")
+		return
+	}
+
+	nameWidth, oldWidth := len("Package"), len("Old")
+	for _, p := range d.Packages {
+		if n := len(p.Name) + len(p.Arch) + 1; n > nameWidth {
+			nameWidth = n
+		}
+		if len(p.Old) > oldWidth {
+			oldWidth = len(p.Old)
+		}
+	}
+	fmt.Printf("%-10s %-*s %-*s %s\n", "Change", nameWidth, "Package", oldWidth, "Old", "New")
+	for _, p := range d.Packages {
+		line := fmt.Sprintf("%-10s %-*s %-*s %s", p.Change, nameWidth, p.Name+"."+p.Arch, oldWidth, p.Old, p.New)
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+}
+
+func diff(cmd *cobra.Command, args []string) error {
+	if diffFormat != "table" && diffFormat != "json" {
+		return root.ExecutionError(cmd, "Diff Error: unknown format %s, it should be table or json", diffFormat)
+	}
+	var infos []weldr.ComposeInfoV0
+	for _, id := range args {
+		info, resp, err := root.Client.ComposeInfo(id)
+		if err != nil {
+			return root.ExecutionError(cmd, "Diff Error: %s: %s", id, err)
+		}
+		if resp != nil {
+			return root.ExecutionErrors(cmd, resp.Errors)
+		}
+		infos = append(infos, info)
+	}
+	oldInfo, newInfo := infos[0], infos[1]
+
+	d := composeDiff{
+		Old: composeSide{
+			ID:               oldInfo.ID,
+			Blueprint:        oldInfo.Blueprint.Name,
+			BlueprintVersion: oldInfo.Blueprint.Version,
+			ComposeType:      oldInfo.ComposeType,
+		},
+		New: composeSide{
+			ID:               newInfo.ID,
+			Blueprint:        newInfo.Blueprint.Name,
+			BlueprintVersion: newInfo.Blueprint.Version,
+			ComposeType:      newInfo.ComposeType,
+		},
+		Packages: diffPackages(oldInfo.Deps.Packages, newInfo.Deps.Packages),
+	}
+
+	if diffFormat == "json" {
+		data, err := json.MarshalIndent(d, "", "    ")
+		if err != nil {
+			return root.ExecutionError(cmd, "Diff Error: %s", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	printDiffTable(d)
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var diffTestInfo = map[string]string{
+	"old-uuid": `{"id": "old-uuid", "compose_type": "qcow2", "queue_status": "FINISHED",
+		"blueprint": {"name": "http-server", "version": "0.0.1"},
+		"deps": {"packages": [
+			{"name": "bash", "epoch": 0, "version": "5.1.8", "release": "1.fc34", "arch": "x86_64"},
+			{"name": "glibc", "epoch": 0, "version": "2.33", "release": "5.fc34", "arch": "x86_64"},
+			{"name": "glibc", "epoch": 0, "version": "2.33", "release": "5.fc34", "arch": "i686"},
+			{"name": "nano", "epoch": 0, "version": "5.6.1", "release": "1.fc34", "arch": "x86_64"},
+			{"name": "tmux", "epoch": 0, "version": "3.10", "release": "1.fc34", "arch": "x86_64"}
+		]}}`,
+	"new-uuid": `{"id": "new-uuid", "compose_type": "ami", "queue_status": "FINISHED",
+		"blueprint": {"name": "http-server", "version": "0.0.2"},
+		"deps": {"packages": [
+			{"name": "bash", "epoch": 0, "version": "5.1.8", "release": "10.fc34", "arch": "x86_64"},
+			{"name": "glibc", "epoch": 0, "version": "2.33", "release": "5.fc34", "arch": "x86_64"},
+			{"name": "tmux", "epoch": 0, "version": "3.9", "release": "1.fc34", "arch": "x86_64"},
+			{"name": "vim-minimal", "epoch": 2, "version": "8.2.3318", "release": "1.fc34", "arch": "x86_64"}
+		]}}`,
+}
+
+func runDiff(t *testing.T, args ...string) (string, string, error) {
+	routes := make(map[string]string)
+	for id, body := range diffTestInfo {
+		routes["/api/v1/compose/info/"+id] = body
+	}
+	server := routeServer(routes, `{"status": false,
+		"errors": [{"id": "UnknownUUID", "msg": "missing-uuid is not a valid build uuid"}]}`)
+	return executeCmd(t, server, append([]string{"compose", "diff"}, args...)...)
+}
+
+func TestCmdComposeDiff(t *testing.T) {
+	stdout, stderr, err := runDiff(t, "--format", "table", "old-uuid", "new-uuid")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, `Blueprint: http-server v0.0.1 -> http-server v0.0.2
+Type: qcow2 -> ami
+Change     Package            Old          New
+upgraded   bash.x86_64        5.1.8-1.fc34 5.1.8-10.fc34
+removed    glibc.i686         2.33-5.fc34
+removed    nano.x86_64        5.6.1-1.fc34
+downgraded tmux.x86_64        3.10-1.fc34  3.9-1.fc34
+added      vim-minimal.x86_64              2:8.2.3318-1.fc34
+`, stdout)
+}
+
+func TestCmdComposeDiffJSON(t *testing.T) {
+	stdout, stderr, err := runDiff(t, "--format", "json", "old-uuid", "new-uuid")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+
+	var d composeDiff
+	require.Nil(t, json.Unmarshal([]byte(stdout), &d))
+	assert.Equal(t, composeSide{ID: "old-uuid", Blueprint: "http-server", BlueprintVersion: "0.0.1", ComposeType: "qcow2"}, d.Old)
+	assert.Equal(t, "ami", d.New.ComposeType)
+	require.Equal(t, 5, len(d.Packages))
+	assert.Equal(t, packageChange{Change: "upgraded", Name: "bash", Arch: "x86_64", Old: "5.1.8-1.fc34", New: "5.1.8-10.fc34"}, d.Packages[0])
+}
+
+func TestCmdComposeDiffSame(t *testing.T) {
+	stdout, _, err := runDiff(t, "--format", "table", "old-uuid", "old-uuid")
+	require.Nil(t, err)
+	assert.Equal(t, "Blueprint: http-server v0.0.1\nType: qcow2\nNo package changes\n", stdout)
+}
+
+func TestCmdComposeDiffErrors(t *testing.T) {
+	_, stderr, err := runDiff(t, "--format", "table", "old-uuid", "missing-uuid")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: UnknownUUID: missing-uuid is not a valid build uuid\n", stderr)
+
+	_, stderr, err = runDiff(t, "--format", "yaml", "old-uuid", "new-uuid")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: Diff Error: unknown format yaml, it should be table or json\n", stderr)
+}
+
+func TestDiffPackagesVersions(t *testing.T) {
+	kernel := func(release string) weldr.PackageNEVRA {
+		return weldr.PackageNEVRA{Name: "kernel", Version: "5.14.10", Release: release, Arch: "x86_64"}
+	}
+	// Two kernels are installed, the oldest one is replaced by a newer one
+	changes := diffPackages(
+		[]weldr.PackageNEVRA{kernel("300.fc34"), kernel("200.fc34")},
+		[]weldr.PackageNEVRA{kernel("300.fc34"), kernel("400.fc34")})
+	assert.Equal(t, []packageChange{
+		{Change: "upgraded", Name: "kernel", Arch: "x86_64", Old: "5.14.10-200.fc34", New: "5.14.10-400.fc34"},
+	}, changes)
+
+	// A second kernel is added
+	changes = diffPackages(
+		[]weldr.PackageNEVRA{kernel("200.fc34")},
+		[]weldr.PackageNEVRA{kernel("300.fc34"), kernel("200.fc34")})
+	assert.Equal(t, []packageChange{
+		{Change: "added", Name: "kernel", Arch: "x86_64", New: "5.14.10-300.fc34"},
+	}, changes)
+
+	// Both kernels are replaced
+	changes = diffPackages(
+		[]weldr.PackageNEVRA{kernel("200.fc34"), kernel("100.fc34")},
+		[]weldr.PackageNEVRA{kernel("400.fc34"), kernel("300.fc34")})
+	assert.Equal(t, []packageChange{
+		{Change: "removed", Name: "kernel", Arch: "x86_64", Old: "5.14.10-100.fc34"},
+		{Change: "removed", Name: "kernel", Arch: "x86_64", Old: "5.14.10-200.fc34"},
+		{Change: "added", Name: "kernel", Arch: "x86_64", New: "5.14.10-300.fc34"},
+		{Change: "added", Name: "kernel", Arch: "x86_64", New: "5.14.10-400.fc34"},
+	}, changes)
+
+	assert.Equal(t, []packageChange{}, diffPackages(
+		[]weldr.PackageNEVRA{kernel("300.fc34"), kernel("200.fc34")},
+		[]weldr.PackageNEVRA{kernel("200.fc34"), kernel("300.fc34")}))
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	freezeCheckCmd = &cobra.Command{
+		Use:   "freeze-check UUID [FILE]",
+		Short: "Check the packages of a compose against a frozen blueprint file",
+		Long: `Check the packages of a compose started with start --frozen against the frozen blueprint file
+
+The packages and modules of the frozen blueprint are read from the UUID-frozen.json
+file recorded by start --frozen in the current directory, or from FILE. Each of
+them has to be in the compose's packages with exactly the same version, and the
+same arch if the file includes it. The differences are listed and it returns an
+error if there are any.`,
+		RunE: freezeCheck,
+		Args: cobra.RangeArgs(1, 2),
+	}
+)
+
+func init() {
+	composeCmd.AddCommand(freezeCheckCmd)
+}
+
+// frozenCompose records the frozen blueprint file that a compose was started from
+type frozenCompose struct {
+	Compose   string          `json:"compose"`
+	Blueprint string          `json:"blueprint"`
+	Pushed    string          `json:"pushed"`
+	File      string          `json:"file"`
+	Packages  []weldr.Package `json:"packages"`
+	Modules   []weldr.Package `json:"modules"`
+}
+
+// frozenComposeFile returns the name of the file recording the compose's frozen blueprint
+func frozenComposeFile(uuid string) string {
+	return uuid + "-frozen.json"
+}
+
+// writeFrozenCompose records the frozen blueprint used by the compose in the current directory
+// The packages are included so that the file can be checked even if the frozen file changes.
+func writeFrozenCompose(uuid, filename string, bp weldr.Blueprint) (string, error) {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(frozenCompose{
+		Compose:   uuid,
+		Blueprint: bp.Name,
+		Pushed:    frozenName(bp.Name),
+		File:      path,
+		Packages:  bp.Packages,
+		Modules:   bp.Modules,
+	}, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	out := frozenComposeFile(uuid)
+	return out, ioutil.WriteFile(out, append(data, '\n'), 0644)
+}
+
+// readFrozenCompose reads the frozen blueprint recorded for the compose
+// It returns the frozen packages and modules, and the file they came from.
+func readFrozenCompose(uuid string) (weldr.Blueprint, string, error) {
+	data, err := ioutil.ReadFile(frozenComposeFile(uuid))
+	if err != nil {
+		return weldr.Blueprint{}, "", err
+	}
+	var fc frozenCompose
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return weldr.Blueprint{}, "", fmt.Errorf("%s: %s", frozenComposeFile(uuid), err)
+	}
+	if fc.Compose != uuid {
+		return weldr.Blueprint{}, "", fmt.Errorf("%s is for compose %s", frozenComposeFile(uuid), fc.Compose)
+	}
+	return weldr.Blueprint{Name: fc.Blueprint, Packages: fc.Packages, Modules: fc.Modules}, fc.File, nil
+}
+
+func freezeCheck(cmd *cobra.Command, args []string) error {
+	uuid := args[0]
+	var frozen weldr.Blueprint
+	var filename string
+	var err error
+	if len(args) > 1 {
+		filename = args[1]
+		frozen, err = readFrozen(filename)
+		if err != nil {
+			return root.ExecutionError(cmd, "Frozen Error: %s: %s", filename, err)
+		}
+	} else {
+		frozen, filename, err = readFrozenCompose(uuid)
+		if err != nil {
+			return root.ExecutionError(cmd, "Frozen Error: %s", err)
+		}
+	}
+
+	info, resp, err := root.Client.ComposeInfo(uuid)
+	if err != nil {
+		return root.ExecutionError(cmd, "Frozen Error: %s", err)
+	}
+	if resp != nil {
+		return root.ExecutionErrors(cmd, resp.Errors)
+	}
+	if info.Blueprint.Name != frozenName(frozen.Name) {
+		return root.ExecutionError(cmd, "Frozen Error: compose %s was built from %s, not %s", uuid, info.Blueprint.Name, frozenName(frozen.Name))
+	}
+	if len(info.Deps.Packages) == 0 {
+		return root.ExecutionError(cmd, "Frozen Error: compose %s has no packages, it is %s", uuid, info.QueueStatus)
+	}
+
+	changes := frozenChanges(frozen, info.Deps.Packages)
+	if len(changes) == 0 {
+		fmt.Printf("compose %s packages match %s\n", uuid, filename)
+		return nil
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	return root.ExecutionError(cmd, "Frozen Error: the packages in compose %s do not match %s", uuid, filename)
+}
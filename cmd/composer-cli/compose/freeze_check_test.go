@@ -0,0 +1,104 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+const freezeCheckUUID = "876b2946-16cd-4f38-bace-0cdd0093d112"
+
+// runFreezeCheck runs compose freeze-check with the compose info returning the blueprint and packages
+func runFreezeCheck(t *testing.T, blueprint, packages string, args ...string) (string, string, error) {
+	body := fmt.Sprintf(`{"id": "%s", "blueprint": {"name": "%s", "version": "0.0.2"},
+		"deps": {"packages": [%s]}, "compose_type": "qcow2", "queue_status": "FINISHED"}`, freezeCheckUUID, blueprint, packages)
+	server := routeServer(map[string]string{"/api/v1/compose/info/" + freezeCheckUUID: body},
+		`{"status": false, "errors": [{"id": "UnknownUUID", "msg": "unknown compose"}]}`)
+	return executeCmd(t, server, append([]string{"compose", "freeze-check"}, args...)...)
+}
+
+// recordFrozenTest writes the record of the frozen test blueprint for the compose
+func recordFrozenTest(t *testing.T) {
+	require.Nil(t, ioutil.WriteFile("http-server.frozen.toml", []byte(frozenTestTOML), 0644))
+	bp, err := weldr.NewBlueprintFromTOML(frozenTestTOML)
+	require.Nil(t, err)
+	_, err = writeFrozenCompose(freezeCheckUUID, "http-server.frozen.toml", bp)
+	require.Nil(t, err)
+}
+
+func TestCmdComposeFreezeCheck(t *testing.T) {
+	inTempDir(t, func() {
+		recordFrozenTest(t)
+		stdout, stderr, err := runFreezeCheck(t, "http-server-frozen", frozenTestPackages+`,
+			{"name": "libevent", "epoch": 0, "version": "2.1.12", "release": "3.fc34", "arch": "x86_64"}`,
+			freezeCheckUUID)
+		require.Nil(t, err)
+		assert.Equal(t, "", stderr)
+		assert.Regexp(t, "^compose "+freezeCheckUUID+" packages match /.*/http-server.frozen.toml\n$", stdout)
+	})
+}
+
+func TestCmdComposeFreezeCheckMismatch(t *testing.T) {
+	inTempDir(t, func() {
+		recordFrozenTest(t)
+
+		// A different version is listed
+		stdout, stderr, err := runFreezeCheck(t, "http-server-frozen", `
+			{"name": "tmux", "epoch": 0, "version": "3.2a", "release": "1.fc34", "arch": "x86_64"},
+			{"name": "vim-minimal", "epoch": 2, "version": "8.2.3318", "release": "1.fc34", "arch": "x86_64"}`,
+			freezeCheckUUID)
+		require.NotNil(t, err)
+		assert.Equal(t, "changed tmux 3.1c-2.fc34.x86_64 -> 3.2a-1.fc34.x86_64\n", stdout)
+		assert.Contains(t, stderr, "ERROR: Frozen Error: the packages in compose "+freezeCheckUUID+" do not match ")
+
+		// Another version built along with the frozen one does not match either
+		stdout, _, err = runFreezeCheck(t, "http-server-frozen", frozenTestPackages+`,
+			{"name": "tmux", "epoch": 0, "version": "3.2a", "release": "1.fc34", "arch": "i686"}`,
+			freezeCheckUUID)
+		require.NotNil(t, err)
+		assert.Equal(t, "changed tmux 3.1c-2.fc34.x86_64 -> 3.1c-2.fc34.x86_64, 3.2a-1.fc34.i686\n", stdout)
+
+		// A missing package is listed
+		stdout, _, err = runFreezeCheck(t, "http-server-frozen", `
+			{"name": "tmux", "epoch": 0, "version": "3.1c", "release": "2.fc34", "arch": "x86_64"}`,
+			freezeCheckUUID)
+		require.NotNil(t, err)
+		assert.Equal(t, "removed vim-minimal 2:8.2.3318-1.fc34.x86_64\n", stdout)
+	})
+}
+
+func TestCmdComposeFreezeCheckFile(t *testing.T) {
+	inTempDir(t, func() {
+		// The file can be given instead of the record, in any of the blueprint formats
+		frozen := `{"name": "http-server", "packages": [{"name": "tmux", "version": "3.1c-2.fc34"}]}`
+		require.Nil(t, ioutil.WriteFile("http-server.frozen.json", []byte(frozen), 0644))
+		stdout, stderr, err := runFreezeCheck(t, "http-server-frozen", frozenTestPackages,
+			freezeCheckUUID, "http-server.frozen.json")
+		require.Nil(t, err)
+		assert.Equal(t, "", stderr)
+		assert.Equal(t, "compose "+freezeCheckUUID+" packages match http-server.frozen.json\n", stdout)
+
+		// The compose has to be built from the frozen blueprint
+		_, stderr, err = runFreezeCheck(t, "http-server", frozenTestPackages,
+			freezeCheckUUID, "http-server.frozen.json")
+		require.NotNil(t, err)
+		assert.Equal(t, "ERROR: Frozen Error: compose "+freezeCheckUUID+" was built from http-server, not http-server-frozen\n", stderr)
+	})
+}
+
+func TestCmdComposeFreezeCheckNoRecord(t *testing.T) {
+	inTempDir(t, func() {
+		_, stderr, err := runFreezeCheck(t, "http-server-frozen", frozenTestPackages, freezeCheckUUID)
+		require.NotNil(t, err)
+		assert.Contains(t, stderr, "ERROR: Frozen Error: open "+freezeCheckUUID+"-frozen.json: ")
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+)
+
+var (
+	licensesCmd = &cobra.Command{
+		Use:   "licenses UUID [--policy FILE]",
+		Short: "List the licenses of the packages in the compose",
+		Long: `List the licenses of the packages in the compose
+
+The packages are grouped by their license. Old Fedora license names are replaced
+by their SPDX identifiers. With --policy the licenses are checked against a TOML
+file with allow and deny lists of licenses, eg. allow = ["MIT", "GPL-2.0-or-later"],
+and it returns an error if any of the packages are not allowed. When the allow
+list is empty every license that is not denied is allowed.`,
+		RunE: licenses,
+		Args: cobra.ExactArgs(1),
+	}
+	licensesPolicy string
+)
+
+func init() {
+	licensesCmd.Flags().StringVarP(&licensesPolicy, "policy", "", "", "TOML file with the allowed and denied licenses")
+	composeCmd.AddCommand(licensesCmd)
+}
+
+func licenses(cmd *cobra.Command, args []string) error {
+	var policy *root.LicensePolicy
+	if len(licensesPolicy) > 0 {
+		p, err := root.ReadLicensePolicy(licensesPolicy)
+		if err != nil {
+			return root.ExecutionError(cmd, "Licenses Error: %s: %s", licensesPolicy, err)
+		}
+		policy = &p
+	}
+
+	info, resp, err := root.Client.ComposeInfo(args[0])
+	if err != nil {
+		return root.ExecutionError(cmd, "Licenses Error: %s", err)
+	}
+	if resp != nil {
+		return root.ExecutionErrors(cmd, resp.Errors)
+	}
+
+	pkgs, err := root.LookupPackages(info.Deps.Packages, info.Blueprint.Distro)
+	if err != nil {
+		return root.ExecutionError(cmd, "Licenses Error: %s", err)
+	}
+	if violations := root.PrintLicenseReport(pkgs, policy); violations > 0 {
+		return root.ExecutionError(cmd, "Licenses Error: %d packages in %s are not allowed by %s", violations, args[0], licensesPolicy)
+	}
+	return nil
+}
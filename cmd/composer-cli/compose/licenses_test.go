@@ -0,0 +1,54 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runComposeLicenses(t *testing.T, args ...string) (string, string, error) {
+	return executeCmd(t, sbomTestServer(), append([]string{"compose", "licenses"}, args...)...)
+}
+
+func TestCmdComposeLicenses(t *testing.T) {
+	stdout, stderr, err := runComposeLicenses(t, "--policy", "", "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	assert.Equal(t, `GPL-3.0-or-later: 1 packages
+    bash-5.1.8-1.fc34.x86_64
+Vim AND Copyright only: 1 packages
+    vim-minimal-2:8.2.3318-1.fc34.x86_64
+unknown license: 1 packages
+    libstdc++-11.2.1-1.fc34.x86_64
+`, stdout)
+}
+
+func TestCmdComposeLicensesPolicy(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "test-policy-*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpFile.Name())
+	_, err = tmpFile.Write([]byte("deny = [\"GPLv3+\"]\n"))
+	require.Nil(t, err)
+	tmpFile.Close()
+
+	stdout, stderr, err := runComposeLicenses(t, "--policy", tmpFile.Name(), "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7")
+	require.NotNil(t, err)
+	assert.Contains(t, stdout, "GPL-3.0-or-later: 1 packages, not allowed\n")
+	assert.Contains(t, stdout, "Vim AND Copyright only: 1 packages\n")
+	assert.Contains(t, stdout, "unknown license: 1 packages\n")
+	assert.Equal(t, "ERROR: Licenses Error: 1 packages in b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7 are not allowed by "+tmpFile.Name()+"\n", stderr)
+}
+
+func TestCmdComposeLicensesUnknown(t *testing.T) {
+	stdout, stderr, err := runComposeLicenses(t, "--policy", "", "unknown-uuid")
+	require.NotNil(t, err)
+	assert.Equal(t, "", stdout)
+	assert.Equal(t, "ERROR: UnknownUUID: unknown-uuid is not a valid build uuid\n", stderr)
+}
@@ -0,0 +1,430 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+var (
+	sbomCmd = &cobra.Command{
+		Use:   "sbom UUID [--format spdx-json|cyclonedx-json] [--vendor VENDOR]",
+		Short: "Output a software bill of materials for the compose",
+		Long: `Output a software bill of materials for the compose
+
+The document lists the image as the top-level component, and every package
+installed in it with its package URL (pkg:rpm/...), license, homepage, upstream
+VCS, and source ref when the server knows them. --format selects an SPDX 2.3 or
+a CycloneDX 1.4 JSON document. --vendor sets the namespace of the package URLs,
+eg. fedora or redhat. Each document has a new random UUID, used for the SPDX
+namespace and the CycloneDX serial number. Old Fedora license names are replaced
+by their SPDX identifiers, licenses that still use names that are not on the SPDX
+license list are included as SPDX LicenseRefs, or as CycloneDX license names.`,
+		RunE: sbom,
+		Args: cobra.ExactArgs(1),
+	}
+	sbomFormat string
+	sbomVendor string
+
+	// sbomTime returns the creation time of the document, it is replaced by the tests
+	sbomTime = time.Now
+
+	// sbomUUID returns the unique ID of the document, it is replaced by the tests
+	sbomUUID = randomUUID
+)
+
+func init() {
+	sbomCmd.Flags().StringVarP(&sbomFormat, "format", "", "spdx-json", "Format of the document, spdx-json or cyclonedx-json")
+	sbomCmd.Flags().StringVarP(&sbomVendor, "vendor", "", "", "Vendor namespace for the package URLs")
+	composeCmd.AddCommand(sbomCmd)
+}
+
+// purlEscape percent-encodes a package URL component
+// Everything except letters, digits, and .-_~ is encoded.
+func purlEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isDigitOrAlpha(c) || strings.IndexByte(".-_~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func isDigitOrAlpha(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// packageURL returns the package URL of an rpm
+// The qualifiers are sorted by key, the vendor and the distro are left out when empty.
+func packageURL(p weldr.PackageNEVRA, vendor, distro string) string {
+	purl := "pkg:rpm/"
+	if len(vendor) > 0 {
+		purl += purlEscape(vendor) + "/"
+	}
+	purl += fmt.Sprintf("%s@%s", purlEscape(p.Name), purlEscape(p.Version+"-"+p.Release))
+
+	var qualifiers []string
+	if len(p.Arch) > 0 {
+		qualifiers = append(qualifiers, "arch="+purlEscape(p.Arch))
+	}
+	if len(distro) > 0 {
+		qualifiers = append(qualifiers, "distro="+purlEscape(distro))
+	}
+	if p.Epoch > 0 {
+		qualifiers = append(qualifiers, fmt.Sprintf("epoch=%d", p.Epoch))
+	}
+	if len(qualifiers) > 0 {
+		purl += "?" + strings.Join(qualifiers, "&")
+	}
+	return purl
+}
+
+var spdxRefRegex = regexp.MustCompile(`[^A-Za-z0-9.]+`)
+
+// spdxRef returns a valid SPDX identifier with the prefix
+func spdxRef(prefix, s string) string {
+	return prefix + strings.Trim(spdxRefRegex.ReplaceAllString(s, "-"), "-")
+}
+
+// uniqueSPDXRef returns an SPDX identifier with the prefix that is not already used
+// Different strings can have the same identifier, a number is added to the later ones.
+func uniqueSPDXRef(used map[string]bool, prefix, s string) string {
+	ref := spdxRef(prefix, s)
+	id := ref
+	for i := 2; used[id]; i++ {
+		id = fmt.Sprintf("%s-%d", ref, i)
+	}
+	used[id] = true
+	return id
+}
+
+// randomUUID returns a random version 4 UUID
+func randomUUID() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", err
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:]), nil
+}
+
+// writeJSON prints the document as indented JSON, without escaping the & in package URLs
+func writeJSON(doc interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	return enc.Encode(doc)
+}
+
+type spdxDocument struct {
+	SPDXVersion       string                     `json:"spdxVersion"`
+	DataLicense       string                     `json:"dataLicense"`
+	SPDXID            string                     `json:"SPDXID"`
+	Name              string                     `json:"name"`
+	DocumentNamespace string                     `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo           `json:"creationInfo"`
+	Packages          []spdxPackage              `json:"packages"`
+	Relationships     []spdxRelationship         `json:"relationships"`
+	ExtractedLicenses []spdxExtractedLicenseInfo `json:"hasExtractedLicensingInfos,omitempty"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	Homepage              string            `json:"homepage,omitempty"`
+	LicenseDeclared       string            `json:"licenseDeclared"`
+	SourceInfo            string            `json:"sourceInfo,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+type spdxExtractedLicenseInfo struct {
+	LicenseID     string `json:"licenseId"`
+	Name          string `json:"name"`
+	ExtractedText string `json:"extractedText"`
+}
+
+// newSPDX returns an SPDX document for the compose
+// The document's namespace includes its unique ID. Packages are identified by their
+// name, EVR and arch. Licenses that are not SPDX expressions, and the LicenseRefs used
+// in the expressions, are listed in the extracted licenses.
+func newSPDX(info weldr.ComposeInfoV0, pkgs []root.PackageDetails, vendor, docID string, created time.Time) spdxDocument {
+	name := fmt.Sprintf("%s-%s-%s", info.Blueprint.Name, info.Blueprint.Version, info.ComposeType)
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              name,
+		DocumentNamespace: fmt.Sprintf("https://osbuild.org/spdxdocs/composer-cli/%s-%s", name, docID),
+		CreationInfo: spdxCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: composer-cli-" + root.Version},
+		},
+		Packages: []spdxPackage{{
+			SPDXID:                "SPDXRef-Image",
+			Name:                  info.Blueprint.Name,
+			VersionInfo:           info.Blueprint.Version,
+			DownloadLocation:      "NOASSERTION",
+			LicenseDeclared:       "NOASSERTION",
+			PrimaryPackagePurpose: "OPERATING-SYSTEM",
+		}},
+		Relationships: []spdxRelationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: "SPDXRef-Image",
+		}},
+	}
+
+	// The LicenseRefs in the expressions are reserved first so that the ones made
+	// for the other licenses do not use the same identifier.
+	usedIDs := map[string]bool{"SPDXRef-DOCUMENT": true, "SPDXRef-Image": true}
+	licenseRefs := make(map[string]string)
+	for _, p := range pkgs {
+		if !root.IsSPDXExpression(p.License) {
+			continue
+		}
+		for _, ref := range root.LicenseRefs(p.License) {
+			if usedIDs[ref] {
+				continue
+			}
+			usedIDs[ref] = true
+			// The text of the license is not available, the package's license is what
+			// the LicenseRef was extracted from.
+			doc.ExtractedLicenses = append(doc.ExtractedLicenses, spdxExtractedLicenseInfo{
+				LicenseID:     ref,
+				Name:          strings.TrimPrefix(ref, "LicenseRef-"),
+				ExtractedText: p.License,
+			})
+		}
+	}
+
+	for _, p := range pkgs {
+		sp := spdxPackage{
+			SPDXID:           uniqueSPDXRef(usedIDs, "SPDXRef-Package-", fmt.Sprintf("%s-%s-%s", p.Name, p.EVR(), p.Arch)),
+			Name:             p.Name,
+			VersionInfo:      p.EVR(),
+			DownloadLocation: "NOASSERTION",
+			Homepage:         p.Homepage,
+			LicenseDeclared:  "NOASSERTION",
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  packageURL(p.PackageNEVRA, vendor, info.Blueprint.Distro),
+			}},
+			PrimaryPackagePurpose: "LIBRARY",
+		}
+		if len(p.UpstreamVCS) > 0 {
+			sp.DownloadLocation = p.UpstreamVCS
+		}
+		if len(p.SourceRef) > 0 {
+			sp.SourceInfo = "built from source ref " + p.SourceRef
+		}
+		switch {
+		case len(p.License) == 0:
+		case root.IsSPDXExpression(p.License):
+			sp.LicenseDeclared = p.License
+		default:
+			ref, ok := licenseRefs[p.License]
+			if !ok {
+				ref = uniqueSPDXRef(usedIDs, "LicenseRef-", p.License)
+				licenseRefs[p.License] = ref
+				doc.ExtractedLicenses = append(doc.ExtractedLicenses, spdxExtractedLicenseInfo{
+					LicenseID:     ref,
+					Name:          p.License,
+					ExtractedText: p.License,
+				})
+			}
+			sp.LicenseDeclared = ref
+		}
+		doc.Packages = append(doc.Packages, sp)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      "SPDXRef-Image",
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: sp.SPDXID,
+		})
+	}
+	return doc
+}
+
+type cyclonedxDocument struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	SerialNumber string                `json:"serialNumber"`
+	Version      int                   `json:"version"`
+	Metadata     cyclonedxMetadata     `json:"metadata"`
+	Components   []cyclonedxComponent  `json:"components"`
+	Dependencies []cyclonedxDependency `json:"dependencies"`
+}
+
+type cyclonedxMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     []cyclonedxTool    `json:"tools"`
+	Component cyclonedxComponent `json:"component"`
+}
+
+type cyclonedxTool struct {
+	Vendor  string `json:"vendor"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type cyclonedxComponent struct {
+	Type               string                 `json:"type"`
+	BOMRef             string                 `json:"bom-ref"`
+	Name               string                 `json:"name"`
+	Version            string                 `json:"version,omitempty"`
+	Description        string                 `json:"description,omitempty"`
+	Licenses           []cyclonedxLicense     `json:"licenses,omitempty"`
+	PURL               string                 `json:"purl,omitempty"`
+	ExternalReferences []cyclonedxExternalRef `json:"externalReferences,omitempty"`
+	Properties         []cyclonedxProperty    `json:"properties,omitempty"`
+}
+
+type cyclonedxLicense struct {
+	Expression string                `json:"expression,omitempty"`
+	License    *cyclonedxLicenseName `json:"license,omitempty"`
+}
+
+type cyclonedxLicenseName struct {
+	Name string `json:"name"`
+}
+
+type cyclonedxExternalRef struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cyclonedxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cyclonedxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// newCycloneDX returns a CycloneDX document for the compose
+// The serial number is the document's unique ID.
+func newCycloneDX(info weldr.ComposeInfoV0, pkgs []root.PackageDetails, vendor, docID string, created time.Time) cyclonedxDocument {
+	doc := cyclonedxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.4",
+		SerialNumber: "urn:uuid:" + docID,
+		Version:      1,
+		Metadata: cyclonedxMetadata{
+			Timestamp: created.UTC().Format(time.RFC3339),
+			Tools:     []cyclonedxTool{{Vendor: "osbuild", Name: "composer-cli", Version: root.Version}},
+			Component: cyclonedxComponent{
+				Type:        "operating-system",
+				BOMRef:      info.ID,
+				Name:        info.Blueprint.Name,
+				Version:     info.Blueprint.Version,
+				Description: fmt.Sprintf("%s image of blueprint %s", info.ComposeType, info.Blueprint.Name),
+			},
+		},
+		Components: []cyclonedxComponent{},
+	}
+
+	image := cyclonedxDependency{Ref: info.ID, DependsOn: []string{}}
+	for _, p := range pkgs {
+		purl := packageURL(p.PackageNEVRA, vendor, info.Blueprint.Distro)
+		c := cyclonedxComponent{
+			Type:    "library",
+			BOMRef:  purl,
+			Name:    p.Name,
+			Version: p.EVR(),
+			PURL:    purl,
+		}
+		switch {
+		case len(p.License) == 0:
+		case root.IsSPDXExpression(p.License):
+			c.Licenses = []cyclonedxLicense{{Expression: p.License}}
+		default:
+			c.Licenses = []cyclonedxLicense{{License: &cyclonedxLicenseName{Name: p.License}}}
+		}
+		if len(p.Homepage) > 0 {
+			c.ExternalReferences = append(c.ExternalReferences, cyclonedxExternalRef{Type: "website", URL: p.Homepage})
+		}
+		if len(p.UpstreamVCS) > 0 {
+			c.ExternalReferences = append(c.ExternalReferences, cyclonedxExternalRef{Type: "vcs", URL: p.UpstreamVCS})
+		}
+		if len(p.SourceRef) > 0 {
+			c.Properties = append(c.Properties, cyclonedxProperty{Name: "osbuild:source_ref", Value: p.SourceRef})
+		}
+		doc.Components = append(doc.Components, c)
+		image.DependsOn = append(image.DependsOn, purl)
+	}
+	doc.Dependencies = []cyclonedxDependency{image}
+	return doc
+}
+
+func sbom(cmd *cobra.Command, args []string) error {
+	if sbomFormat != "spdx-json" && sbomFormat != "cyclonedx-json" {
+		return root.ExecutionError(cmd, "SBOM Error: unknown format %s, it should be spdx-json or cyclonedx-json", sbomFormat)
+	}
+	info, resp, err := root.Client.ComposeInfo(args[0])
+	if err != nil {
+		return root.ExecutionError(cmd, "SBOM Error: %s", err)
+	}
+	if resp != nil {
+		return root.ExecutionErrors(cmd, resp.Errors)
+	}
+	pkgs, err := root.LookupPackages(info.Deps.Packages, info.Blueprint.Distro)
+	if err != nil {
+		return root.ExecutionError(cmd, "SBOM Error: %s", err)
+	}
+
+	docID, err := sbomUUID()
+	if err != nil {
+		return root.ExecutionError(cmd, "SBOM Error: %s", err)
+	}
+	var doc interface{}
+	if sbomFormat == "spdx-json" {
+		doc = newSPDX(info, pkgs, sbomVendor, docID, sbomTime())
+	} else {
+		doc = newCycloneDX(info, pkgs, sbomVendor, docID, sbomTime())
+	}
+	if err := writeJSON(doc); err != nil {
+		return root.ExecutionError(cmd, "SBOM Error: %s", err)
+	}
+	return nil
+}
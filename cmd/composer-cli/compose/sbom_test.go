@@ -0,0 +1,253 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+const sbomTestInfo = `{"id": "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7", "compose_type": "qcow2", "queue_status": "FINISHED",
+	"blueprint": {"name": "http-server", "version": "0.0.1", "distro": "fedora-34"},
+	"deps": {"packages": [
+		{"name": "vim-minimal", "epoch": 2, "version": "8.2.3318", "release": "1.fc34", "arch": "x86_64"},
+		{"name": "bash", "epoch": 0, "version": "5.1.8", "release": "1.fc34", "arch": "x86_64"},
+		{"name": "libstdc++", "epoch": 0, "version": "11.2.1", "release": "1.fc34", "arch": "x86_64"}
+	]}}`
+
+const sbomTestProjects = `{"projects": [
+	{"name": "bash", "homepage": "https://www.gnu.org/software/bash", "upstream_vcs": "UPSTREAM_VCS",
+		"builds": [
+			{"arch": "x86_64", "epoch": 0, "release": "1.fc34", "source": {"license": "GPLv3+", "version": "5.1.8", "source_ref": "SOURCE_REF"}},
+			{"arch": "x86_64", "epoch": 0, "release": "2.fc34", "source": {"license": "GPL-2.0-or-later", "version": "5.1.8", "source_ref": "SOURCE_REF"}}
+		]},
+	{"name": "vim-minimal", "homepage": "http://www.vim.org/", "upstream_vcs": "https://github.com/vim/vim",
+		"builds": [
+			{"arch": "x86_64", "epoch": 2, "release": "1.fc34", "source": {"license": "Vim and Copyright only", "version": "8.2.3318", "source_ref": "v8.2.3318"}}
+		]}
+]}`
+
+// sbomTestServer returns the compose info and the projects for the sbom and licenses tests
+func sbomTestServer() func(request *http.Request) (*http.Response, error) {
+	return routeServer(map[string]string{
+		"/api/v1/compose/info/b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7": sbomTestInfo,
+		"/api/v1/projects/info/":                                    sbomTestProjects,
+	}, `{"status": false,
+		"errors": [{"id": "UnknownUUID", "msg": "unknown-uuid is not a valid build uuid"}]}`)
+}
+
+func runSbom(t *testing.T, args ...string) (string, string, *http.Request, error) {
+	defer func() { sbomTime, sbomUUID = time.Now, randomUUID }()
+	sbomTime = func() time.Time { return time.Date(2021, 10, 19, 12, 30, 0, 0, time.UTC) }
+	sbomUUID = func() (string, error) { return "0b4dd8c1-0f2e-4a5f-9d1c-6a2f1e0c7b3d", nil }
+
+	var projectsReq *http.Request
+	server := sbomTestServer()
+	stdout, stderr, err := executeCmd(t, func(request *http.Request) (*http.Response, error) {
+		if strings.HasPrefix(request.URL.Path, "/api/v1/projects/info/") {
+			projectsReq = request
+		}
+		return server(request)
+	}, append([]string{"compose", "sbom"}, args...)...)
+	return stdout, stderr, projectsReq, err
+}
+
+func TestCmdComposeSbomSPDX(t *testing.T) {
+	stdout, stderr, req, err := runSbom(t, "--format", "spdx-json", "--vendor", "fedora", "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+	require.NotNil(t, req)
+	assert.Equal(t, "/api/v1/projects/info/bash,libstdc++,vim-minimal", req.URL.Path)
+	assert.Equal(t, "fedora-34", req.URL.Query().Get("distro"))
+	assert.Contains(t, stdout, `"referenceLocator": "pkg:rpm/fedora/vim-minimal@8.2.3318-1.fc34?arch=x86_64&distro=fedora-34&epoch=2"`)
+
+	var doc spdxDocument
+	require.Nil(t, json.Unmarshal([]byte(stdout), &doc))
+	assert.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+	assert.Equal(t, "http-server-0.0.1-qcow2", doc.Name)
+	assert.Equal(t, "https://osbuild.org/spdxdocs/composer-cli/http-server-0.0.1-qcow2-0b4dd8c1-0f2e-4a5f-9d1c-6a2f1e0c7b3d", doc.DocumentNamespace)
+	assert.Equal(t, "2021-10-19T12:30:00Z", doc.CreationInfo.Created)
+	require.Equal(t, 4, len(doc.Packages))
+	assert.Equal(t, "SPDXRef-Image", doc.Packages[0].SPDXID)
+	assert.Equal(t, "OPERATING-SYSTEM", doc.Packages[0].PrimaryPackagePurpose)
+
+	bash := doc.Packages[1]
+	assert.Equal(t, "SPDXRef-Package-bash-5.1.8-1.fc34-x86-64", bash.SPDXID)
+	assert.Equal(t, "5.1.8-1.fc34", bash.VersionInfo)
+	assert.Equal(t, "GPL-3.0-or-later", bash.LicenseDeclared)
+	assert.Equal(t, "NOASSERTION", bash.DownloadLocation)
+	assert.Equal(t, "", bash.SourceInfo)
+	assert.Equal(t, "https://www.gnu.org/software/bash", bash.Homepage)
+
+	libstdcxx := doc.Packages[2]
+	assert.Equal(t, "SPDXRef-Package-libstdc-11.2.1-1.fc34-x86-64", libstdcxx.SPDXID)
+	assert.Equal(t, "NOASSERTION", libstdcxx.LicenseDeclared)
+	assert.Equal(t, "pkg:rpm/fedora/libstdc%2B%2B@11.2.1-1.fc34?arch=x86_64&distro=fedora-34", libstdcxx.ExternalRefs[0].ReferenceLocator)
+
+	vim := doc.Packages[3]
+	assert.Equal(t, "2:8.2.3318-1.fc34", vim.VersionInfo)
+	assert.Equal(t, "LicenseRef-Vim-AND-Copyright-only", vim.LicenseDeclared)
+	assert.Equal(t, "https://github.com/vim/vim", vim.DownloadLocation)
+	assert.Equal(t, "built from source ref v8.2.3318", vim.SourceInfo)
+	assert.Equal(t, []spdxExtractedLicenseInfo{{LicenseID: "LicenseRef-Vim-AND-Copyright-only", Name: "Vim AND Copyright only", ExtractedText: "Vim AND Copyright only"}},
+		doc.ExtractedLicenses)
+	checkExtractedLicenses(t, doc)
+
+	require.Equal(t, 4, len(doc.Relationships))
+	assert.Equal(t, spdxRelationship{"SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-Image"}, doc.Relationships[0])
+	assert.Equal(t, spdxRelationship{"SPDXRef-Image", "CONTAINS", "SPDXRef-Package-vim-minimal-2-8.2.3318-1.fc34-x86-64"}, doc.Relationships[3])
+}
+
+func TestCmdComposeSbomCycloneDX(t *testing.T) {
+	stdout, stderr, _, err := runSbom(t, "--format", "cyclonedx-json", "--vendor", "", "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7")
+	require.Nil(t, err)
+	assert.Equal(t, "", stderr)
+
+	var doc cyclonedxDocument
+	require.Nil(t, json.Unmarshal([]byte(stdout), &doc))
+	assert.Equal(t, "CycloneDX", doc.BOMFormat)
+	assert.Equal(t, "1.4", doc.SpecVersion)
+	assert.Equal(t, "urn:uuid:0b4dd8c1-0f2e-4a5f-9d1c-6a2f1e0c7b3d", doc.SerialNumber)
+	assert.Equal(t, "2021-10-19T12:30:00Z", doc.Metadata.Timestamp)
+	assert.Equal(t, "operating-system", doc.Metadata.Component.Type)
+	assert.Equal(t, "http-server", doc.Metadata.Component.Name)
+
+	require.Equal(t, 3, len(doc.Components))
+	bash := doc.Components[0]
+	assert.Equal(t, "pkg:rpm/bash@5.1.8-1.fc34?arch=x86_64&distro=fedora-34", bash.PURL)
+	assert.Equal(t, bash.PURL, bash.BOMRef)
+	assert.Equal(t, []cyclonedxLicense{{Expression: "GPL-3.0-or-later"}}, bash.Licenses)
+	assert.Equal(t, []cyclonedxExternalRef{{Type: "website", URL: "https://www.gnu.org/software/bash"}}, bash.ExternalReferences)
+	assert.Nil(t, doc.Components[1].Licenses)
+
+	vim := doc.Components[2]
+	assert.Equal(t, []cyclonedxLicense{{License: &cyclonedxLicenseName{Name: "Vim AND Copyright only"}}}, vim.Licenses)
+	assert.Equal(t, []cyclonedxProperty{{Name: "osbuild:source_ref", Value: "v8.2.3318"}}, vim.Properties)
+
+	require.Equal(t, 1, len(doc.Dependencies))
+	assert.Equal(t, "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7", doc.Dependencies[0].Ref)
+	assert.Equal(t, 3, len(doc.Dependencies[0].DependsOn))
+}
+
+func TestCmdComposeSbomUnknown(t *testing.T) {
+	stdout, stderr, _, err := runSbom(t, "--format", "spdx-json", "unknown-uuid")
+	require.NotNil(t, err)
+	assert.Equal(t, "", stdout)
+	assert.Equal(t, "ERROR: UnknownUUID: unknown-uuid is not a valid build uuid\n", stderr)
+}
+
+func TestCmdComposeSbomBadFormat(t *testing.T) {
+	defer func() { sbomFormat = "spdx-json" }()
+	_, stderr, _, err := runSbom(t, "--format", "xml", "b27c5a7b-d1f6-4c8c-8526-6d6de464f1c7")
+	require.NotNil(t, err)
+	assert.Equal(t, "ERROR: SBOM Error: unknown format xml, it should be spdx-json or cyclonedx-json\n", stderr)
+}
+
+func TestNewSPDXUniqueIDs(t *testing.T) {
+	libstdcxx := weldr.PackageNEVRA{Name: "libstdc++", Version: "11.2.1", Release: "1.fc34", Arch: "x86_64"}
+	libstdc := weldr.PackageNEVRA{Name: "libstdc", Version: "11.2.1", Release: "1.fc34", Arch: "x86_64"}
+	older := weldr.PackageNEVRA{Name: "libstdc", Version: "11.2.1", Release: "0.fc34", Arch: "x86_64"}
+	doc := newSPDX(weldr.ComposeInfoV0{}, []root.PackageDetails{
+		{PackageNEVRA: libstdcxx, License: "Freeware+"},
+		{PackageNEVRA: libstdc, License: "Freeware"},
+		{PackageNEVRA: older, License: "LicenseRef-Freeware AND MIT"},
+	}, "", "0b4dd8c1-0f2e-4a5f-9d1c-6a2f1e0c7b3d", time.Now())
+
+	require.Equal(t, 4, len(doc.Packages))
+	assert.Equal(t, "SPDXRef-Package-libstdc-11.2.1-1.fc34-x86-64", doc.Packages[1].SPDXID)
+	assert.Equal(t, "SPDXRef-Package-libstdc-11.2.1-1.fc34-x86-64-2", doc.Packages[2].SPDXID)
+	assert.Equal(t, "SPDXRef-Package-libstdc-11.2.1-0.fc34-x86-64", doc.Packages[3].SPDXID)
+
+	// The LicenseRef in the expression keeps its name, the others get their own
+	assert.Equal(t, "LicenseRef-Freeware-2", doc.Packages[1].LicenseDeclared)
+	assert.Equal(t, "LicenseRef-Freeware-3", doc.Packages[2].LicenseDeclared)
+	assert.Equal(t, "LicenseRef-Freeware AND MIT", doc.Packages[3].LicenseDeclared)
+	assert.Equal(t, []spdxExtractedLicenseInfo{
+		{LicenseID: "LicenseRef-Freeware", Name: "Freeware", ExtractedText: "LicenseRef-Freeware AND MIT"},
+		{LicenseID: "LicenseRef-Freeware-2", Name: "Freeware+", ExtractedText: "Freeware+"},
+		{LicenseID: "LicenseRef-Freeware-3", Name: "Freeware", ExtractedText: "Freeware"},
+	}, doc.ExtractedLicenses)
+	checkExtractedLicenses(t, doc)
+}
+
+// checkExtractedLicenses checks the extracted licenses against the SPDX 2.3 rules
+// Every LicenseRef used by a package has to be listed once, with an identifier made of
+// letters, numbers, . and -, and a text. Unused ones are not expected.
+func checkExtractedLicenses(t *testing.T, doc spdxDocument) {
+	idRegex := regexp.MustCompile(`^LicenseRef-[A-Za-z0-9.-]+$`)
+	listed := make(map[string]bool)
+	for _, e := range doc.ExtractedLicenses {
+		assert.Regexp(t, idRegex, e.LicenseID)
+		assert.False(t, listed[e.LicenseID], "%s is listed more than once", e.LicenseID)
+		listed[e.LicenseID] = true
+		assert.NotEmpty(t, e.ExtractedText, "%s has no text", e.LicenseID)
+	}
+	used := make(map[string]bool)
+	for _, p := range doc.Packages {
+		for _, ref := range root.LicenseRefs(p.LicenseDeclared) {
+			used[ref] = true
+			assert.True(t, listed[ref], "%s of %s is not listed", ref, p.SPDXID)
+		}
+	}
+	for id := range listed {
+		assert.True(t, used[id], "%s is not used", id)
+	}
+}
+
+func TestNewSPDXExtractedLicenses(t *testing.T) {
+	pkgs := []root.PackageDetails{
+		{PackageNEVRA: weldr.PackageNEVRA{Name: "a", Version: "1", Release: "1", Arch: "noarch"}, License: "LicenseRef-Fedora-Public-Domain OR MIT"},
+		{PackageNEVRA: weldr.PackageNEVRA{Name: "b", Version: "1", Release: "1", Arch: "noarch"}, License: "LicenseRef-Fedora-Public-Domain"},
+		{PackageNEVRA: weldr.PackageNEVRA{Name: "c", Version: "1", Release: "1", Arch: "noarch"}, License: "GPLv2+ and (MIT or BSD)"},
+		{PackageNEVRA: weldr.PackageNEVRA{Name: "d", Version: "1", Release: "1", Arch: "noarch"}, License: "GPLv2+ and (MIT or BSD)"},
+		{PackageNEVRA: weldr.PackageNEVRA{Name: "e", Version: "1", Release: "1", Arch: "noarch"}, License: "MIT"},
+	}
+	doc := newSPDX(weldr.ComposeInfoV0{}, pkgs, "", "0b4dd8c1-0f2e-4a5f-9d1c-6a2f1e0c7b3d", time.Now())
+	checkExtractedLicenses(t, doc)
+	assert.Equal(t, []spdxExtractedLicenseInfo{
+		{LicenseID: "LicenseRef-Fedora-Public-Domain", Name: "Fedora-Public-Domain", ExtractedText: "LicenseRef-Fedora-Public-Domain OR MIT"},
+		{LicenseID: "LicenseRef-GPLv2-and-MIT-or-BSD", Name: "GPLv2+ and (MIT or BSD)", ExtractedText: "GPLv2+ and (MIT or BSD)"},
+	}, doc.ExtractedLicenses)
+}
+
+func TestNewCycloneDXLicenseName(t *testing.T) {
+	p := weldr.PackageNEVRA{Name: "tmux", Version: "3.2a", Release: "1.fc34", Arch: "x86_64"}
+	doc := newCycloneDX(weldr.ComposeInfoV0{}, []root.PackageDetails{
+		{PackageNEVRA: p, License: "Freeware"},
+		{PackageNEVRA: p, License: "ISC"},
+	}, "", "0b4dd8c1-0f2e-4a5f-9d1c-6a2f1e0c7b3d", time.Now())
+	require.Equal(t, 2, len(doc.Components))
+	assert.Equal(t, []cyclonedxLicense{{License: &cyclonedxLicenseName{Name: "Freeware"}}}, doc.Components[0].Licenses)
+	assert.Equal(t, []cyclonedxLicense{{Expression: "ISC"}}, doc.Components[1].Licenses)
+}
+
+func TestRandomUUID(t *testing.T) {
+	a, err := randomUUID()
+	require.Nil(t, err)
+	b, err := randomUUID()
+	require.Nil(t, err)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, a)
+	assert.NotEqual(t, a, b)
+}
+
+func TestPackageURL(t *testing.T) {
+	p := weldr.PackageNEVRA{Name: "tmux", Version: "3.2a", Release: "1.fc34", Arch: "x86_64"}
+	assert.Equal(t, "pkg:rpm/tmux@3.2a-1.fc34?arch=x86_64", packageURL(p, "", ""))
+	p.Epoch = 1
+	assert.Equal(t, "pkg:rpm/redhat/tmux@3.2a-1.fc34?arch=x86_64&distro=rhel-85&epoch=1", packageURL(p, "redhat", "rhel-85"))
+	p.Arch = ""
+	p.Version = "1.0~rc1^git2"
+	assert.Equal(t, "pkg:rpm/tmux@1.0~rc1%5Egit2-1.fc34?epoch=1", packageURL(p, "", ""))
+}
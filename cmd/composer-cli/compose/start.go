@@ -5,8 +5,12 @@
 package compose
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -16,26 +20,187 @@ import (
 
 var (
 	startCmd = &cobra.Command{
-		Use:   "start BLUEPRINT TYPE [IMAGE-NAME PROFILE.TOML]",
+		Use:   "start BLUEPRINT TYPE [IMAGE-NAME PROFILE.TOML] | start --frozen FILE TYPE [IMAGE-NAME PROFILE.TOML]",
 		Short: "Start a compose using the selected blueprint and output type",
-		Long:  "Start a compose using the selected blueprint and output type. Optionally start an upload. --size is supported by osbuild-composer, and is in MiB",
-		RunE:  start,
+		Long: `Start a compose using the selected blueprint and output type. Optionally start an upload. --size is supported by osbuild-composer, and is in MiB
+
+With --frozen a frozen blueprint file in TOML, JSON, or YAML, saved by blueprints
+freeze save, is used instead of a blueprint name. It is pushed as NAME-frozen,
+with the file it came from in its description, and it is depsolved before the
+compose is queued. Each package and module in the file has to be in the
+depsolved packages with the same version, and the same arch if the file includes
+it. Other dependencies are ignored. The compose is not queued if any of them do
+not match. The file, the blueprint and the compose are recorded in
+UUID-frozen.json in the current directory, use compose freeze-check UUID to
+check the built packages.`,
+		RunE: start,
 		Args: func(cmd *cobra.Command, args []string) error {
-			if len(args) == 2 || len(args) == 4 {
+			if len(frozenFile) > 0 && (len(args) == 1 || len(args) == 3) {
+				return nil
+			}
+			if len(frozenFile) == 0 && (len(args) == 2 || len(args) == 4) {
 				return nil
 			}
 			return errors.New("Invalid number of arguments")
 		},
 	}
-	size uint
+	size       uint
+	frozenFile string
 )
 
 func init() {
 	startCmd.Flags().UintVarP(&size, "size", "", 0, "Size of image in MiB")
+	startCmd.Flags().StringVarP(&frozenFile, "frozen", "", "", "Build the packages in a frozen blueprint file")
 	composeCmd.AddCommand(startCmd)
 }
 
+// frozenName returns the name used to push a frozen blueprint
+func frozenName(name string) string {
+	return name + "-frozen"
+}
+
+// readFrozen reads a frozen blueprint file in any of the blueprint formats
+// It returns an error if any of the packages or modules do not have an exact version.
+func readFrozen(filename string) (weldr.Blueprint, error) {
+	bp, err := root.ReadBlueprintFile(filename)
+	if err != nil {
+		return weldr.Blueprint{}, err
+	}
+	for _, p := range append(append([]weldr.Package{}, bp.Packages...), bp.Modules...) {
+		if len(p.Version) == 0 || strings.ContainsAny(p.Version, "*?[") {
+			return weldr.Blueprint{}, fmt.Errorf("not a frozen blueprint, %s has version '%s'", p.Name, p.Version)
+		}
+	}
+	return bp, nil
+}
+
+// pushFrozen pushes the frozen blueprint under its frozen name
+// The file and the original blueprint are recorded in the description.
+func pushFrozen(filename string, bp weldr.Blueprint) ([]weldr.APIErrorMsg, error) {
+	if len(bp.Version) > 0 {
+		bp.Description = fmt.Sprintf("%s v%s frozen in %s", bp.Name, bp.Version, filepath.Base(filename))
+	} else {
+		bp.Description = fmt.Sprintf("%s frozen in %s", bp.Name, filepath.Base(filename))
+	}
+	bp.Name = frozenName(bp.Name)
+	data, err := bp.TOML()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := root.Client.PushBlueprintTOML(data)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil && !resp.Status {
+		return resp.Errors, nil
+	}
+	return nil, nil
+}
+
+// frozenVersion returns the package's version in the format used by frozen blueprints
+func frozenVersion(p weldr.PackageNEVRA) string {
+	return fmt.Sprintf("%s.%s", p.EVR(), p.Arch)
+}
+
+// frozenMatch returns true if the frozen version is the package's EVR, with or without its arch
+func frozenMatch(version string, p weldr.PackageNEVRA) bool {
+	version = strings.TrimPrefix(version, "0:")
+	return version == p.EVR() || version == frozenVersion(p)
+}
+
+// depsolvedFrozen is the part of the depsolve response used to verify a frozen blueprint
+type depsolvedFrozen struct {
+	Dependencies []weldr.PackageNEVRA
+}
+
+// frozenChanges returns the differences between the frozen packages and modules and
+// the depsolved or built packages. Every version of a frozen package has to match,
+// the other dependencies are not in the frozen blueprint and are not checked.
+func frozenChanges(bp weldr.Blueprint, deps []weldr.PackageNEVRA) []string {
+	frozen := make(map[string]string)
+	built := make(map[string]string)
+	for _, p := range append(append([]weldr.Package{}, bp.Packages...), bp.Modules...) {
+		frozen[p.Name] = p.Version
+		var versions []string
+		match := true
+		for _, d := range deps {
+			if d.Name != p.Name {
+				continue
+			}
+			versions = append(versions, frozenVersion(d))
+			if !frozenMatch(p.Version, d) {
+				match = false
+			}
+		}
+		if len(versions) == 0 {
+			continue
+		}
+		if match {
+			built[p.Name] = p.Version
+		} else {
+			built[p.Name] = strings.Join(versions, ", ")
+		}
+	}
+	return root.CompareVersions(frozen, built)
+}
+
+// verifyFrozen depsolves the pushed frozen blueprint and checks its packages
+// The differences are printed and it returns an error if there are any.
+func verifyFrozen(cmd *cobra.Command, filename string, bp weldr.Blueprint) error {
+	name := frozenName(bp.Name)
+	bps, apiErrors, err := root.Client.DepsolveBlueprints([]string{name})
+	if err != nil {
+		return root.ExecutionError(cmd, "Frozen Error: %s", err)
+	}
+	if len(apiErrors) > 0 {
+		rcErr := root.ExecutionErrors(cmd, apiErrors)
+		root.PrintBlueprintSuggestions(apiErrors, []string{name})
+		return rcErr
+	}
+	if len(bps) != 1 {
+		return root.ExecutionError(cmd, "Frozen Error: missing blueprint %s", name)
+	}
+	data, err := json.Marshal(bps[0])
+	if err != nil {
+		return root.ExecutionError(cmd, "Frozen Error: %s", err)
+	}
+	var parts depsolvedFrozen
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return root.ExecutionError(cmd, "Frozen Error: decoding depsolved blueprint: %s", err)
+	}
+
+	changes := frozenChanges(bp, parts.Dependencies)
+	if len(changes) == 0 {
+		fmt.Printf("%s packages match %s\n", name, filename)
+		return nil
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	return root.ExecutionError(cmd, "Frozen Error: the packages in %s do not match %s, the compose was not started", name, filename)
+}
+
 func start(cmd *cobra.Command, args []string) error {
+	var frozen weldr.Blueprint
+	if len(frozenFile) > 0 {
+		var err error
+		frozen, err = readFrozen(frozenFile)
+		if err != nil {
+			return root.ExecutionError(cmd, "Frozen Error: %s: %s", frozenFile, err)
+		}
+		apiErrors, err := pushFrozen(frozenFile, frozen)
+		if err != nil {
+			return root.ExecutionError(cmd, "Frozen Error: %s", err)
+		}
+		if len(apiErrors) > 0 {
+			return root.ExecutionErrors(cmd, apiErrors)
+		}
+		if err := verifyFrozen(cmd, frozenFile, frozen); err != nil {
+			return err
+		}
+		args = append([]string{frozenName(frozen.Name)}, args...)
+	}
+
 	var resp *weldr.APIResponse
 	var uuid string
 	var err error
@@ -50,9 +215,21 @@ func start(cmd *cobra.Command, args []string) error {
 		return root.ExecutionError(cmd, "Push TOML Error: %s", err)
 	}
 	if resp != nil && !resp.Status {
-		return root.ExecutionErrors(cmd, resp.Errors)
+		rcErr := root.ExecutionErrors(cmd, resp.Errors)
+		root.PrintBlueprintSuggestions(resp.Errors, args[:1])
+		return rcErr
 	}
 
 	fmt.Printf("Compose %s added to the queue\n", uuid)
+	if len(frozenFile) > 0 {
+		fmt.Printf("Building %s from %s as %s\n", frozen.Name, frozenFile, frozenName(frozen.Name))
+		// The compose has been queued, not being able to record it is not an error
+		filename, err := writeFrozenCompose(uuid, frozenFile, frozen)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: cannot record the frozen blueprint of %s: %s\n", uuid, err)
+			return nil
+		}
+		fmt.Printf("Recorded in %s, check the built packages with: composer-cli compose freeze-check %s\n", filename, uuid)
+	}
 	return nil
 }
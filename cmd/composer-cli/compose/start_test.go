@@ -9,12 +9,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
 )
 
 func TestCmdComposeStart(t *testing.T) {
@@ -150,3 +152,145 @@ aws_secret_key = "AWS Secret Key"
 	assert.Equal(t, "application/json", mc.Req.Header.Get("Content-Type"))
 	assert.Equal(t, "/api/v1/compose", mc.Req.URL.Path)
 }
+
+const frozenTestTOML = `name = "http-server"
+description = "An example http server"
+version = "0.0.2"
+
+[[packages]]
+name = "tmux"
+version = "3.1c-2.fc34.x86_64"
+
+[[packages]]
+name = "vim-minimal"
+version = "2:8.2.3318-1.fc34.x86_64"
+`
+
+// frozenTestServer depsolves the frozen blueprint to the packages, and records the
+// pushed blueprint and the compose request.
+func frozenTestServer(packages string, pushed, started *string) func(request *http.Request) (*http.Response, error) {
+	return func(request *http.Request) (*http.Response, error) {
+		var body string
+		switch {
+		case request.URL.Path == "/api/v1/blueprints/new":
+			data, _ := ioutil.ReadAll(request.Body)
+			*pushed = string(data)
+			body = `{"status": true}`
+		case request.URL.Path == "/api/v1/compose":
+			data, _ := ioutil.ReadAll(request.Body)
+			*started = string(data)
+			body = `{"build_id": "876b2946-16cd-4f38-bace-0cdd0093d112", "status": true}`
+		case request.URL.Path == "/api/v1/blueprints/depsolve/http-server-frozen":
+			body = `{"blueprints": [{"blueprint": {"name": "http-server-frozen", "version": "0.0.2"},
+				"dependencies": [` + packages + `]}], "errors": []}`
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+func runStartFrozen(t *testing.T, packages string) (string, string, string, string, error) {
+	return runStartFrozenFile(t, ".frozen.toml", frozenTestTOML, packages)
+}
+
+func runStartFrozenFile(t *testing.T, suffix, frozen, packages string) (string, string, string, string, error) {
+	tmpBp, err := ioutil.TempFile("", "http-server-*"+suffix)
+	require.Nil(t, err)
+	defer os.Remove(tmpBp.Name())
+	_, err = tmpBp.Write([]byte(frozen))
+	require.Nil(t, err)
+	tmpBp.Close()
+
+	var pushed, started string
+	size = 0
+	defer func() { frozenFile = "" }()
+	stdout, stderr, err := executeCmd(t, frozenTestServer(packages, &pushed, &started),
+		"compose", "start", "--frozen", tmpBp.Name(), "qcow2")
+	return stdout, stderr, pushed, started, err
+}
+
+const frozenTestPackages = `
+		{"name": "tmux", "epoch": 0, "version": "3.1c", "release": "2.fc34", "arch": "x86_64"},
+		{"name": "vim-minimal", "epoch": 2, "version": "8.2.3318", "release": "1.fc34", "arch": "x86_64"}`
+
+func TestCmdComposeStartFrozen(t *testing.T) {
+	inTempDir(t, func() {
+		stdout, stderr, pushed, started, err := runStartFrozen(t, frozenTestPackages)
+		require.Nil(t, err)
+		assert.Equal(t, "", stderr)
+		assert.Contains(t, stdout, "http-server-frozen packages match ")
+		assert.Contains(t, stdout, "Compose 876b2946-16cd-4f38-bace-0cdd0093d112 added to the queue\n")
+		assert.Contains(t, stdout, "Recorded in 876b2946-16cd-4f38-bace-0cdd0093d112-frozen.json, "+
+			"check the built packages with: composer-cli compose freeze-check 876b2946-16cd-4f38-bace-0cdd0093d112\n")
+		assert.Contains(t, pushed, `name = "http-server-frozen"`)
+		assert.Contains(t, pushed, `description = "http-server v0.0.2 frozen in http-server-`)
+		assert.Equal(t, `{"blueprint_name":"http-server-frozen","compose_type":"qcow2","branch":"master","size":0}`, started)
+
+		bp, filename, err := readFrozenCompose("876b2946-16cd-4f38-bace-0cdd0093d112")
+		require.Nil(t, err)
+		assert.Equal(t, "http-server", bp.Name)
+		assert.True(t, filepath.IsAbs(filename))
+		assert.Contains(t, filename, "http-server-")
+		assert.Equal(t, []weldr.Package{
+			{Name: "tmux", Version: "3.1c-2.fc34.x86_64"},
+			{Name: "vim-minimal", Version: "2:8.2.3318-1.fc34.x86_64"},
+		}, bp.Packages)
+	})
+}
+
+func TestCmdComposeStartFrozenJSON(t *testing.T) {
+	inTempDir(t, func() {
+		frozen := `{"name": "http-server", "version": "0.0.2", "packages": [
+			{"name": "tmux", "version": "3.1c-2.fc34.x86_64"},
+			{"name": "vim-minimal", "version": "2:8.2.3318-1.fc34.x86_64"}]}`
+		stdout, stderr, pushed, _, err := runStartFrozenFile(t, ".frozen.json", frozen, frozenTestPackages)
+		require.Nil(t, err)
+		assert.Equal(t, "", stderr)
+		assert.Contains(t, stdout, "http-server-frozen packages match ")
+		assert.Contains(t, pushed, `name = "http-server-frozen"`)
+	})
+}
+
+func TestCmdComposeStartFrozenMismatch(t *testing.T) {
+	stdout, stderr, _, started, err := runStartFrozen(t, `
+		{"name": "tmux", "epoch": 0, "version": "3.2a", "release": "1.fc34", "arch": "x86_64"},
+		{"name": "vim-minimal", "epoch": 2, "version": "8.2.3318", "release": "1.fc34", "arch": "x86_64"},
+		{"name": "libevent", "epoch": 0, "version": "2.1.12", "release": "3.fc34", "arch": "x86_64"}`)
+	require.NotNil(t, err)
+	assert.Equal(t, "changed tmux 3.1c-2.fc34.x86_64 -> 3.2a-1.fc34.x86_64\n", stdout)
+	assert.Contains(t, stderr, "ERROR: Frozen Error: the packages in http-server-frozen do not match ")
+	assert.Equal(t, "", started)
+}
+
+func TestCmdComposeStartFrozenMissing(t *testing.T) {
+	stdout, stderr, _, started, err := runStartFrozen(t, `
+		{"name": "tmux", "epoch": 0, "version": "3.1c", "release": "2.fc34", "arch": "x86_64"}`)
+	require.NotNil(t, err)
+	assert.Equal(t, "removed vim-minimal 2:8.2.3318-1.fc34.x86_64\n", stdout)
+	assert.Contains(t, stderr, "the compose was not started")
+	assert.Equal(t, "", started)
+}
+
+func TestFrozenMatch(t *testing.T) {
+	p := weldr.PackageNEVRA{Name: "tmux", Version: "3.1c", Release: "2.fc34", Arch: "x86_64"}
+	assert.True(t, frozenMatch("3.1c-2.fc34.x86_64", p))
+	assert.True(t, frozenMatch("3.1c-2.fc34", p))
+	assert.True(t, frozenMatch("0:3.1c-2.fc34", p))
+	assert.False(t, frozenMatch("3.1c-2.fc34.i686", p))
+	assert.False(t, frozenMatch("3.2a-1.fc34", p))
+}
+
+func TestReadFrozenGlob(t *testing.T) {
+	tmpBp, err := ioutil.TempFile("", "test-bp-*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpBp.Name())
+	_, err = tmpBp.Write([]byte("name = \"glob\"\n[[packages]]\nname = \"tmux\"\nversion = \"3.*\"\n"))
+	require.Nil(t, err)
+	tmpBp.Close()
+
+	_, err = readFrozen(tmpBp.Name())
+	require.NotNil(t, err)
+	assert.Equal(t, "not a frozen blueprint, tmux has version '3.*'", err.Error())
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/osbuild/weldr-client/v2/cmd/composer-cli/root"
+	"github.com/osbuild/weldr-client/v2/weldr"
 )
 
 var (
@@ -30,21 +31,6 @@ func init() {
 	projectsCmd.AddCommand(depsolveCmd)
 }
 
-type project struct {
-	Name    string
-	Epoch   int
-	Version string
-	Release string
-	Arch    string
-}
-
-func (p project) String() string {
-	if p.Epoch == 0 {
-		return fmt.Sprintf("%s-%s-%s.%s", p.Name, p.Version, p.Release, p.Arch)
-	}
-	return fmt.Sprintf("%d:%s-%s-%s.%s", p.Epoch, p.Name, p.Version, p.Release, p.Arch)
-}
-
 func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
 	names := root.GetCommaArgs(args)
 
@@ -54,6 +40,7 @@ func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
 	}
 	if len(errors) > 0 {
 		rcErr = root.ExecutionErrors(cmd, errors)
+		root.PrintSuggestions(errors, distro)
 	}
 
 	// Encode it using json
@@ -64,7 +51,7 @@ func depsolve(cmd *cobra.Command, args []string) (rcErr error) {
 	}
 
 	// Decode the dependencies
-	var projects []project
+	var projects []weldr.PackageNEVRA
 	if err = json.Unmarshal(data.Bytes(), &projects); err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: decoding deps: %s\n", err)
 		return root.ExecutionError(cmd, "")
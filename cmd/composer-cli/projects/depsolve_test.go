@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -375,3 +376,74 @@ func TestCmdProjectsDepsolveUnknownJSON(t *testing.T) {
 	assert.Equal(t, []byte(""), stderr)
 	assert.Equal(t, "GET", mc.Req.Method)
 }
+
+func TestCmdProjectsDepsolveSuggestions(t *testing.T) {
+	// Test the "projects depsolve" command suggesting similar package names
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		path := strings.TrimPrefix(request.URL.Path, "/api/v1")
+		var body string
+		status := 200
+		switch path {
+		case "/projects/depsolve/bash,vim-enchanced,openssl-dev":
+			status = 400
+			body = `{"errors": [{"id": "ProjectsError",
+				"msg": "BadRequest: DNF error occured: MarkingErrors: Error occurred when marking packages for installation: Problems in request:\nmissing packages: vim-enchanced, openssl-dev"}],
+				"status": false}`
+		case "/projects/list":
+			body = `{"projects": [{"name": "bash"}, {"name": "openssl-devel"}, {"name": "vim-enhanced"}, {"name": "vim-minimal"}],
+				"total": 4, "offset": 0, "limit": 4}`
+		case "/projects/source/list":
+			body = `{"sources": ["fedora", "updates"]}`
+		case "/projects/source/info/fedora,updates":
+			body = `{"sources": {
+				"fedora": {"id": "fedora", "url": "https://example.com/fedora/os"},
+				"updates": {"id": "updates", "url": "https://example.com/updates/"}}, "errors": []}`
+		case "/projects/depsolve/vim-enhanced":
+			body = `{"projects": [{"name": "vim-enhanced", "remote_location": "https://example.com/updates/Packages/v/vim-enhanced.rpm"}]}`
+		case "/projects/depsolve/openssl-devel":
+			body = `{"projects": [{"name": "openssl-devel", "remote_location": "https://example.com/fedora/os/Packages/o/openssl-devel.rpm"}]}`
+		default:
+			body = `{"projects": []}`
+		}
+		return &http.Response{
+			Request:    request,
+			StatusCode: status,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	})
+
+	cmd, out, err := root.ExecuteTest("projects", "depsolve", "--distro=", "bash,vim-enchanced,openssl-dev")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.NotNil(t, err)
+	assert.Equal(t, root.ExecutionError(cmd, ""), err)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), stdout)
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stderr), `vim-enchanced was not found, did you mean:
+    vim-enhanced (updates)
+openssl-dev was not found, did you mean:
+    openssl-devel (fedora)
+`)
+}
+
+func TestCmdProjectsDepsolveEpoch(t *testing.T) {
+	// The epoch goes before the version, not before the name
+	json := `{"projects": [{"name": "shadow-utils", "epoch": 2, "version": "4.9", "release": "8.fc35", "arch": "x86_64"}]}`
+	root.SetupCmdTest(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(json))),
+		}, nil
+	})
+
+	_, out, err := root.ExecuteTest("projects", "depsolve", "--distro=", "shadow-utils")
+	require.NotNil(t, out)
+	defer out.Close()
+	require.Nil(t, err)
+	stdout, err := ioutil.ReadAll(out.Stdout)
+	assert.Nil(t, err)
+	assert.Equal(t, "    shadow-utils-2:4.9-8.fc35.x86_64\n", string(stdout))
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+// FormatFromFilename returns the blueprint format for the file's extension
+// Anything that is not .json, .yaml, or .yml is treated as TOML.
+func FormatFromFilename(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return "toml"
+}
+
+// ReadBlueprintFile reads a blueprint file in any of the blueprint formats
+// It returns an error if the blueprint does not have a name.
+func ReadBlueprintFile(filename string) (weldr.Blueprint, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return weldr.Blueprint{}, err
+	}
+	bp, err := weldr.NewBlueprint(string(data), FormatFromFilename(filename))
+	if err != nil {
+		return weldr.Blueprint{}, err
+	}
+	if len(bp.Name) == 0 {
+		return weldr.Blueprint{}, fmt.Errorf("missing blueprint name")
+	}
+	return bp, nil
+}
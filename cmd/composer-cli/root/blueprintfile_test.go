@@ -0,0 +1,41 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatFromFilename(t *testing.T) {
+	assert.Equal(t, "toml", FormatFromFilename("bp.toml"))
+	assert.Equal(t, "json", FormatFromFilename("bp.JSON"))
+	assert.Equal(t, "yaml", FormatFromFilename("/tmp/bp.yml"))
+	assert.Equal(t, "yaml", FormatFromFilename("bp.yaml"))
+	assert.Equal(t, "toml", FormatFromFilename("bp"))
+}
+
+func TestReadBlueprintFile(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "test-bp-")
+	require.Nil(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	filename := filepath.Join(tmpdir, "bp.yaml")
+	require.Nil(t, ioutil.WriteFile(filename, []byte("name: read-bp\npackages:\n  - name: bash\n    version: 5.1.8-1.fc34.x86_64\n"), 0644))
+	bp, err := ReadBlueprintFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, "read-bp", bp.Name)
+	assert.Equal(t, "5.1.8-1.fc34.x86_64", bp.Packages[0].Version)
+
+	filename = filepath.Join(tmpdir, "noname.json")
+	require.Nil(t, ioutil.WriteFile(filename, []byte(`{"description": "no name"}`), 0644))
+	_, err = ReadBlueprintFile(filename)
+	assert.EqualError(t, err, "missing blueprint name")
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+// +build ignore
+
+// gen_spdx writes the SPDX license and exception identifiers to spdx_list.go
+// It is run by go generate and downloads the lists from the SPDX license-list-data
+// repository, -dir reads licenses.json and exceptions.json from a local copy of its
+// json directory instead. The generated file is committed so that building does
+// not need the network.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const listURL = "https://raw.githubusercontent.com/spdx/license-list-data/v%s/json/%s"
+
+// readList returns the contents of one of the SPDX json files
+func readList(dir, version, name string) ([]byte, error) {
+	if len(dir) > 0 {
+		return ioutil.ReadFile(filepath.Join(dir, name))
+	}
+	resp, err := http.Get(fmt.Sprintf(listURL, version, name))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Request.URL, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// writeMap writes a map of the lower-case identifiers to their canonical case
+func writeMap(src *bytes.Buffer, comment, name string, ids []string) {
+	sort.Slice(ids, func(i, j int) bool {
+		return strings.ToLower(ids[i]) < strings.ToLower(ids[j])
+	})
+	fmt.Fprintf(src, "// %s\n", comment)
+	fmt.Fprintf(src, "var %s = map[string]string{\n", name)
+	for _, id := range ids {
+		fmt.Fprintf(src, "%q: %q,\n", strings.ToLower(id), id)
+	}
+	src.WriteString("}\n")
+}
+
+func exitError(err error) {
+	fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+	os.Exit(1)
+}
+
+func main() {
+	version := flag.String("version", "3.25.0", "Version of the SPDX license list")
+	dir := flag.String("dir", "", "Directory with licenses.json and exceptions.json")
+	flag.Parse()
+
+	var licenses struct {
+		Version  string `json:"licenseListVersion"`
+		Licenses []struct {
+			ID string `json:"licenseId"`
+		} `json:"licenses"`
+	}
+	data, err := readList(*dir, *version, "licenses.json")
+	if err != nil {
+		exitError(err)
+	}
+	if err := json.Unmarshal(data, &licenses); err != nil {
+		exitError(fmt.Errorf("licenses.json: %s", err))
+	}
+
+	var exceptions struct {
+		Exceptions []struct {
+			ID string `json:"licenseExceptionId"`
+		} `json:"exceptions"`
+	}
+	data, err = readList(*dir, *version, "exceptions.json")
+	if err != nil {
+		exitError(err)
+	}
+	if err := json.Unmarshal(data, &exceptions); err != nil {
+		exitError(fmt.Errorf("exceptions.json: %s", err))
+	}
+
+	var licenseIDs, exceptionIDs []string
+	for _, l := range licenses.Licenses {
+		licenseIDs = append(licenseIDs, l.ID)
+	}
+	for _, e := range exceptions.Exceptions {
+		exceptionIDs = append(exceptionIDs, e.ID)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by gen_spdx.go from the SPDX license list %s; DO NOT EDIT.\n\n", licenses.Version)
+	src.WriteString("package root\n\n")
+	writeMap(&src, "spdxLicenseIDs maps the lower-case SPDX license identifiers to their canonical case",
+		"spdxLicenseIDs", licenseIDs)
+	src.WriteString("\n")
+	writeMap(&src, "spdxExceptionIDs maps the lower-case SPDX license exception identifiers to their canonical case",
+		"spdxExceptionIDs", exceptionIDs)
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		exitError(err)
+	}
+	if err := ioutil.WriteFile("spdx_list.go", out, 0644); err != nil {
+		exitError(err)
+	}
+}
@@ -0,0 +1,411 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+//go:generate go run gen_spdx.go
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+// PackageDetails is a package and the details about it from its project
+type PackageDetails struct {
+	weldr.PackageNEVRA
+	License     string
+	Homepage    string
+	UpstreamVCS string
+	SourceRef   string
+}
+
+// findBuild returns the project's build of the package
+func findBuild(p weldr.PackageNEVRA, builds []weldr.ProjectBuildV0) (weldr.ProjectBuildV0, bool) {
+	for _, b := range builds {
+		if b.Arch == p.Arch && weldr.CompareEVR(b.NEVRA(p.Name), p) == 0 {
+			return b, true
+		}
+	}
+	return weldr.ProjectBuildV0{}, false
+}
+
+// serverValue returns the value, or an empty string if it is the server's placeholder for it
+func serverValue(value, placeholder string) string {
+	if value == placeholder {
+		return ""
+	}
+	return value
+}
+
+// LookupPackages returns the packages, sorted by name, with the details from their projects
+// The license is normalized. Packages that the server does not know about are returned
+// without any details.
+func LookupPackages(pkgs []weldr.PackageNEVRA, distro string) ([]PackageDetails, error) {
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	sorted := append([]weldr.PackageNEVRA{}, pkgs...)
+	weldr.SortNEVRAs(sorted)
+	var names []string
+	seen := make(map[string]bool)
+	for _, p := range sorted {
+		if !seen[p.Name] {
+			names = append(names, p.Name)
+			seen[p.Name] = true
+		}
+	}
+	projects, resp, err := Client.ProjectsInfo(names, distro)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil && !resp.Status {
+		for _, e := range resp.Errors {
+			if e.ID != "UnknownProject" {
+				return nil, fmt.Errorf("%s: %s", e.ID, e.Msg)
+			}
+		}
+	}
+	byName := make(map[string]weldr.ProjectV0)
+	for _, p := range projects {
+		byName[p.Name] = p
+	}
+
+	var result []PackageDetails
+	for _, p := range sorted {
+		d := PackageDetails{PackageNEVRA: p}
+		if proj, ok := byName[p.Name]; ok {
+			d.Homepage = proj.Homepage
+			d.UpstreamVCS = serverValue(proj.UpstreamVCS, "UPSTREAM_VCS")
+			if b, ok := findBuild(p, proj.Builds); ok {
+				d.License = NormalizeLicense(b.Source.License)
+				d.SourceRef = serverValue(b.Source.SourceRef, "SOURCE_REF")
+			}
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// legacyLicenses maps the old Fedora license names to their SPDX identifiers
+var legacyLicenses = map[string]string{
+	"AGPLv3":        "AGPL-3.0-only",
+	"AGPLv3+":       "AGPL-3.0-or-later",
+	"ASL 1.1":       "Apache-1.1",
+	"ASL 2.0":       "Apache-2.0",
+	"Artistic 2.0":  "Artistic-2.0",
+	"Boost":         "BSL-1.0",
+	"GPL+":          "GPL-1.0-or-later",
+	"GPLv2":         "GPL-2.0-only",
+	"GPLv2+":        "GPL-2.0-or-later",
+	"GPLv3":         "GPL-3.0-only",
+	"GPLv3+":        "GPL-3.0-or-later",
+	"LGPLv2":        "LGPL-2.0-only",
+	"LGPLv2+":       "LGPL-2.0-or-later",
+	"LGPLv2.1":      "LGPL-2.1-only",
+	"LGPLv2.1+":     "LGPL-2.1-or-later",
+	"LGPLv3":        "LGPL-3.0-only",
+	"LGPLv3+":       "LGPL-3.0-or-later",
+	"MPLv1.1":       "MPL-1.1",
+	"MPLv2.0":       "MPL-2.0",
+	"Public Domain": "LicenseRef-Fedora-Public-Domain",
+	"Python":        "Python-2.0",
+	"zlib":          "Zlib",
+}
+
+// licenseTokens splits a license expression into parentheses, operators, and licenses
+// The operators are upper-cased, the words between them are joined into one license
+// so that names like "ASL 2.0" are kept together.
+func licenseTokens(license string) []string {
+	license = strings.ReplaceAll(license, "(", " ( ")
+	license = strings.ReplaceAll(license, ")", " ) ")
+
+	var tokens, words []string
+	flush := func() {
+		if len(words) > 0 {
+			tokens = append(tokens, strings.Join(words, " "))
+			words = nil
+		}
+	}
+	for _, w := range strings.Fields(license) {
+		switch op := strings.ToUpper(w); op {
+		case "AND", "OR", "WITH":
+			flush()
+			tokens = append(tokens, op)
+		case "(", ")":
+			flush()
+			tokens = append(tokens, w)
+		default:
+			words = append(words, w)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// NormalizeLicense returns the license expression with SPDX operators and identifiers
+// The old Fedora license names are replaced by their SPDX identifiers, the rest are kept.
+func NormalizeLicense(license string) string {
+	var b strings.Builder
+	for i, tok := range licenseTokens(license) {
+		if id, ok := legacyLicenses[tok]; ok {
+			tok = id
+		}
+		if i > 0 && tok != ")" && !strings.HasSuffix(b.String(), "(") {
+			b.WriteString(" ")
+		}
+		b.WriteString(tok)
+	}
+	return b.String()
+}
+
+var licenseRefRegex = regexp.MustCompile(`^LicenseRef-[A-Za-z0-9.-]+$`)
+
+// isSPDXLicense returns true if the license is on the SPDX license list, or is a LicenseRef
+// A license on the list may be followed by + for that version or later.
+func isSPDXLicense(license string) bool {
+	if licenseRefRegex.MatchString(license) {
+		return true
+	}
+	_, ok := spdxLicenseIDs[strings.ToLower(strings.TrimSuffix(license, "+"))]
+	return ok
+}
+
+// isSPDXException returns true if the exception is on the SPDX license exceptions list
+func isSPDXException(exception string) bool {
+	_, ok := spdxExceptionIDs[strings.ToLower(exception)]
+	return ok
+}
+
+// IsSPDXExpression returns true if the license is a well formed SPDX license expression
+// Every license has to be on the SPDX license list or be a LicenseRef, and every
+// exception after WITH has to be on the SPDX license exceptions list.
+func IsSPDXExpression(license string) bool {
+	depth := 0
+	operand := true
+	exception := false
+	prev := ""
+	for _, tok := range licenseTokens(license) {
+		switch tok {
+		case "(":
+			if !operand || prev == "WITH" {
+				return false
+			}
+			depth++
+		case ")":
+			if operand || depth == 0 {
+				return false
+			}
+			depth--
+		case "AND", "OR", "WITH":
+			// WITH follows a single license, not a group or another exception
+			if operand || (tok == "WITH" && (prev == ")" || exception)) {
+				return false
+			}
+			operand = true
+		default:
+			if !operand {
+				return false
+			}
+			exception = prev == "WITH"
+			if exception && !isSPDXException(tok) || !exception && !isSPDXLicense(tok) {
+				return false
+			}
+			operand = false
+		}
+		prev = tok
+	}
+	return !operand && depth == 0
+}
+
+// LicenseRefs returns the LicenseRefs used by the license expression, in order
+func LicenseRefs(license string) []string {
+	var refs []string
+	for _, tok := range licenseTokens(license) {
+		if licenseRefRegex.MatchString(tok) {
+			refs = append(refs, tok)
+		}
+	}
+	return refs
+}
+
+// LicensePolicy lists the licenses that are allowed and denied
+// When Allow is empty every license that is not denied is allowed.
+type LicensePolicy struct {
+	Allow []string `toml:"allow"`
+	Deny  []string `toml:"deny"`
+}
+
+// ReadLicensePolicy reads a TOML policy file with allow and deny lists of licenses
+func ReadLicensePolicy(filename string) (LicensePolicy, error) {
+	var policy LicensePolicy
+	if _, err := toml.DecodeFile(filename, &policy); err != nil {
+		return LicensePolicy{}, err
+	}
+	return policy, nil
+}
+
+// containsLicense returns true if the license is in the list, ignoring case like SPDX
+func containsLicense(licenses []string, license string) bool {
+	for _, l := range licenses {
+		if strings.EqualFold(NormalizeLicense(l), license) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed returns true if the policy allows a single license
+func (p LicensePolicy) allowed(license string) bool {
+	if containsLicense(p.Deny, license) {
+		return false
+	}
+	return len(p.Allow) == 0 || containsLicense(p.Allow, license)
+}
+
+// Permits returns true if the policy allows the license expression
+// One side of an OR has to be allowed, both sides of an AND, and the license of a WITH.
+// An empty or malformed expression is only permitted when the policy has no allow list,
+// and none of the licenses in it are denied.
+func (p LicensePolicy) Permits(license string) bool {
+	e := licenseEvaluator{policy: p, tokens: licenseTokens(license)}
+	ok, err := e.or()
+	if err != nil || e.pos != len(e.tokens) {
+		if len(p.Allow) > 0 || containsLicense(p.Deny, license) {
+			return false
+		}
+		for _, tok := range e.tokens {
+			if containsLicense(p.Deny, tok) {
+				return false
+			}
+		}
+		return true
+	}
+	return ok
+}
+
+// licenseEvaluator checks a license expression against a policy
+// OR has the lowest precedence, then AND, then WITH.
+type licenseEvaluator struct {
+	policy LicensePolicy
+	tokens []string
+	pos    int
+}
+
+func (e *licenseEvaluator) next() string {
+	if e.pos >= len(e.tokens) {
+		return ""
+	}
+	return e.tokens[e.pos]
+}
+
+func (e *licenseEvaluator) or() (bool, error) {
+	ok, err := e.and()
+	for err == nil && e.next() == "OR" {
+		e.pos++
+		var right bool
+		right, err = e.and()
+		ok = ok || right
+	}
+	return ok, err
+}
+
+func (e *licenseEvaluator) and() (bool, error) {
+	ok, err := e.with()
+	for err == nil && e.next() == "AND" {
+		e.pos++
+		var right bool
+		right, err = e.with()
+		ok = ok && right
+	}
+	return ok, err
+}
+
+func (e *licenseEvaluator) with() (bool, error) {
+	ok, err := e.license()
+	if err == nil && e.next() == "WITH" {
+		e.pos += 2
+		if e.pos > len(e.tokens) {
+			return false, fmt.Errorf("missing exception")
+		}
+	}
+	return ok, err
+}
+
+func (e *licenseEvaluator) license() (bool, error) {
+	switch tok := e.next(); tok {
+	case "":
+		return false, fmt.Errorf("missing license")
+	case "(":
+		e.pos++
+		ok, err := e.or()
+		if err != nil {
+			return false, err
+		}
+		if e.next() != ")" {
+			return false, fmt.Errorf("missing )")
+		}
+		e.pos++
+		return ok, nil
+	case ")", "AND", "OR", "WITH":
+		return false, fmt.Errorf("unexpected %s", tok)
+	default:
+		e.pos++
+		return e.policy.allowed(tok), nil
+	}
+}
+
+// LicenseGroup is a license and the packages that use it
+type LicenseGroup struct {
+	License  string
+	Packages []weldr.PackageNEVRA
+}
+
+// GroupByLicense returns the packages grouped by license, sorted by license
+// Packages without a license are in the last group, with an empty license.
+func GroupByLicense(pkgs []PackageDetails) []LicenseGroup {
+	byLicense := make(map[string][]weldr.PackageNEVRA)
+	for _, p := range pkgs {
+		byLicense[p.License] = append(byLicense[p.License], p.PackageNEVRA)
+	}
+	var groups []LicenseGroup
+	for l, p := range byLicense {
+		groups = append(groups, LicenseGroup{License: l, Packages: p})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].License) == 0 || len(groups[j].License) == 0 {
+			return len(groups[j].License) == 0 && len(groups[i].License) > 0
+		}
+		return groups[i].License < groups[j].License
+	})
+	return groups
+}
+
+// PrintLicenseReport prints the packages grouped by license
+// When there is a policy the groups it does not permit are marked, and the number of
+// packages in them is returned.
+func PrintLicenseReport(pkgs []PackageDetails, policy *LicensePolicy) int {
+	var violations int
+	for _, g := range GroupByLicense(pkgs) {
+		license := g.License
+		if len(license) == 0 {
+			license = "unknown license"
+		}
+		if policy != nil && !policy.Permits(g.License) {
+			fmt.Printf("%s: %d packages, not allowed\n", license, len(g.Packages))
+			violations += len(g.Packages)
+		} else {
+			fmt.Printf("%s: %d packages\n", license, len(g.Packages))
+		}
+		for _, p := range g.Packages {
+			fmt.Printf("    %s\n", p)
+		}
+	}
+	return violations
+}
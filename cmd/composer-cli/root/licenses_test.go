@@ -0,0 +1,113 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+func TestNormalizeLicense(t *testing.T) {
+	assert.Equal(t, "MIT", NormalizeLicense("MIT"))
+	assert.Equal(t, "GPL-2.0-or-later", NormalizeLicense("GPLv2+"))
+	assert.Equal(t, "Apache-2.0 AND MIT", NormalizeLicense("ASL 2.0 and MIT"))
+	assert.Equal(t, "GPL-1.0-or-later OR Artistic", NormalizeLicense("GPL+ or Artistic"))
+	assert.Equal(t, "(GPL-2.0-or-later OR LGPL-3.0-or-later) AND LicenseRef-Fedora-Public-Domain",
+		NormalizeLicense("( GPLv2+  or LGPLv3+) and Public Domain"))
+	assert.Equal(t, "GPL-2.0-only WITH Classpath-exception-2.0", NormalizeLicense("GPL-2.0-only WITH Classpath-exception-2.0"))
+	assert.Equal(t, "Copyright only", NormalizeLicense("Copyright only"))
+	assert.Equal(t, "", NormalizeLicense(""))
+}
+
+func TestIsSPDXExpression(t *testing.T) {
+	valid := []string{"MIT", "mit", "GPL-2.0-or-later", "GPL-2.0+", "Apache-2.0+", "MIT OR Apache-2.0", "(MIT OR BSD-3-Clause) AND GPL-2.0-only",
+		"GPL-2.0-only WITH Classpath-exception-2.0", "LicenseRef-Fedora-Public-Domain", "Vim and MIT"}
+	for _, l := range valid {
+		assert.True(t, IsSPDXExpression(l), l)
+	}
+	invalid := []string{"", "Copyright only", "MIT OR", "(MIT", "MIT)", "AND MIT", "Public Domain", "MIT () OR BSD",
+		"GPLv2+", "Freeware", "MIT AND Freeware", "GPL-2.0-only WITH MIT", "MIT WITH Classpath-exception-2.0 WITH Classpath-exception-2.0",
+		"(MIT OR Zlib) WITH Classpath-exception-2.0", "Classpath-exception-2.0", "LicenseRef-Fedora Public"}
+	for _, l := range invalid {
+		assert.False(t, IsSPDXExpression(l), l)
+	}
+}
+
+func TestLicenseRefs(t *testing.T) {
+	assert.Equal(t, []string{"LicenseRef-Fedora-Public-Domain", "LicenseRef-Vim-like"},
+		LicenseRefs("(MIT OR LicenseRef-Fedora-Public-Domain) AND LicenseRef-Vim-like"))
+	assert.Nil(t, LicenseRefs("MIT AND Zlib"))
+}
+
+func TestLicensePolicyPermits(t *testing.T) {
+	policy := LicensePolicy{
+		Allow: []string{"MIT", "GPLv2+", "apache-2.0", "BSD-3-Clause"},
+		Deny:  []string{"GPL-3.0-only"},
+	}
+	assert.True(t, policy.Permits("MIT"))
+	assert.True(t, policy.Permits("GPL-2.0-or-later"))
+	assert.True(t, policy.Permits("Apache-2.0 AND MIT"))
+	assert.True(t, policy.Permits("GPL-3.0-only OR MIT"))
+	assert.True(t, policy.Permits("(GPL-3.0-only OR BSD-3-Clause) AND MIT"))
+	assert.True(t, policy.Permits("GPL-2.0-or-later WITH Classpath-exception-2.0"))
+	assert.False(t, policy.Permits("GPL-3.0-only"))
+	assert.False(t, policy.Permits("GPL-3.0-only AND MIT"))
+	assert.False(t, policy.Permits("Zlib"))
+	assert.False(t, policy.Permits(""))
+	assert.False(t, policy.Permits("MIT AND"))
+
+	// Without an allow list only the denied licenses are not permitted
+	policy = LicensePolicy{Deny: []string{"GPL-3.0-only", "Copyright only"}}
+	assert.True(t, policy.Permits("Zlib"))
+	assert.True(t, policy.Permits(""))
+	assert.True(t, policy.Permits("MIT AND"))
+	assert.False(t, policy.Permits("GPL-3.0-only"))
+	assert.False(t, policy.Permits("Copyright only"))
+
+	// A malformed expression with a denied license is not permitted
+	assert.False(t, policy.Permits("GPL-3.0-only AND"))
+	assert.False(t, policy.Permits("(MIT OR GPL-3.0-only"))
+	assert.False(t, policy.Permits("GPL-3.0-only WITH"))
+	assert.False(t, policy.Permits("OR Copyright only"))
+}
+
+func TestReadLicensePolicy(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "test-policy-*.toml")
+	require.Nil(t, err)
+	defer os.Remove(tmpFile.Name())
+	_, err = tmpFile.Write([]byte("allow = [\"MIT\", \"ASL 2.0\"]\ndeny = [\"GPLv3\"]\n"))
+	require.Nil(t, err)
+	tmpFile.Close()
+
+	policy, err := ReadLicensePolicy(tmpFile.Name())
+	require.Nil(t, err)
+	assert.Equal(t, LicensePolicy{Allow: []string{"MIT", "ASL 2.0"}, Deny: []string{"GPLv3"}}, policy)
+	assert.True(t, policy.Permits("Apache-2.0"))
+	assert.False(t, policy.Permits("GPL-3.0-only"))
+}
+
+func TestGroupByLicense(t *testing.T) {
+	bash := weldr.PackageNEVRA{Name: "bash", Version: "5.1.8", Release: "1.fc34", Arch: "x86_64"}
+	glibc := weldr.PackageNEVRA{Name: "glibc", Version: "2.33", Release: "5.fc34", Arch: "x86_64"}
+	tmux := weldr.PackageNEVRA{Name: "tmux", Version: "3.2a", Release: "1.fc34", Arch: "x86_64"}
+	tzdata := weldr.PackageNEVRA{Name: "tzdata", Version: "2021a", Release: "1.fc34", Arch: "noarch"}
+	groups := GroupByLicense([]PackageDetails{
+		{PackageNEVRA: bash, License: "GPL-3.0-or-later"},
+		{PackageNEVRA: glibc, License: "LGPL-2.1-or-later"},
+		{PackageNEVRA: tmux},
+		{PackageNEVRA: tzdata, License: "GPL-3.0-or-later"},
+	})
+	assert.Equal(t, []LicenseGroup{
+		{License: "GPL-3.0-or-later", Packages: []weldr.PackageNEVRA{bash, tzdata}},
+		{License: "LGPL-2.1-or-later", Packages: []weldr.PackageNEVRA{glibc}},
+		{License: "", Packages: []weldr.PackageNEVRA{tmux}},
+	}, groups)
+}
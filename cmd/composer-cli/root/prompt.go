@@ -0,0 +1,102 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Prompt prints a message and returns the line entered by the user
+// The line is read one byte at a time so that repeated prompts do not lose any
+// input to buffering. Leading and trailing whitespace is removed.
+func Prompt(msg string) (string, error) {
+	fmt.Print(msg)
+	line, err := readLine()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readLine reads a line from stdin without the trailing newline
+// It reads one byte at a time so that none of the following input is buffered.
+func readLine() (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF {
+			if len(line) == 0 {
+				return "", err
+			}
+			break
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
+
+// Confirm asks the user a yes or no question
+// It returns true if the answer starts with y or Y, and false for anything else,
+// including errors reading the answer.
+func Confirm(msg string) bool {
+	answer, err := Prompt(msg + " [y/N] ")
+	if err != nil {
+		fmt.Println()
+		return false
+	}
+	return strings.HasPrefix(strings.ToLower(answer), "y")
+}
+
+// isTerminal returns true if the file is a character device, like a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// PromptPassword asks for a password without echoing it to the terminal
+// When stdin is a terminal the password is asked for twice to make sure it was
+// typed correctly. When it is not, eg. it is piped in, a single line is read.
+// If echo cannot be turned off, eg. on platforms other than Linux, a warning is
+// printed before asking for it.
+func PromptPassword(msg string) (string, error) {
+	restore, err := disableEcho(os.Stdin)
+	if err != nil {
+		if !isTerminal(os.Stdin) {
+			return readLine()
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: cannot hide the password, it will be visible as it is typed: %s\n", err)
+		restore = func() {}
+	}
+	defer restore()
+
+	// The prompts are written to stderr so that stdout can be redirected
+	fmt.Fprint(os.Stderr, msg)
+	password, err := readLine()
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Confirm "+strings.ToLower(msg[:1])+msg[1:])
+	confirm, err := readLine()
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if password != confirm {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	return password, nil
+}
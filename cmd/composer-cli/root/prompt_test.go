@@ -0,0 +1,94 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrompt(t *testing.T) {
+	restore, err := SetupStdin("first answer\n  second  \nlast")
+	require.Nil(t, err)
+	defer restore()
+
+	out, err := NewOutputCapture()
+	require.Nil(t, err)
+	defer out.Close()
+
+	answer, err := Prompt("? ")
+	require.Nil(t, err)
+	assert.Equal(t, "first answer", answer)
+	answer, err = Prompt("? ")
+	require.Nil(t, err)
+	assert.Equal(t, "second", answer)
+	answer, err = Prompt("? ")
+	require.Nil(t, err)
+	assert.Equal(t, "last", answer)
+	_, err = Prompt("? ")
+	assert.NotNil(t, err)
+}
+
+func TestConfirm(t *testing.T) {
+	restore, err := SetupStdin("y\nYes\nn\n\nnope\n")
+	require.Nil(t, err)
+	defer restore()
+
+	out, err := NewOutputCapture()
+	require.Nil(t, err)
+	defer out.Close()
+
+	assert.True(t, Confirm("Continue?"))
+	assert.True(t, Confirm("Continue?"))
+	assert.False(t, Confirm("Continue?"))
+	assert.False(t, Confirm("Continue?"))
+	assert.False(t, Confirm("Continue?"))
+	// No more input
+	assert.False(t, Confirm("Continue?"))
+}
+
+func TestPromptPasswordNotTerminal(t *testing.T) {
+	// When stdin is not a terminal a single line is read, without trimming spaces
+	restore, err := SetupStdin(" secret password \nnext\n")
+	require.Nil(t, err)
+	defer restore()
+
+	password, err := PromptPassword("Password: ")
+	require.Nil(t, err)
+	assert.Equal(t, " secret password ", password)
+}
+
+func TestPromptPasswordVisible(t *testing.T) {
+	// /dev/null is a character device that echo cannot be turned off for
+	devNull, err := os.Open(os.DevNull)
+	require.Nil(t, err)
+	defer devNull.Close()
+	original := os.Stdin
+	os.Stdin = devNull
+	defer func() { os.Stdin = original }()
+
+	out, err := NewOutputCapture()
+	require.Nil(t, err)
+	defer out.Close()
+
+	_, err = PromptPassword("Password: ")
+	assert.NotNil(t, err)
+	require.Nil(t, out.Rewind())
+	stderr, err := ioutil.ReadAll(out.Stderr)
+	require.Nil(t, err)
+	assert.Contains(t, string(stderr), "WARNING: cannot hide the password, it will be visible as it is typed: ")
+}
+
+func TestIsTerminal(t *testing.T) {
+	f, err := ioutil.TempFile("", "not-a-terminal-")
+	require.Nil(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+	assert.False(t, isTerminal(f))
+}
@@ -0,0 +1,268 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// packageSuffixes are the subpackage suffixes that are often mixed up
+var packageSuffixes = []string{"-devel", "-dev", "-libs", "-lib", "-common", "-tools", "-docs", "-doc"}
+
+// packageBase returns the package name without a subpackage suffix
+func packageBase(name string) string {
+	for _, s := range packageSuffixes {
+		if strings.HasSuffix(name, s) && len(name) > len(s) {
+			return strings.TrimSuffix(name, s)
+		}
+	}
+	return name
+}
+
+// SuggestNames returns up to max of the names that are close to name
+// Names are close when they only differ by a few edits, by a subpackage suffix
+// like -devel, or when one contains the other. They are sorted with the closest
+// first. Case is ignored.
+func SuggestNames(name string, names []string, max int) []string {
+	name = strings.ToLower(name)
+	limit := len(name) / 3
+	if limit < 1 {
+		limit = 1
+	} else if limit > 3 {
+		limit = 3
+	}
+
+	type suggestion struct {
+		name     string
+		distance int
+	}
+	var found []suggestion
+	for _, n := range names {
+		lower := strings.ToLower(n)
+		if lower == name {
+			continue
+		}
+		d := editDistance(name, lower)
+		if d > limit && packageBase(name) == packageBase(lower) {
+			d = limit
+		} else if d > limit {
+			// Substring matches sort after the near misses
+			if len(name) < 3 || !(strings.Contains(lower, name) || strings.Contains(name, lower)) {
+				continue
+			}
+			d = limit + 1 + abs(len(lower)-len(name))
+		}
+		found = append(found, suggestion{n, d})
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].distance != found[j].distance {
+			return found[i].distance < found[j].distance
+		}
+		return found[i].name < found[j].name
+	})
+
+	var result []string
+	for i := 0; i < len(found) && i < max; i++ {
+		result = append(result, found[i].name)
+	}
+	return result
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// depsolveErrorIDs are the ids of the errors returned when depsolving fails
+var depsolveErrorIDs = map[string]bool{"BlueprintsError": true, "ProjectsError": true, "DepsolveError": true}
+
+// missingPackagesRegex matches the list of packages that DNF could not find
+var missingPackagesRegex = regexp.MustCompile(`(?m)(?:missing packages|No match for argument|No match for):?\s*(.+)$`)
+
+// MissingPackages returns the names of the packages that a depsolve error could not find
+func MissingPackages(e weldr.APIErrorMsg) []string {
+	if !depsolveErrorIDs[e.ID] {
+		return nil
+	}
+	var names []string
+	for _, m := range missingPackagesRegex.FindAllStringSubmatch(e.Msg, -1) {
+		names = append(names, GetCommaArgs([]string{m[1]})...)
+	}
+	return names
+}
+
+// sourceURLs returns the base url of each of the server's sources
+func sourceURLs() map[string]string {
+	urls := make(map[string]string)
+	ids, resp, err := Client.ListSources()
+	if err != nil || resp != nil || len(ids) == 0 {
+		return urls
+	}
+	sources, _, err := Client.GetSourcesJSON(ids)
+	if err != nil {
+		return urls
+	}
+	for id, s := range sources {
+		if m, ok := s.(map[string]interface{}); ok {
+			if url, ok := m["url"].(string); ok && len(url) > 0 {
+				urls[id] = strings.TrimSuffix(url, "/") + "/"
+			}
+		}
+	}
+	return urls
+}
+
+// packageSources returns the ids of the sources that the package is downloaded from
+// The package's remote_location is matched against the sources' urls, so sources using
+// a metalink or mirrorlist cannot be found.
+func packageSources(name, distro string, urls map[string]string) []string {
+	deps, _, err := Client.DepsolveProjects([]string{name}, distro)
+	if err != nil {
+		return nil
+	}
+	var ids []string
+	for _, d := range deps {
+		m, ok := d.(map[string]interface{})
+		if !ok || m["name"] != name {
+			continue
+		}
+		location, _ := m["remote_location"].(string)
+		for id, url := range urls {
+			if strings.HasPrefix(location, url) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// blueprintsDistro returns the distribution that the blueprints are built for
+// It returns an empty string, for the server's default distribution, when they use
+// different distributions or cannot be read.
+func blueprintsDistro(names []string) string {
+	bps, _, err := Client.GetBlueprintsJSON(names)
+	if err != nil || len(bps) == 0 {
+		return ""
+	}
+	distros := make(map[string]bool)
+	for _, bp := range bps {
+		fields, ok := bp.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		distro, _ := fields["distro"].(string)
+		distros[distro] = true
+	}
+	if len(distros) != 1 {
+		return ""
+	}
+	for distro := range distros {
+		return distro
+	}
+	return ""
+}
+
+// PrintBlueprintSuggestions prints similar package names for the packages that depsolving
+// the blueprints could not find, using the blueprints' distribution.
+func PrintBlueprintSuggestions(errors []weldr.APIErrorMsg, names []string) {
+	if JSONOutput {
+		return
+	}
+	for _, e := range errors {
+		if len(MissingPackages(e)) > 0 {
+			PrintSuggestions(errors, blueprintsDistro(names))
+			return
+		}
+	}
+}
+
+// PrintSuggestions prints similar package names for the packages that depsolving could not find
+// They are printed to stderr, along with the sources that provide them.
+func PrintSuggestions(errors []weldr.APIErrorMsg, distro string) {
+	if JSONOutput {
+		return
+	}
+	var missing []string
+	seen := make(map[string]bool)
+	for _, e := range errors {
+		for _, name := range MissingPackages(e) {
+			if !seen[name] {
+				seen[name] = true
+				missing = append(missing, name)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	projects, resp, err := Client.ListProjects(distro)
+	if err != nil || resp != nil {
+		return
+	}
+	var names []string
+	for _, p := range projects {
+		names = append(names, p.Name)
+	}
+	var urls map[string]string
+	for _, name := range missing {
+		suggestions := SuggestNames(name, names, 3)
+		if len(suggestions) == 0 {
+			continue
+		}
+		if urls == nil {
+			urls = sourceURLs()
+		}
+		fmt.Fprintf(os.Stderr, "%s was not found, did you mean:\n", name)
+		for _, s := range suggestions {
+			if ids := packageSources(s, distro, urls); len(ids) > 0 {
+				fmt.Fprintf(os.Stderr, "    %s (%s)\n", s, strings.Join(ids, ", "))
+			} else {
+				fmt.Fprintf(os.Stderr, "    %s\n", s)
+			}
+		}
+	}
+}
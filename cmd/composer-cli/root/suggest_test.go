@@ -0,0 +1,47 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/osbuild/weldr-client/v2/weldr"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("tmux", "tmux"))
+	assert.Equal(t, 1, editDistance("tmux", "tmax"))
+	assert.Equal(t, 1, editDistance("vim-enchanced", "vim-enhanced"))
+	assert.Equal(t, 2, editDistance("bsah", "bash"))
+	assert.Equal(t, 4, editDistance("", "bash"))
+}
+
+func TestSuggestNames(t *testing.T) {
+	names := []string{"vim-enhanced", "vim-minimal", "vim-common", "tmux", "bash", "bash-completion", "nginx"}
+	assert.Equal(t, []string{"vim-enhanced"}, SuggestNames("vim-enchanced", names, 3))
+	assert.Equal(t, []string{"bash", "bash-completion"}, SuggestNames("bas", names, 2))
+	assert.Equal(t, []string{"tmux"}, SuggestNames("TMUX2", names, 3))
+	assert.Equal(t, []string{"vim-common", "vim-minimal", "vim-enhanced"}, SuggestNames("vim", names, 3))
+	assert.Nil(t, SuggestNames("httpd", names, 3))
+
+	// The development package is suggested for a name using another suffix
+	assert.Equal(t, []string{"openssl-devel"}, SuggestNames("openssl-dev", []string{"openssl", "openssl-devel", "openssh"}, 1))
+}
+
+func TestMissingPackages(t *testing.T) {
+	e := weldr.APIErrorMsg{
+		ID:  "BlueprintsError",
+		Msg: "cli-test-bp-1: DNF error occured: MarkingErrors: Error occurred when marking packages for installation: Problems in request:\nmissing packages: homer, vim-enchanced",
+	}
+	assert.Equal(t, []string{"homer", "vim-enchanced"}, MissingPackages(e))
+
+	e = weldr.APIErrorMsg{ID: "DepsolveError", Msg: "No match for argument: tmuxx"}
+	assert.Equal(t, []string{"tmuxx"}, MissingPackages(e))
+
+	e = weldr.APIErrorMsg{ID: "UnknownBlueprint", Msg: "missing packages: homer"}
+	assert.Nil(t, MissingPackages(e))
+}
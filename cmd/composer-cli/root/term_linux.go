@@ -0,0 +1,33 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// disableEcho turns off echo on the terminal
+// It returns a function that restores the original terminal settings, or an
+// error if f is not a terminal.
+func disableEcho(f *os.File) (func(), error) {
+	fd := f.Fd()
+	var original syscall.Termios
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(&original))); e != 0 {
+		return nil, e
+	}
+	noEcho := original
+	noEcho.Lflag &^= syscall.ECHO
+	noEcho.Lflag |= syscall.ICANON | syscall.ISIG
+	noEcho.Iflag |= syscall.ICRNL
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCSETS, uintptr(unsafe.Pointer(&noEcho))); e != 0 {
+		return nil, e
+	}
+	return func() {
+		//nolint:errcheck
+		syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCSETS, uintptr(unsafe.Pointer(&original)))
+	}, nil
+}
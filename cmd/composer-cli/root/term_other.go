@@ -0,0 +1,17 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+// +build !linux
+
+package root
+
+import (
+	"errors"
+	"os"
+)
+
+// disableEcho is only supported on Linux, PromptPassword warns that the input is visible
+func disableEcho(f *os.File) (func(), error) {
+	return nil, errors.New("not supported on this platform")
+}
@@ -178,3 +178,31 @@ func LogToFile(filename, message string) error {
 
 	return nil
 }
+
+// SetupStdin replaces os.Stdin with a temporary file containing input
+// It is used to answer prompts during testing. The returned function
+// restores the original os.Stdin and removes the temporary file.
+func SetupStdin(input string) (func(), error) {
+	f, err := ioutil.TempFile("", "stdin-input-")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write([]byte(input)); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	original := os.Stdin
+	os.Stdin = f
+
+	return func() {
+		os.Stdin = original
+		f.Close()
+		os.Remove(f.Name())
+	}, nil
+}
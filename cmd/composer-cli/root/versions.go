@@ -0,0 +1,40 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package root
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CompareVersions returns a description of each difference between two sets of versions
+// The keys are the names of the packages, the descriptions are sorted by name.
+func CompareVersions(saved, current map[string]string) []string {
+	var keys []string
+	for k := range saved {
+		keys = append(keys, k)
+	}
+	for k := range current {
+		if _, ok := saved[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []string
+	for _, k := range keys {
+		s, inSaved := saved[k]
+		c, inCurrent := current[k]
+		switch {
+		case !inCurrent:
+			changes = append(changes, fmt.Sprintf("removed %s %s", k, s))
+		case !inSaved:
+			changes = append(changes, fmt.Sprintf("added %s %s", k, c))
+		case s != c:
+			changes = append(changes, fmt.Sprintf("changed %s %s -> %s", k, s, c))
+		}
+	}
+	return changes
+}
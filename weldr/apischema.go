@@ -84,11 +84,12 @@ type PackageNEVRA struct {
 
 // String returns the package name, epoch, version and release as a string
 func (pkg PackageNEVRA) String() string {
-	if pkg.Epoch == 0 {
-		return fmt.Sprintf("%s-%s-%s.%s", pkg.Name, pkg.Version, pkg.Release, pkg.Arch)
-	}
+	return formatNEVRA(pkg.Name, uint(pkg.Epoch), pkg.Version, pkg.Release, pkg.Arch)
+}
 
-	return fmt.Sprintf("%s-%d:%s-%s.%s", pkg.Name, pkg.Epoch, pkg.Version, pkg.Release, pkg.Arch)
+// EVR returns the package's epoch, version and release as a string
+func (pkg PackageNEVRA) EVR() string {
+	return formatEVR(uint(pkg.Epoch), pkg.Version, pkg.Release)
 }
 
 // StatusV0 is the response to /api/status from a v0+ server
@@ -110,6 +111,20 @@ type BlueprintsListV0 struct {
 	Blueprints []string `json:"blueprints"`
 }
 
+// BlueprintChangedV0 reports whether the workspace copy of a blueprint differs from its last commit
+type BlueprintChangedV0 struct {
+	Name    string `json:"name"`
+	Changed bool   `json:"changed"`
+}
+
+// BlueprintDiffV0 is one difference between two versions of a blueprint
+// Old and New each hold a single field of the blueprint, eg. {"Package": {...}}.
+// Old is nil when the field was added, and New is nil when it was removed.
+type BlueprintDiffV0 struct {
+	Old map[string]interface{} `json:"old"`
+	New map[string]interface{} `json:"new"`
+}
+
 // BlueprintsChangesV0 is the response to /blueprints/changes/ request
 type BlueprintsChangesV0 struct {
 	Changes []BlueprintChanges `json:"blueprints"`
@@ -175,6 +190,7 @@ type infoBlueprint struct {
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	Version     string    `json:"version,omitempty"`
+	Distro      string    `json:"distro,omitempty"`
 	Packages    []Package `json:"packages"`
 	Modules     []Package `json:"modules"`
 	Groups      []Group   `json:"groups"`
@@ -182,13 +198,13 @@ type infoBlueprint struct {
 
 // A Package specifies an RPM package.
 type Package struct {
-	Name    string `json:"name" toml:"name"`
-	Version string `json:"version,omitempty" toml:"version,omitempty"`
+	Name    string `json:"name" toml:"name" yaml:"name"`
+	Version string `json:"version,omitempty" toml:"version,omitempty" yaml:"version,omitempty"`
 }
 
 // Group specifies a package group.
 type Group struct {
-	Name string `json:"name" toml:"name"`
+	Name string `json:"name" toml:"name" yaml:"name"`
 }
 
 // ComposeInfoV0 is the response to a compose/info request
@@ -259,13 +275,12 @@ type ProjectSourceV0 struct {
 
 // String returns the package name, epoch, version and release as a string
 func (p ProjectBuildV0) String() string {
-	if p.Epoch == 0 {
-		return fmt.Sprintf("%s-%s.%s at %s for %s",
-			p.Source.Version, p.Release, p.Arch, p.BuildTime, p.Changelog)
-	}
+	return fmt.Sprintf("%s.%s at %s for %s", p.EVR(), p.Arch, p.BuildTime, p.Changelog)
+}
 
-	return fmt.Sprintf("%d:%s-%s.%s at %s for %s",
-		p.Epoch, p.Source.Version, p.Release, p.Arch, p.BuildTime, p.Changelog)
+// EVR returns the build's epoch, version and release as a string
+func (p ProjectBuildV0) EVR() string {
+	return formatEVR(p.Epoch, p.Source.Version, p.Release)
 }
 
 // ProjectSpecV0 holds details about a project release
@@ -283,9 +298,5 @@ type ProjectSpecV0 struct {
 
 // String returns the package name, epoch, version and release as a string
 func (p ProjectSpecV0) String() string {
-	if p.Epoch == 0 {
-		return fmt.Sprintf("%s-%s-%s.%s", p.Name, p.Version, p.Release, p.Arch)
-	}
-
-	return fmt.Sprintf("%s-%d:%s-%s.%s", p.Name, p.Epoch, p.Version, p.Release, p.Arch)
+	return formatNEVRA(p.Name, p.Epoch, p.Version, p.Release, p.Arch)
 }
@@ -88,6 +88,55 @@ func (c Client) GetBlueprintsJSON(names []string) ([]interface{}, []APIErrorMsg,
 	return r.Blueprints, nil, nil
 }
 
+// GetBlueprintsInfoJSON returns the blueprints, whether they have uncommitted workspace changes, and errors
+// The blueprints include any changes in the workspace. It uses interface{} for the blueprints so that it
+// is not tightly coupled to the server's blueprint schema.
+func (c Client) GetBlueprintsInfoJSON(names []string) ([]interface{}, []BlueprintChangedV0, []APIErrorMsg, error) {
+	route := fmt.Sprintf("/blueprints/info/%s", strings.Join(names, ","))
+	j, resp, err := c.GetRaw("GET", route)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if resp != nil {
+		return nil, nil, resp.Errors, nil
+	}
+
+	var r struct {
+		Blueprints []interface{}
+		Changes    []BlueprintChangedV0
+		Errors     []APIErrorMsg
+	}
+	err = json.Unmarshal(j, &r)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("ERROR: %s", err.Error())
+	}
+	if len(r.Errors) > 0 {
+		return r.Blueprints, r.Changes, r.Errors, nil
+	}
+	return r.Blueprints, r.Changes, nil, nil
+}
+
+// GetBlueprintChangeJSON returns the blueprint as it was at a commit
+// It uses interface{} for the blueprint so that it is not tightly coupled to the server's blueprint
+// schema.
+func (c Client) GetBlueprintChangeJSON(name, commit string) (interface{}, []APIErrorMsg, error) {
+	route := fmt.Sprintf("/blueprints/change/%s/%s", name, commit)
+	j, resp, err := c.GetRaw("GET", route)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp != nil {
+		return nil, resp.Errors, nil
+	}
+
+	var bp interface{}
+	err = json.Unmarshal(j, &bp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ERROR: %s", err.Error())
+	}
+	return bp, nil, nil
+}
+
 // GetFrozenBlueprintsJSON returns the blueprints and errors
 // It uses interface{} for the blueprints so that it is not tightly coupled to the server's blueprint
 // schema.
@@ -143,6 +192,17 @@ func (c Client) PushBlueprintTOML(blueprint string) (*APIResponse, error) {
 	return resp, err
 }
 
+// PushBlueprintJSON pushes a JSON formatted blueprint as a new commit
+// When successful the response will have Status = true
+func (c Client) PushBlueprintJSON(blueprint string) (*APIResponse, error) {
+	body, resp, err := c.PostJSON("/blueprints/new", blueprint)
+	// body may contain a response with status and errors
+	if resp == nil && len(body) > 0 {
+		resp, _ = NewAPIResponse(body)
+	}
+	return resp, err
+}
+
 // PushBlueprintWorkspaceTOML pushes a TOML formatted blueprint to the temporary workspace
 // When successful the response will have Status = true
 func (c Client) PushBlueprintWorkspaceTOML(blueprint string) (*APIResponse, error) {
@@ -154,6 +214,36 @@ func (c Client) PushBlueprintWorkspaceTOML(blueprint string) (*APIResponse, erro
 	return resp, err
 }
 
+// DeleteBlueprintWorkspace discards the blueprint's uncommitted workspace changes
+// When successful the response will have Status = true
+func (c Client) DeleteBlueprintWorkspace(name string) (*APIResponse, error) {
+	route := fmt.Sprintf("/blueprints/workspace/%s", name)
+	_, resp, err := c.DeleteRaw(route)
+	return resp, err
+}
+
+// GetBlueprintDiff returns the differences between two versions of a blueprint
+// from is a commit hash or NEWEST, and to is a commit hash, NEWEST, or WORKSPACE
+func (c Client) GetBlueprintDiff(name, from, to string) ([]BlueprintDiffV0, []APIErrorMsg, error) {
+	route := fmt.Sprintf("/blueprints/diff/%s/%s/%s", name, from, to)
+	j, resp, err := c.GetRaw("GET", route)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp != nil {
+		return nil, resp.Errors, nil
+	}
+
+	var r struct {
+		Diff []BlueprintDiffV0
+	}
+	err = json.Unmarshal(j, &r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ERROR: %s", err.Error())
+	}
+	return r.Diff, nil, nil
+}
+
 // TagBlueprint tags the most recent blueprint commit as a release
 // When successful the response will have Status = true
 func (c Client) TagBlueprint(name string) (*APIResponse, error) {
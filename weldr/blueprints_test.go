@@ -55,6 +55,19 @@ func TestGetBlueprintsJSON(t *testing.T) {
 	assert.Equal(t, APIErrorMsg{"UnknownBlueprint", "unknown-cli-bp: "}, errors[0])
 }
 
+func TestGetBlueprintsInfoJSON(t *testing.T) {
+	blueprints, changes, errors, err := testState.client.GetBlueprintsInfoJSON([]string{"cli-test-bp-1", "unknown-cli-bp"})
+	require.Nil(t, err)
+	require.NotNil(t, errors)
+	require.Equal(t, 1, len(blueprints))
+	require.Equal(t, 1, len(changes))
+	name, ok := blueprints[0].(map[string]interface{})["name"].(string)
+	require.True(t, ok)
+	assert.Equal(t, "cli-test-bp-1", name)
+	assert.Equal(t, BlueprintChangedV0{Name: "cli-test-bp-1", Changed: false}, changes[0])
+	assert.Equal(t, APIErrorMsg{"UnknownBlueprint", "unknown-cli-bp: "}, errors[0])
+}
+
 func TestDeleteBlueprint(t *testing.T) {
 	r, err := testState.client.DeleteBlueprint("cli-test-bp-2")
 	require.Nil(t, err)
@@ -152,6 +165,71 @@ func TestPushBlueprintWorkspaceTOMLError(t *testing.T) {
 	assert.Equal(t, "BlueprintsError", r.Errors[0].ID)
 }
 
+func TestWorkspaceDiffDiscard(t *testing.T) {
+	bp := `
+		name="cli-test-bp-1"
+		description="workspace changes"
+		version="0.1.1"
+		[[packages]]
+		name="bash"
+		version="*"
+		`
+	r, err := testState.client.PushBlueprintWorkspaceTOML(bp)
+	require.Nil(t, err)
+	require.NotNil(t, r)
+	require.True(t, r.Status)
+
+	diff, errors, err := testState.client.GetBlueprintDiff("cli-test-bp-1", "NEWEST", "WORKSPACE")
+	require.Nil(t, err)
+	require.Nil(t, errors)
+	assert.Greater(t, len(diff), 0)
+
+	r, err = testState.client.DeleteBlueprintWorkspace("cli-test-bp-1")
+	require.Nil(t, err)
+	require.Nil(t, r)
+
+	_, changes, errors, err := testState.client.GetBlueprintsInfoJSON([]string{"cli-test-bp-1"})
+	require.Nil(t, err)
+	require.Nil(t, errors)
+	require.Equal(t, 1, len(changes))
+	assert.False(t, changes[0].Changed)
+}
+
+func TestGetBlueprintDiffUnknown(t *testing.T) {
+	_, errors, err := testState.client.GetBlueprintDiff("unknown-cli-bp", "NEWEST", "WORKSPACE")
+	require.Nil(t, err)
+	require.NotNil(t, errors)
+	assert.Equal(t, "UnknownBlueprint", errors[0].ID)
+}
+
+func TestPushBlueprintJSON(t *testing.T) {
+	bp := `{"name": "test-json-blueprint-v0", "description": "postJSONBlueprintV0", "version": "0.0.1",
+		"packages": [{"name": "bash", "version": "*"}]}`
+	r, err := testState.client.PushBlueprintJSON(bp)
+	require.Nil(t, err)
+	require.NotNil(t, r)
+	assert.True(t, r.Status)
+}
+
+func TestGetBlueprintChangeJSON(t *testing.T) {
+	changes, errors, err := testState.client.GetBlueprintsChanges([]string{"cli-test-bp-1"})
+	require.Nil(t, err)
+	require.Nil(t, errors)
+	require.Equal(t, 1, len(changes))
+	require.Greater(t, len(changes[0].Changes), 0)
+
+	bp, errors, err := testState.client.GetBlueprintChangeJSON("cli-test-bp-1", changes[0].Changes[0].Commit)
+	require.Nil(t, err)
+	require.Nil(t, errors)
+	name, ok := bp.(map[string]interface{})["name"].(string)
+	require.True(t, ok)
+	assert.Equal(t, "cli-test-bp-1", name)
+
+	_, errors, err = testState.client.GetBlueprintChangeJSON("cli-test-bp-1", "0123456789")
+	require.Nil(t, err)
+	require.NotNil(t, errors)
+}
+
 func TestTagBlueprint(t *testing.T) {
 	r, err := testState.client.TagBlueprint("cli-test-bp-1")
 	require.Nil(t, err)
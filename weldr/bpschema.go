@@ -0,0 +1,452 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package weldr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Blueprint is the client's copy of the server's blueprint schema
+// It is used by commands that need to read, modify, and write blueprints.
+// The order of the fields is the order they are written out in.
+type Blueprint struct {
+	Name           string          `json:"name" toml:"name" yaml:"name"`
+	Description    string          `json:"description" toml:"description" yaml:"description"`
+	Version        string          `json:"version,omitempty" toml:"version,omitempty" yaml:"version,omitempty"`
+	Distro         string          `json:"distro,omitempty" toml:"distro,omitempty" yaml:"distro,omitempty"`
+	Packages       []Package       `json:"packages" toml:"packages" yaml:"packages"`
+	Modules        []Package       `json:"modules" toml:"modules" yaml:"modules"`
+	Groups         []Group         `json:"groups" toml:"groups" yaml:"groups"`
+	Customizations *Customizations `json:"customizations,omitempty" toml:"customizations,omitempty" yaml:"customizations,omitempty"`
+
+	// Extra holds the fields that are not part of the client's schema, so that
+	// rewriting the blueprint does not drop them.
+	Extra map[string]interface{} `json:"-" toml:"-" yaml:"-"`
+}
+
+// Customizations holds the optional changes made to the image
+type Customizations struct {
+	Hostname           string                    `json:"hostname,omitempty" toml:"hostname,omitempty" yaml:"hostname,omitempty"`
+	Kernel             *KernelCustomization      `json:"kernel,omitempty" toml:"kernel,omitempty" yaml:"kernel,omitempty"`
+	SSHKey             []SSHKeyCustomization     `json:"sshkey,omitempty" toml:"sshkey,omitempty" yaml:"sshkey,omitempty"`
+	User               []UserCustomization       `json:"user,omitempty" toml:"user,omitempty" yaml:"user,omitempty"`
+	Group              []GroupCustomization      `json:"group,omitempty" toml:"group,omitempty" yaml:"group,omitempty"`
+	Timezone           *TimezoneCustomization    `json:"timezone,omitempty" toml:"timezone,omitempty" yaml:"timezone,omitempty"`
+	Locale             *LocaleCustomization      `json:"locale,omitempty" toml:"locale,omitempty" yaml:"locale,omitempty"`
+	Firewall           *FirewallCustomization    `json:"firewall,omitempty" toml:"firewall,omitempty" yaml:"firewall,omitempty"`
+	Services           *ServicesCustomization    `json:"services,omitempty" toml:"services,omitempty" yaml:"services,omitempty"`
+	Filesystem         []FilesystemCustomization `json:"filesystem,omitempty" toml:"filesystem,omitempty" yaml:"filesystem,omitempty"`
+	InstallationDevice string                    `json:"installation_device,omitempty" toml:"installation_device,omitempty" yaml:"installation_device,omitempty"`
+}
+
+// KernelCustomization sets the kernel package and its cmdline arguments
+type KernelCustomization struct {
+	Name   string `json:"name,omitempty" toml:"name,omitempty" yaml:"name,omitempty"`
+	Append string `json:"append" toml:"append" yaml:"append"`
+}
+
+// SSHKeyCustomization sets the ssh key for an existing user
+type SSHKeyCustomization struct {
+	User string `json:"user" toml:"user" yaml:"user"`
+	Key  string `json:"key" toml:"key" yaml:"key"`
+}
+
+// UserCustomization creates a new user
+type UserCustomization struct {
+	Name        string   `json:"name" toml:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" toml:"description,omitempty" yaml:"description,omitempty"`
+	Password    string   `json:"password,omitempty" toml:"password,omitempty" yaml:"password,omitempty"`
+	Key         string   `json:"key,omitempty" toml:"key,omitempty" yaml:"key,omitempty"`
+	Home        string   `json:"home,omitempty" toml:"home,omitempty" yaml:"home,omitempty"`
+	Shell       string   `json:"shell,omitempty" toml:"shell,omitempty" yaml:"shell,omitempty"`
+	Groups      []string `json:"groups,omitempty" toml:"groups,omitempty" yaml:"groups,omitempty"`
+	UID         *int     `json:"uid,omitempty" toml:"uid,omitempty" yaml:"uid,omitempty"`
+	GID         *int     `json:"gid,omitempty" toml:"gid,omitempty" yaml:"gid,omitempty"`
+}
+
+// GroupCustomization creates a new group
+type GroupCustomization struct {
+	Name string `json:"name" toml:"name" yaml:"name"`
+	GID  *int   `json:"gid,omitempty" toml:"gid,omitempty" yaml:"gid,omitempty"`
+}
+
+// TimezoneCustomization sets the timezone and the NTP servers
+type TimezoneCustomization struct {
+	Timezone   string   `json:"timezone,omitempty" toml:"timezone,omitempty" yaml:"timezone,omitempty"`
+	NTPServers []string `json:"ntpservers,omitempty" toml:"ntpservers,omitempty" yaml:"ntpservers,omitempty"`
+}
+
+// LocaleCustomization sets the languages and the keyboard layout
+type LocaleCustomization struct {
+	Languages []string `json:"languages,omitempty" toml:"languages,omitempty" yaml:"languages,omitempty"`
+	Keyboard  string   `json:"keyboard,omitempty" toml:"keyboard,omitempty" yaml:"keyboard,omitempty"`
+}
+
+// FirewallCustomization opens ports and enables or disables firewalld services
+type FirewallCustomization struct {
+	Ports    []string                       `json:"ports,omitempty" toml:"ports,omitempty" yaml:"ports,omitempty"`
+	Services *FirewallServicesCustomization `json:"services,omitempty" toml:"services,omitempty" yaml:"services,omitempty"`
+}
+
+// FirewallServicesCustomization lists the firewalld services to enable and disable
+type FirewallServicesCustomization struct {
+	Enabled  []string `json:"enabled,omitempty" toml:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Disabled []string `json:"disabled,omitempty" toml:"disabled,omitempty" yaml:"disabled,omitempty"`
+}
+
+// ServicesCustomization lists the systemd services to enable and disable
+type ServicesCustomization struct {
+	Enabled  []string `json:"enabled,omitempty" toml:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Disabled []string `json:"disabled,omitempty" toml:"disabled,omitempty" yaml:"disabled,omitempty"`
+}
+
+// FilesystemCustomization sets the minimum size of a mountpoint
+// MinSize is a uint64 number of bytes, or a string with a unit like "20 GiB".
+type FilesystemCustomization struct {
+	Mountpoint string      `json:"mountpoint" toml:"mountpoint" yaml:"mountpoint"`
+	MinSize    interface{} `json:"minsize,omitempty" toml:"minsize,omitempty" yaml:"minsize,omitempty"`
+}
+
+// NewBlueprintFromTOML parses a TOML blueprint
+// Fields that are not part of the schema are kept in Extra, so that rewriting the
+// blueprint never silently drops any of them.
+func NewBlueprintFromTOML(data string) (Blueprint, error) {
+	var bp Blueprint
+	if _, err := toml.Decode(data, &bp); err != nil {
+		return bp, err
+	}
+	raw, err := decodeTOMLMap(data)
+	if err != nil {
+		return bp, err
+	}
+	bp.afterDecode(raw)
+	return bp, nil
+}
+
+// TOML returns the blueprint as a TOML string
+func (bp Blueprint) TOML() (string, error) {
+	data := new(bytes.Buffer)
+	if err := toml.NewEncoder(data).Encode(bp); err != nil {
+		return "", err
+	}
+	return bp.withUnknown(bp, data.String(), encodeTOMLMap)
+}
+
+// Canonical returns a copy of the blueprint in the canonical order
+// The packages, modules, and groups are sorted by name. Everything else is
+// written in the order of the schema.
+func (bp Blueprint) Canonical() Blueprint {
+	bp.Extra = sortUnknownLists(bp)
+	bp.Packages = sortedPackages(bp.Packages)
+	bp.Modules = sortedPackages(bp.Modules)
+	if bp.Groups != nil {
+		groups := append([]Group{}, bp.Groups...)
+		sort.SliceStable(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+		bp.Groups = groups
+	}
+	return bp
+}
+
+// SameContent returns true if the blueprints are the same, ignoring their versions
+// The order of the packages, modules, and groups, and empty lists and sections are
+// not significant. A package version of "" is the same as "*".
+func (bp Blueprint) SameContent(other Blueprint) bool {
+	a, err := bp.normalized().JSON()
+	if err != nil {
+		return false
+	}
+	b, err := other.normalized().JSON()
+	if err != nil {
+		return false
+	}
+	return a == b
+}
+
+// normalized returns a copy of the blueprint for comparing its content
+func (bp Blueprint) normalized() Blueprint {
+	bp = bp.Canonical().withEmptyLists()
+	bp.Version = ""
+	for _, pkgs := range [][]Package{bp.Packages, bp.Modules} {
+		for i := range pkgs {
+			if pkgs[i].Version == "" {
+				pkgs[i].Version = "*"
+			}
+		}
+	}
+	if bp.Customizations != nil && reflect.DeepEqual(*bp.Customizations, Customizations{}) {
+		bp.Customizations = nil
+	}
+	return bp
+}
+
+// sortedPackages returns a copy of the packages sorted by name
+func sortedPackages(pkgs []Package) []Package {
+	if pkgs == nil {
+		return nil
+	}
+	sorted := append([]Package{}, pkgs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// BlueprintFormats lists the formats that blueprints can be converted between
+var BlueprintFormats = []string{"toml", "json", "yaml"}
+
+// NewBlueprintFromJSON parses a JSON blueprint
+// Like NewBlueprintFromTOML it keeps the fields that are not part of the schema in Extra.
+func NewBlueprintFromJSON(data string) (Blueprint, error) {
+	var bp Blueprint
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&bp); err != nil {
+		return bp, err
+	}
+	raw, err := decodeJSONMap(data)
+	if err != nil {
+		return bp, err
+	}
+	bp.afterDecode(raw)
+	return bp, nil
+}
+
+// NewBlueprintFromYAML parses a YAML blueprint
+// Like NewBlueprintFromTOML it keeps the fields that are not part of the schema in Extra.
+func NewBlueprintFromYAML(data string) (Blueprint, error) {
+	var bp Blueprint
+	if err := yaml.Unmarshal([]byte(data), &bp); err != nil {
+		return bp, err
+	}
+	raw, err := decodeYAMLMap(data)
+	if err != nil {
+		return bp, err
+	}
+	bp.afterDecode(raw)
+	return bp, nil
+}
+
+// NewBlueprint parses a blueprint in one of the BlueprintFormats
+func NewBlueprint(data, format string) (Blueprint, error) {
+	switch format {
+	case "toml":
+		return NewBlueprintFromTOML(data)
+	case "json":
+		return NewBlueprintFromJSON(data)
+	case "yaml":
+		return NewBlueprintFromYAML(data)
+	}
+	return Blueprint{}, fmt.Errorf("unknown blueprint format: %s", format)
+}
+
+// withEmptyLists returns a copy of the blueprint with empty lists instead of nil
+// JSON and YAML write nil lists as null, the server always returns empty lists.
+func (bp Blueprint) withEmptyLists() Blueprint {
+	if bp.Packages == nil {
+		bp.Packages = []Package{}
+	}
+	if bp.Modules == nil {
+		bp.Modules = []Package{}
+	}
+	if bp.Groups == nil {
+		bp.Groups = []Group{}
+	}
+	return bp
+}
+
+// JSON returns the blueprint as an indented JSON string
+func (bp Blueprint) JSON() (string, error) {
+	known := bp.withEmptyLists()
+	data, err := json.MarshalIndent(known, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return bp.withUnknown(known, string(data)+"\n", encodeJSONMap)
+}
+
+// YAML returns the blueprint as a YAML string
+func (bp Blueprint) YAML() (string, error) {
+	known := bp.withEmptyLists()
+	data := new(bytes.Buffer)
+	enc := yaml.NewEncoder(data)
+	enc.SetIndent(2)
+	if err := enc.Encode(known); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return bp.withUnknown(known, data.String(), encodeYAMLMap)
+}
+
+// Format returns the blueprint as a string in one of the BlueprintFormats
+func (bp Blueprint) Format(format string) (string, error) {
+	switch format {
+	case "toml":
+		return bp.TOML()
+	case "json":
+		return bp.JSON()
+	case "yaml":
+		return bp.YAML()
+	}
+	return "", fmt.Errorf("unknown blueprint format: %s", format)
+}
+
+// BumpVersion returns the next semantic version
+// part is one of major, minor, or patch. An empty version is treated as 0.0.0
+func BumpVersion(version, part string) (string, error) {
+	v, err := parseVersion(version)
+	if err != nil {
+		return "", err
+	}
+
+	switch part {
+	case "major":
+		v = [3]int{v[0] + 1, 0, 0}
+	case "minor":
+		v = [3]int{v[0], v[1] + 1, 0}
+	case "patch":
+		v[2]++
+	default:
+		return "", fmt.Errorf("unknown version part: %s", part)
+	}
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2]), nil
+}
+
+// NewerVersion returns true if version is greater than the other version
+// An empty version is never newer. Versions that are not semantic versions are
+// only compared for equality.
+func NewerVersion(version, other string) bool {
+	if len(version) == 0 {
+		return false
+	}
+	v, err := parseVersion(version)
+	if err != nil {
+		return version != other
+	}
+	o, err := parseVersion(other)
+	if err != nil {
+		return version != other
+	}
+	for i := range v {
+		if v[i] != o[i] {
+			return v[i] > o[i]
+		}
+	}
+	return false
+}
+
+// parseVersion splits a semantic version into its major, minor, and patch numbers
+// An empty version is treated as 0.0.0
+func parseVersion(version string) ([3]int, error) {
+	var v [3]int
+	if len(version) == 0 {
+		return v, nil
+	}
+	fields := strings.Split(version, ".")
+	if len(fields) != 3 {
+		return v, fmt.Errorf("%s is not a semantic version", version)
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("%s is not a semantic version", version)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// AutoBumpPart returns the part of the version to bump for the changes from old to new
+// Adding packages, modules, groups, or customizations is a minor change. Anything
+// else, eg. pinning a package version or removing a package, is a patch.
+func AutoBumpPart(old, new Blueprint) string {
+	if addedNames(packageNames(old), packageNames(new)) || addedCustomizations(old.Customizations, new.Customizations) {
+		return "minor"
+	}
+	return "patch"
+}
+
+// packageNames returns the names of the packages, modules, and groups
+// Groups are prefixed with @ so they cannot match a package with the same name.
+func packageNames(bp Blueprint) []string {
+	var names []string
+	for _, p := range append(append([]Package{}, bp.Packages...), bp.Modules...) {
+		names = append(names, p.Name)
+	}
+	for _, g := range bp.Groups {
+		names = append(names, "@"+g.Name)
+	}
+	return names
+}
+
+// addedNames returns true if new has any names that are not in old
+func addedNames(old, new []string) bool {
+	seen := make(map[string]bool, len(old))
+	for _, n := range old {
+		seen[n] = true
+	}
+	for _, n := range new {
+		if !seen[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// addedCustomizations returns true if new has sections, or list entries, that are not in old
+// Changes to the values of existing customizations are not counted as additions.
+func addedCustomizations(old, new *Customizations) bool {
+	oldMap, err := customizationsMap(old)
+	if err != nil {
+		return false
+	}
+	newMap, err := customizationsMap(new)
+	if err != nil {
+		return false
+	}
+	return addedValues(oldMap, newMap)
+}
+
+// addedValues returns true if new has map keys, or more list entries, than old
+func addedValues(old, new interface{}) bool {
+	switch n := new.(type) {
+	case map[string]interface{}:
+		o, ok := old.(map[string]interface{})
+		if !ok {
+			return true
+		}
+		for k, v := range n {
+			if _, ok := o[k]; !ok || addedValues(o[k], v) {
+				return true
+			}
+		}
+	case []interface{}:
+		o, ok := old.([]interface{})
+		return !ok || len(n) > len(o)
+	}
+	return false
+}
+
+// customizationsMap returns the customizations as a map of the sections that are set
+func customizationsMap(c *Customizations) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if c == nil {
+		return m, nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
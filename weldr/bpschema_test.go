@@ -0,0 +1,489 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package weldr
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBlueprintFromTOML(t *testing.T) {
+	bp, err := NewBlueprintFromTOML(`name = "simple"
+description = "simple blueprint"
+version = "0.1.0"
+groups = []
+modules = []
+
+[[packages]]
+name = "bash"
+version = "*"
+
+[customizations]
+hostname = "simple-host"
+
+[[customizations.user]]
+name = "admin"
+groups = ["wheel"]
+uid = 0
+
+[customizations.services]
+enabled = ["sshd"]
+`)
+	require.Nil(t, err)
+	assert.Equal(t, "simple", bp.Name)
+	assert.Equal(t, "0.1.0", bp.Version)
+	assert.Equal(t, []Package{{Name: "bash", Version: "*"}}, bp.Packages)
+	require.NotNil(t, bp.Customizations)
+	assert.Equal(t, "simple-host", bp.Customizations.Hostname)
+	require.Equal(t, 1, len(bp.Customizations.User))
+	require.NotNil(t, bp.Customizations.User[0].UID)
+	assert.Equal(t, 0, *bp.Customizations.User[0].UID)
+	assert.Equal(t, []string{"sshd"}, bp.Customizations.Services.Enabled)
+}
+
+func TestNewBlueprintFromTOMLUnknown(t *testing.T) {
+	bp, err := NewBlueprintFromTOML(`name = "simple"
+description = "simple blueprint"
+future = true
+
+[customizations]
+future = "yes"
+
+[[customizations.user]]
+name = "admin"
+future = 1
+
+[customizations.openscap]
+profile_id = "cis"
+`)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"customizations.future", "customizations.openscap", "customizations.user.future", "future"}, bp.UnknownFields())
+
+	// The unknown fields are kept when it is written out, in any format
+	for _, format := range BlueprintFormats {
+		data, err := bp.Format(format)
+		require.Nil(t, err, format)
+		bp2, err := NewBlueprint(data, format)
+		require.Nil(t, err, format)
+		assert.Equal(t, bp.UnknownFields(), bp2.UnknownFields(), format)
+		assert.Contains(t, data, "cis", format)
+		assert.True(t, bp.SameContent(bp2), format)
+	}
+
+	// Changing an unknown field changes the content
+	bp2, err := NewBlueprintFromTOML(`name = "simple"
+description = "simple blueprint"
+future = false
+`)
+	require.Nil(t, err)
+	assert.False(t, bp.SameContent(bp2))
+}
+
+func TestBlueprintUnknownCanonical(t *testing.T) {
+	bp, err := NewBlueprintFromJSON(`{"name": "simple", "packages": [
+		{"name": "tmux", "version": "*", "future": "t"},
+		{"name": "bash", "version": "*"}]}`)
+	require.Nil(t, err)
+	data, err := bp.Canonical().JSON()
+	require.Nil(t, err)
+	// The known fields are in the schema's order, followed by the unknown fields
+	assert.Equal(t, `{
+    "name": "simple",
+    "description": "",
+    "packages": [
+        {
+            "name": "bash",
+            "version": "*"
+        },
+        {
+            "name": "tmux",
+            "version": "*",
+            "future": "t"
+        }
+    ],
+    "modules": [],
+    "groups": []
+}
+`, data)
+}
+
+func TestBlueprintUnknownOrder(t *testing.T) {
+	bp, err := NewBlueprintFromTOML(`version = "0.0.1"
+zfuture = true
+description = "fdo blueprint"
+name = "fdo"
+
+[customizations]
+hostname = "fdo.example.com"
+
+[customizations.fdo]
+manufacturing_server_url = "https://fdo.example.com"
+diun_pub_key_insecure = "true"
+
+[[packages]]
+name = "tmux"
+version = "*"
+`)
+	require.Nil(t, err)
+
+	data, err := bp.Canonical().TOML()
+	require.Nil(t, err)
+	assert.Equal(t, `name = "fdo"
+description = "fdo blueprint"
+version = "0.0.1"
+zfuture = true
+
+[[packages]]
+  name = "tmux"
+  version = "*"
+
+[customizations]
+  hostname = "fdo.example.com"
+  [customizations.fdo]
+    diun_pub_key_insecure = "true"
+    manufacturing_server_url = "https://fdo.example.com"
+`, data)
+
+	data, err = bp.Canonical().YAML()
+	require.Nil(t, err)
+	assert.Equal(t, `name: fdo
+description: fdo blueprint
+version: 0.0.1
+packages:
+  - name: tmux
+    version: '*'
+modules: []
+groups: []
+customizations:
+  hostname: fdo.example.com
+  fdo:
+    diun_pub_key_insecure: "true"
+    manufacturing_server_url: https://fdo.example.com
+zfuture: true
+`, data)
+}
+
+func TestFormatDocument(t *testing.T) {
+	var doc interface{}
+	require.Nil(t, json.Unmarshal([]byte(`{"name": "simple", "version": "0.0.1",
+		"customizations": {"user": [{"name": "admin", "uid": "1010", "gid": 1010}]}}`), &doc))
+	_, err := NewBlueprintFromJSON(`{"name": "simple", "customizations": {"user": [{"name": "admin", "uid": "1010"}]}}`)
+	require.NotNil(t, err)
+
+	data, err := FormatDocument(doc, "toml")
+	require.Nil(t, err)
+	assert.Equal(t, `name = "simple"
+version = "0.0.1"
+
+[customizations]
+
+  [[customizations.user]]
+    gid = 1010
+    name = "admin"
+    uid = "1010"
+`, data)
+	data, err = FormatDocument(doc, "yaml")
+	require.Nil(t, err)
+	assert.Contains(t, data, "    - gid: 1010\n")
+	_, err = FormatDocument(doc, "xml")
+	assert.NotNil(t, err)
+}
+
+func TestNewBlueprintMinSize(t *testing.T) {
+	bp, err := NewBlueprintFromTOML(`name = "simple"
+[[customizations.filesystem]]
+mountpoint = "/var"
+minsize = "20 GiB"
+
+[[customizations.filesystem]]
+mountpoint = "/home"
+minsize = 2147483648
+`)
+	require.Nil(t, err)
+	assert.Nil(t, bp.UnknownFields())
+	expected := []FilesystemCustomization{
+		{Mountpoint: "/var", MinSize: "20 GiB"},
+		{Mountpoint: "/home", MinSize: uint64(2147483648)},
+	}
+	assert.Equal(t, expected, bp.Customizations.Filesystem)
+
+	for _, format := range BlueprintFormats {
+		data, err := bp.Format(format)
+		require.Nil(t, err, format)
+		bp2, err := NewBlueprint(data, format)
+		require.Nil(t, err, format)
+		assert.Equal(t, expected, bp2.Customizations.Filesystem, format)
+	}
+}
+
+func TestNewBlueprintFromTOMLError(t *testing.T) {
+	_, err := NewBlueprintFromTOML(`name = "simple"
+[[packages]
+name = "bash"
+`)
+	assert.NotNil(t, err)
+}
+
+func TestBlueprintTOML(t *testing.T) {
+	uid := 1001
+	bp := Blueprint{
+		Name:        "simple",
+		Description: "simple blueprint",
+		Version:     "0.1.0",
+		Packages:    []Package{{Name: "bash", Version: "*"}},
+		Groups:      []Group{{Name: "core"}},
+		Customizations: &Customizations{
+			Kernel: &KernelCustomization{Append: "nosmt=force"},
+			User:   []UserCustomization{{Name: "admin", UID: &uid}},
+		},
+	}
+	data, err := bp.TOML()
+	require.Nil(t, err)
+	assert.Equal(t, `name = "simple"
+description = "simple blueprint"
+version = "0.1.0"
+
+[[packages]]
+  name = "bash"
+  version = "*"
+
+[[groups]]
+  name = "core"
+
+[customizations]
+  [customizations.kernel]
+    append = "nosmt=force"
+
+  [[customizations.user]]
+    name = "admin"
+    uid = 1001
+`, data)
+
+	// It should read back the same
+	bp2, err := NewBlueprintFromTOML(data)
+	require.Nil(t, err)
+	assert.Equal(t, bp, bp2)
+}
+
+func TestBlueprintFormats(t *testing.T) {
+	uid := 1001
+	bp := Blueprint{
+		Name:        "simple",
+		Description: "simple blueprint",
+		Version:     "0.1.0",
+		Packages:    []Package{{Name: "bash", Version: "*"}},
+		Modules:     []Package{},
+		Groups:      []Group{},
+		Customizations: &Customizations{
+			Timezone:   &TimezoneCustomization{Timezone: "UTC", NTPServers: []string{"0.pool.ntp.org"}},
+			User:       []UserCustomization{{Name: "admin", UID: &uid}},
+			Filesystem: []FilesystemCustomization{{Mountpoint: "/var", MinSize: uint64(2147483648)}},
+		},
+	}
+
+	// Every format should read back the same
+	for _, format := range BlueprintFormats {
+		data, err := bp.Format(format)
+		require.Nil(t, err, format)
+		bp2, err := NewBlueprint(data, format)
+		require.Nil(t, err, format)
+		assert.Equal(t, bp, bp2, format)
+	}
+
+	_, err := bp.Format("xml")
+	assert.NotNil(t, err)
+	_, err = NewBlueprint("", "xml")
+	assert.NotNil(t, err)
+}
+
+func TestBlueprintYAML(t *testing.T) {
+	bp := Blueprint{
+		Name:        "simple",
+		Description: "simple blueprint",
+		Packages:    []Package{{Name: "bash", Version: "*"}},
+		Customizations: &Customizations{
+			Filesystem: []FilesystemCustomization{{Mountpoint: "/var", MinSize: uint64(1024)}},
+		},
+	}
+	data, err := bp.YAML()
+	require.Nil(t, err)
+	assert.Equal(t, `name: simple
+description: simple blueprint
+packages:
+  - name: bash
+    version: '*'
+modules: []
+groups: []
+customizations:
+  filesystem:
+    - mountpoint: /var
+      minsize: 1024
+`, data)
+}
+
+func TestBlueprintJSONEmptyLists(t *testing.T) {
+	data, err := Blueprint{Name: "empty"}.JSON()
+	require.Nil(t, err)
+	assert.Equal(t, `{
+    "name": "empty",
+    "description": "",
+    "packages": [],
+    "modules": [],
+    "groups": []
+}
+`, data)
+}
+
+func TestNewBlueprintUnknownFields(t *testing.T) {
+	bp, err := NewBlueprintFromJSON(`{"name": "simple", "customizations": {"hostname": "x", "unknown": true}}`)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"customizations.unknown"}, bp.UnknownFields())
+	bp, err = NewBlueprintFromYAML("name: simple\ncustomizations:\n  hostname: x\n  unknown: true\n")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"customizations.unknown"}, bp.UnknownFields())
+}
+
+func TestBumpVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		part     string
+		expected string
+	}{
+		{"", "patch", "0.0.1"},
+		{"0.1.0", "patch", "0.1.1"},
+		{"0.1.9", "patch", "0.1.10"},
+		{"0.1.3", "minor", "0.2.0"},
+		{"1.2.3", "major", "2.0.0"},
+	}
+	for _, tt := range tests {
+		v, err := BumpVersion(tt.version, tt.part)
+		require.Nil(t, err)
+		assert.Equal(t, tt.expected, v)
+	}
+
+	_, err := BumpVersion("1.2", "patch")
+	assert.NotNil(t, err)
+	_, err = BumpVersion("1.2.x", "patch")
+	assert.NotNil(t, err)
+	_, err = BumpVersion("1.2.3", "tiny")
+	assert.NotNil(t, err)
+}
+
+func TestNewerVersion(t *testing.T) {
+	assert.True(t, NewerVersion("0.1.0", "0.0.2"))
+	assert.True(t, NewerVersion("0.0.10", "0.0.9"))
+	assert.True(t, NewerVersion("1.0.0", ""))
+	assert.False(t, NewerVersion("0.0.1", "0.0.2"))
+	assert.False(t, NewerVersion("0.0.2", "0.0.2"))
+	assert.False(t, NewerVersion("", "0.0.2"))
+	assert.True(t, NewerVersion("1.x", "0.0.2"))
+	assert.False(t, NewerVersion("1.x", "1.x"))
+}
+
+func TestBlueprintCanonical(t *testing.T) {
+	bp := Blueprint{
+		Name:     "simple",
+		Packages: []Package{{Name: "tmux", Version: "*"}, {Name: "bash", Version: "*"}, {Name: "openssh-server"}},
+		Modules:  []Package{{Name: "zsh"}, {Name: "curl"}},
+		Groups:   []Group{{Name: "core"}, {Name: "base"}},
+	}
+	canonical := bp.Canonical()
+	assert.Equal(t, []Package{{Name: "bash", Version: "*"}, {Name: "openssh-server"}, {Name: "tmux", Version: "*"}}, canonical.Packages)
+	assert.Equal(t, []Package{{Name: "curl"}, {Name: "zsh"}}, canonical.Modules)
+	assert.Equal(t, []Group{{Name: "base"}, {Name: "core"}}, canonical.Groups)
+
+	// The original is not changed
+	assert.Equal(t, "tmux", bp.Packages[0].Name)
+	assert.Equal(t, "core", bp.Groups[0].Name)
+
+	// Empty lists are left alone
+	assert.Nil(t, Blueprint{Name: "empty"}.Canonical().Packages)
+}
+
+func TestBlueprintSameContent(t *testing.T) {
+	bp := Blueprint{
+		Name:     "simple",
+		Version:  "0.1.0",
+		Packages: []Package{{Name: "tmux", Version: "*"}, {Name: "bash"}},
+	}
+	other := Blueprint{
+		Name:           "simple",
+		Version:        "0.1.1",
+		Packages:       []Package{{Name: "bash", Version: "*"}, {Name: "tmux", Version: "*"}},
+		Modules:        []Package{},
+		Customizations: &Customizations{},
+	}
+	assert.True(t, bp.SameContent(other))
+	// Comparing does not change the packages
+	assert.Equal(t, "", bp.Packages[1].Version)
+
+	other.Packages[0].Version = "5.1.*"
+	assert.False(t, bp.SameContent(other))
+	other.Packages[0].Version = "*"
+	other.Customizations.Hostname = "simple"
+	assert.False(t, bp.SameContent(other))
+}
+
+func TestAutoBumpPart(t *testing.T) {
+	old := Blueprint{
+		Name:     "simple",
+		Packages: []Package{{Name: "bash", Version: "*"}, {Name: "tmux", Version: "*"}},
+		Groups:   []Group{{Name: "core"}},
+		Customizations: &Customizations{
+			Hostname: "server",
+			User:     []UserCustomization{{Name: "admin"}},
+			Services: &ServicesCustomization{Enabled: []string{"sshd"}},
+		},
+	}
+	tests := []struct {
+		change   func(bp *Blueprint)
+		expected string
+	}{
+		{func(bp *Blueprint) {}, "patch"},
+		{func(bp *Blueprint) { bp.Packages[0].Version = "5.1.*" }, "patch"},
+		{func(bp *Blueprint) { bp.Packages = bp.Packages[:1] }, "patch"},
+		{func(bp *Blueprint) { bp.Customizations.Hostname = "client" }, "patch"},
+		{func(bp *Blueprint) { bp.Packages = append(bp.Packages, Package{Name: "vim"}) }, "minor"},
+		{func(bp *Blueprint) { bp.Modules = []Package{{Name: "nodejs"}} }, "minor"},
+		{func(bp *Blueprint) { bp.Groups = append(bp.Groups, Group{Name: "base"}) }, "minor"},
+		{func(bp *Blueprint) { bp.Customizations.Kernel = &KernelCustomization{Append: "nosmt"} }, "minor"},
+		{func(bp *Blueprint) {
+			bp.Customizations.User = append(bp.Customizations.User, UserCustomization{Name: "bcl"})
+		}, "minor"},
+		{func(bp *Blueprint) { bp.Customizations.Services.Disabled = []string{"cups"} }, "minor"},
+	}
+	for i, tt := range tests {
+		// Make a deep copy of the old blueprint to change
+		data, err := old.JSON()
+		require.Nil(t, err)
+		bp, err := NewBlueprintFromJSON(data)
+		require.Nil(t, err)
+		tt.change(&bp)
+		assert.Equal(t, tt.expected, AutoBumpPart(old, bp), i)
+	}
+
+	// Adding the first customizations is a minor change
+	assert.Equal(t, "minor", AutoBumpPart(Blueprint{Name: "simple"}, old))
+}
+
+func TestBlueprintUnknownKeyFallback(t *testing.T) {
+	// A key that cannot be a struct field is still written, in map order
+	bp, err := NewBlueprintFromJSON(`{"name": "odd", "description": "", "a,b": 1}`)
+	require.Nil(t, err)
+	data, err := bp.JSON()
+	require.Nil(t, err)
+	assert.Equal(t, `{
+    "a,b": 1,
+    "description": "",
+    "groups": [],
+    "modules": [],
+    "name": "odd",
+    "packages": []
+}
+`, data)
+}
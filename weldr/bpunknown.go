@@ -0,0 +1,501 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package weldr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// unknownMap holds the unknown fields of a known section of the blueprint
+type unknownMap map[string]interface{}
+
+// unknownList holds the unknown fields of each entry of a known list, nil if there are none
+type unknownList []interface{}
+
+// normalizeValue returns a decoded value using the same types for every format
+// Lists of tables are returned as lists, and JSON numbers as int64 or float64.
+func normalizeValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, e := range value {
+			m[k] = normalizeValue(e)
+		}
+		return m
+	case []map[string]interface{}:
+		l := make([]interface{}, len(value))
+		for i, e := range value {
+			l[i] = normalizeValue(e)
+		}
+		return l
+	case []interface{}:
+		l := make([]interface{}, len(value))
+		for i, e := range value {
+			l[i] = normalizeValue(e)
+		}
+		return l
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+		return value.String()
+	}
+	return v
+}
+
+// fieldType returns the type of the struct's field with the name used by the blueprint formats
+func fieldType(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag == name && tag != "-" {
+			return t.Field(i).Type, true
+		}
+	}
+	return nil, false
+}
+
+// unknownFields returns the parts of the decoded value that are not fields of the type
+// Fields that are not part of the schema are returned unchanged, the known sections
+// that have unknown fields inside of them are returned as an unknownMap or unknownList.
+func unknownFields(raw interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch value := raw.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		unknown := make(unknownMap)
+		for k, e := range value {
+			ft, ok := fieldType(t, k)
+			if !ok {
+				unknown[k] = e
+			} else if u := unknownFields(e, ft); u != nil {
+				unknown[k] = u
+			}
+		}
+		if len(unknown) == 0 {
+			return nil
+		}
+		return unknown
+	case []interface{}:
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+		unknown := make(unknownList, len(value))
+		found := false
+		for i, e := range value {
+			if u := unknownFields(e, t.Elem()); u != nil {
+				unknown[i] = u
+				found = true
+			}
+		}
+		if !found {
+			return nil
+		}
+		return unknown
+	}
+	return nil
+}
+
+// orderedTable is a decoded table that keeps the order of its keys
+type orderedTable struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// decodeOrderedJSON decodes a JSON value, with its objects as orderedTables
+// Numbers are returned as int64 or float64, like normalizeValue.
+func decodeOrderedJSON(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			table := &orderedTable{values: make(map[string]interface{})}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeOrderedJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				table.keys = append(table.keys, key.(string))
+				table.values[key.(string)] = value
+			}
+			_, err = dec.Token()
+			return table, err
+		case '[':
+			list := []interface{}{}
+			for dec.More() {
+				value, err := decodeOrderedJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			_, err = dec.Token()
+			return list, err
+		}
+	}
+	return normalizeValue(tok), nil
+}
+
+// mergeUnknown adds the unknown fields to a decoded blueprint
+// The unknown fields are added after the known fields of their table, sorted by name.
+// The unknown fields of a list's entries are only added if the list still has the
+// same number of entries, otherwise they cannot be matched up with them.
+func mergeUnknown(dst, unknown interface{}) interface{} {
+	switch u := unknown.(type) {
+	case unknownMap:
+		d, ok := dst.(*orderedTable)
+		if !ok {
+			d = &orderedTable{values: make(map[string]interface{})}
+		}
+		var keys []string
+		for k := range u {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if _, ok := d.values[k]; !ok {
+				d.keys = append(d.keys, k)
+			}
+			d.values[k] = mergeUnknown(d.values[k], u[k])
+		}
+		return d
+	case unknownList:
+		d, ok := dst.([]interface{})
+		if !ok || len(d) != len(u) {
+			return dst
+		}
+		for i, e := range u {
+			if e != nil {
+				d[i] = mergeUnknown(d[i], e)
+			}
+		}
+		return d
+	}
+	return unknown
+}
+
+// validFieldKey returns true if the key can be used as a struct tag by every encoder
+// These are the characters encoding/json allows in a tag's name, without the comma.
+func validFieldKey(key string) bool {
+	if len(key) == 0 {
+		return false
+	}
+	for _, c := range key {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case unicode.IsLetter(c) || unicode.IsDigit(c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// orderedValue returns the decoded value with its tables as structs, so that the
+// encoders write their keys in order. It returns false if a key cannot be a field name.
+func orderedValue(v interface{}) (interface{}, bool) {
+	switch value := v.(type) {
+	case *orderedTable:
+		fields := make([]reflect.StructField, len(value.keys))
+		for i, k := range value.keys {
+			tag := k
+			if k == "-" {
+				tag = "-,"
+			}
+			if !validFieldKey(k) {
+				return nil, false
+			}
+			fields[i] = reflect.StructField{
+				Name: fmt.Sprintf("F%d", i),
+				Type: interfaceType,
+				Tag:  reflect.StructTag(fmt.Sprintf(`json:"%s" toml:"%s" yaml:"%s"`, tag, tag, tag)),
+			}
+		}
+		table := reflect.New(reflect.StructOf(fields)).Elem()
+		for i, k := range value.keys {
+			e, ok := orderedValue(value.values[k])
+			if !ok {
+				return nil, false
+			}
+			if e != nil {
+				table.Field(i).Set(reflect.ValueOf(e))
+			}
+		}
+		return table.Interface(), true
+	case []interface{}:
+		l := make([]interface{}, len(value))
+		for i, e := range value {
+			var ok bool
+			if l[i], ok = orderedValue(e); !ok {
+				return nil, false
+			}
+		}
+		return l, true
+	}
+	return v, true
+}
+
+// unorderedValue returns the decoded value with its tables as maps
+func unorderedValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case *orderedTable:
+		m := make(map[string]interface{}, len(value.keys))
+		for _, k := range value.keys {
+			m[k] = unorderedValue(value.values[k])
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(value))
+		for i, e := range value {
+			l[i] = unorderedValue(e)
+		}
+		return l
+	}
+	return v
+}
+
+// unknownPaths returns the dotted paths of the unknown fields
+func unknownPaths(prefix string, unknown interface{}) []string {
+	var paths []string
+	switch u := unknown.(type) {
+	case unknownMap:
+		for k, e := range u {
+			paths = append(paths, unknownPaths(prefix+k+".", e)...)
+		}
+	case unknownList:
+		for _, e := range u {
+			paths = append(paths, unknownPaths(prefix, e)...)
+		}
+	default:
+		paths = append(paths, strings.TrimSuffix(prefix, "."))
+	}
+	return paths
+}
+
+// UnknownFields returns the sorted dotted paths of the fields that are not part of the schema
+func (bp Blueprint) UnknownFields() []string {
+	if len(bp.Extra) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	for _, p := range unknownPaths("", unknownMap(bp.Extra)) {
+		if !seen[p] {
+			paths = append(paths, p)
+			seen[p] = true
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// sortUnknownLists returns the unknown fields with the entries of the packages, modules,
+// and groups lists sorted the same way as Canonical sorts the lists.
+func sortUnknownLists(bp Blueprint) map[string]interface{} {
+	if len(bp.Extra) == 0 {
+		return bp.Extra
+	}
+	lists := map[string][]string{}
+	for _, p := range bp.Packages {
+		lists["packages"] = append(lists["packages"], p.Name)
+	}
+	for _, m := range bp.Modules {
+		lists["modules"] = append(lists["modules"], m.Name)
+	}
+	for _, g := range bp.Groups {
+		lists["groups"] = append(lists["groups"], g.Name)
+	}
+
+	extra := make(map[string]interface{}, len(bp.Extra))
+	for k, e := range bp.Extra {
+		extra[k] = e
+	}
+	for key, names := range lists {
+		unknown, ok := extra[key].(unknownList)
+		if !ok || len(unknown) != len(names) {
+			continue
+		}
+		order := make([]int, len(names))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool { return names[order[i]] < names[order[j]] })
+		sorted := make(unknownList, len(unknown))
+		for i, o := range order {
+			sorted[i] = unknown[o]
+		}
+		extra[key] = sorted
+	}
+	return extra
+}
+
+// normalizeSize returns a filesystem size as a uint64 number of bytes, or as a string
+func normalizeSize(size interface{}) interface{} {
+	switch s := normalizeValue(size).(type) {
+	case int:
+		return uint64(s)
+	case int64:
+		return uint64(s)
+	case uint64:
+		return s
+	case float64:
+		if s == math.Trunc(s) {
+			return uint64(s)
+		}
+	}
+	return size
+}
+
+// afterDecode keeps the unknown fields of the decoded blueprint and normalizes its sizes
+func (bp *Blueprint) afterDecode(raw interface{}) {
+	if unknown, ok := unknownFields(normalizeValue(raw), reflect.TypeOf(*bp)).(unknownMap); ok {
+		bp.Extra = unknown
+	}
+	if bp.Customizations != nil {
+		for i := range bp.Customizations.Filesystem {
+			bp.Customizations.Filesystem[i].MinSize = normalizeSize(bp.Customizations.Filesystem[i].MinSize)
+		}
+	}
+}
+
+// withUnknown returns the encoded blueprint with its unknown fields added back
+// known is the value that was encoded as data. The known fields are written in the
+// schema's order, followed by the unknown fields of each table sorted by name. If an
+// unknown key cannot be written that way the tables are written in the encoder's
+// map order.
+func (bp Blueprint) withUnknown(known interface{}, data string, encode func(interface{}) (string, error)) (string, error) {
+	if len(bp.Extra) == 0 {
+		return data, nil
+	}
+	j, err := json.Marshal(known)
+	if err != nil {
+		return "", err
+	}
+	dec := json.NewDecoder(bytes.NewReader(j))
+	dec.UseNumber()
+	doc, err := decodeOrderedJSON(dec)
+	if err != nil {
+		return "", err
+	}
+	doc = mergeUnknown(doc, unknownMap(bp.Extra))
+	if ordered, ok := orderedValue(doc); ok {
+		return encode(ordered)
+	}
+	return encode(unorderedValue(doc))
+}
+
+func decodeTOMLMap(data string) (interface{}, error) {
+	var raw map[string]interface{}
+	_, err := toml.Decode(data, &raw)
+	return raw, err
+}
+
+func encodeTOMLMap(v interface{}) (string, error) {
+	data := new(bytes.Buffer)
+	if err := toml.NewEncoder(data).Encode(v); err != nil {
+		return "", err
+	}
+	return data.String(), nil
+}
+
+func decodeJSONMap(data string) (interface{}, error) {
+	var raw interface{}
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	err := dec.Decode(&raw)
+	return raw, err
+}
+
+func encodeJSONMap(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+func decodeYAMLMap(data string) (interface{}, error) {
+	var raw interface{}
+	err := yaml.Unmarshal([]byte(data), &raw)
+	return raw, err
+}
+
+func encodeYAMLMap(v interface{}) (string, error) {
+	data := new(bytes.Buffer)
+	enc := yaml.NewEncoder(data)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return data.String(), nil
+}
+
+// integralNumbers returns the decoded JSON value with the numbers that have no fraction as int64
+func integralNumbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, e := range value {
+			m[k] = integralNumbers(e)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(value))
+		for i, e := range value {
+			l[i] = integralNumbers(e)
+		}
+		return l
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < math.MaxInt64 {
+			return int64(value)
+		}
+	}
+	return v
+}
+
+// FormatDocument returns a blueprint document decoded from JSON in the format
+// It is for blueprints that cannot be converted to the schema, they are written with
+// the same fields and values, in the encoder's map order.
+func FormatDocument(doc interface{}, format string) (string, error) {
+	doc = integralNumbers(normalizeValue(doc))
+	switch format {
+	case "toml":
+		return encodeTOMLMap(doc)
+	case "json":
+		return encodeJSONMap(doc)
+	case "yaml":
+		return encodeYAMLMap(doc)
+	}
+	return "", fmt.Errorf("unknown blueprint format: %s", format)
+}
@@ -0,0 +1,272 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package weldr
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rpmArches are the architectures that can end a NEVRA
+var rpmArches = map[string]bool{
+	"noarch": true, "x86_64": true, "i386": true, "i486": true, "i586": true, "i686": true,
+	"aarch64": true, "armv7hl": true, "armv7hnl": true, "ppc64": true, "ppc64le": true,
+	"s390": true, "s390x": true, "riscv64": true, "src": true,
+}
+
+// ParseNEVRA parses a package string in the format output by rpm -qa
+// It accepts name-[epoch:]version-release.arch and epoch:name-version-release.arch, the arch
+// is optional. The version must start with a digit to tell it apart from a dashed package name.
+func ParseNEVRA(s string) (PackageNEVRA, error) {
+	var p PackageNEVRA
+	s = strings.TrimSuffix(s, ".rpm")
+
+	if i := strings.LastIndex(s, "."); i > 0 && rpmArches[s[i+1:]] {
+		p.Arch = s[i+1:]
+		s = s[:i]
+	}
+	i := strings.LastIndex(s, "-")
+	if i <= 0 {
+		return PackageNEVRA{}, fmt.Errorf("missing release")
+	}
+	p.Release = s[i+1:]
+	s = s[:i]
+	i = strings.LastIndex(s, "-")
+	if i <= 0 {
+		return PackageNEVRA{}, fmt.Errorf("missing version")
+	}
+	p.Version = s[i+1:]
+	p.Name = s[:i]
+
+	// The epoch may prefix the version or the name
+	epoch := ""
+	if e := strings.Index(p.Version, ":"); e >= 0 {
+		epoch, p.Version = p.Version[:e], p.Version[e+1:]
+	} else if e := strings.Index(p.Name, ":"); e >= 0 {
+		epoch, p.Name = p.Name[:e], p.Name[e+1:]
+	}
+	if len(epoch) > 0 {
+		n, err := strconv.Atoi(epoch)
+		if err != nil || n < 0 {
+			return PackageNEVRA{}, fmt.Errorf("invalid epoch: %s", epoch)
+		}
+		p.Epoch = n
+	}
+	if len(p.Name) == 0 || len(p.Version) == 0 || len(p.Release) == 0 {
+		return PackageNEVRA{}, fmt.Errorf("missing name, version, or release")
+	}
+	if p.Version[0] < '0' || p.Version[0] > '9' {
+		return PackageNEVRA{}, fmt.Errorf("version does not start with a digit: %s", p.Version)
+	}
+	return p, nil
+}
+
+// formatEVR returns [epoch:]version-release, the epoch is left out when it is 0
+func formatEVR(epoch uint, version, release string) string {
+	if epoch == 0 {
+		return fmt.Sprintf("%s-%s", version, release)
+	}
+	return fmt.Sprintf("%d:%s-%s", epoch, version, release)
+}
+
+// formatNEVRA returns name-[epoch:]version-release.arch, the arch is left out when it is empty
+func formatNEVRA(name string, epoch uint, version, release, arch string) string {
+	if len(arch) == 0 {
+		return fmt.Sprintf("%s-%s", name, formatEVR(epoch, version, release))
+	}
+	return fmt.Sprintf("%s-%s.%s", name, formatEVR(epoch, version, release), arch)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// RpmVerCmp compares two version, or release, strings the same way as rpm
+// It returns -1 if a is older than b, 0 if they are the same, and 1 if a is newer.
+// The strings are split into segments of digits or letters and compared segment by
+// segment, numbers are newer than letters and ~ sorts before anything, even the end
+// of the string, and ^ sorts after the end of the string but before anything else.
+func RpmVerCmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		// Skip the separators
+		for i < len(a) && !isDigit(a[i]) && !isAlpha(a[i]) && a[i] != '~' && a[i] != '^' {
+			i++
+		}
+		for j < len(b) && !isDigit(b[j]) && !isAlpha(b[j]) && b[j] != '~' && b[j] != '^' {
+			j++
+		}
+
+		// A tilde sorts before everything else
+		if (i < len(a) && a[i] == '~') || (j < len(b) && b[j] == '~') {
+			if i >= len(a) || a[i] != '~' {
+				return 1
+			}
+			if j >= len(b) || b[j] != '~' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		// A caret sorts after the end of the string, but before anything else
+		if (i < len(a) && a[i] == '^') || (j < len(b) && b[j] == '^') {
+			if i >= len(a) {
+				return -1
+			}
+			if j >= len(b) {
+				return 1
+			}
+			if a[i] != '^' {
+				return 1
+			}
+			if b[j] != '^' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		if i >= len(a) || j >= len(b) {
+			break
+		}
+
+		// Grab the next segment, the type is set by the segment from a
+		si, sj := i, j
+		isNum := isDigit(a[i])
+		if isNum {
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+		} else {
+			for i < len(a) && isAlpha(a[i]) {
+				i++
+			}
+			for j < len(b) && isAlpha(b[j]) {
+				j++
+			}
+		}
+		segA, segB := a[si:i], b[sj:j]
+
+		// The segments are of different types, numbers are newer
+		if len(segB) == 0 {
+			if isNum {
+				return 1
+			}
+			return -1
+		}
+
+		if isNum {
+			segA = strings.TrimLeft(segA, "0")
+			segB = strings.TrimLeft(segB, "0")
+			if len(segA) > len(segB) {
+				return 1
+			}
+			if len(segB) > len(segA) {
+				return -1
+			}
+		}
+		if c := strings.Compare(segA, segB); c != 0 {
+			return c
+		}
+	}
+
+	// Whichever has characters left is newer
+	if i >= len(a) && j >= len(b) {
+		return 0
+	}
+	if i >= len(a) {
+		return -1
+	}
+	return 1
+}
+
+// CompareEVR compares the epoch, version and release of two packages
+// It returns -1 if a is older than b, 0 if they are the same, and 1 if a is newer.
+func CompareEVR(a, b PackageNEVRA) int {
+	if a.Epoch != b.Epoch {
+		if a.Epoch < b.Epoch {
+			return -1
+		}
+		return 1
+	}
+	if c := RpmVerCmp(a.Version, b.Version); c != 0 {
+		return c
+	}
+	return RpmVerCmp(a.Release, b.Release)
+}
+
+// SortNEVRAs sorts the packages by name, then from the oldest to the newest build
+func SortNEVRAs(pkgs []PackageNEVRA) {
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		if pkgs[i].Name != pkgs[j].Name {
+			return pkgs[i].Name < pkgs[j].Name
+		}
+		if c := CompareEVR(pkgs[i], pkgs[j]); c != 0 {
+			return c < 0
+		}
+		return pkgs[i].Arch < pkgs[j].Arch
+	})
+}
+
+// NewestNEVRA returns the newest of the packages, ignoring their names
+// It returns false if there are no packages.
+func NewestNEVRA(pkgs []PackageNEVRA) (PackageNEVRA, bool) {
+	if len(pkgs) == 0 {
+		return PackageNEVRA{}, false
+	}
+	newest := pkgs[0]
+	for _, p := range pkgs[1:] {
+		if CompareEVR(p, newest) > 0 {
+			newest = p
+		}
+	}
+	return newest, true
+}
+
+// NEVRA returns the build of the named project as a PackageNEVRA
+func (p ProjectBuildV0) NEVRA(name string) PackageNEVRA {
+	return PackageNEVRA{Name: name, Epoch: int(p.Epoch), Version: p.Source.Version, Release: p.Release, Arch: p.Arch}
+}
+
+// SortBuilds sorts the builds from the oldest to the newest
+func SortBuilds(builds []ProjectBuildV0) {
+	sort.SliceStable(builds, func(i, j int) bool {
+		if c := CompareEVR(builds[i].NEVRA(""), builds[j].NEVRA("")); c != 0 {
+			return c < 0
+		}
+		return builds[i].Arch < builds[j].Arch
+	})
+}
+
+// NewestBuild returns the newest of the builds
+// It returns false if there are no builds.
+func NewestBuild(builds []ProjectBuildV0) (ProjectBuildV0, bool) {
+	if len(builds) == 0 {
+		return ProjectBuildV0{}, false
+	}
+	newest := builds[0]
+	for _, b := range builds[1:] {
+		if CompareEVR(b.NEVRA(""), newest.NEVRA("")) > 0 {
+			newest = b
+		}
+	}
+	return newest, true
+}
@@ -0,0 +1,207 @@
+// Copyright 2021 by Red Hat, Inc. All rights reserved.
+// Use of this source is goverend by the Apache License
+// that can be found in the LICENSE file.
+
+package weldr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNEVRA(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected PackageNEVRA
+	}{
+		{"bash-5.1.8-2.fc35.x86_64", PackageNEVRA{Name: "bash", Version: "5.1.8", Release: "2.fc35", Arch: "x86_64"}},
+		{"python3-dnf-plugins-core-4.0.24-1.fc35.noarch", PackageNEVRA{Name: "python3-dnf-plugins-core", Version: "4.0.24", Release: "1.fc35", Arch: "noarch"}},
+		{"shadow-utils-2:4.9-8.fc35.x86_64", PackageNEVRA{Name: "shadow-utils", Epoch: 2, Version: "4.9", Release: "8.fc35", Arch: "x86_64"}},
+		{"2:shadow-utils-4.9-8.fc35.x86_64", PackageNEVRA{Name: "shadow-utils", Epoch: 2, Version: "4.9", Release: "8.fc35", Arch: "x86_64"}},
+		{"tzdata-2021e-1.fc35", PackageNEVRA{Name: "tzdata", Version: "2021e", Release: "1.fc35"}},
+		{"acl-2.3.1-2.fc35.x86_64.rpm", PackageNEVRA{Name: "acl", Version: "2.3.1", Release: "2.fc35", Arch: "x86_64"}},
+	}
+	for _, tt := range tests {
+		p, err := ParseNEVRA(tt.s)
+		require.Nil(t, err, tt.s)
+		assert.Equal(t, tt.expected, p)
+	}
+
+	for _, s := range []string{"bash", "python3-libs", "python3-dnf-plugins-core", "a-1:b-c", "-1.0-1"} {
+		_, err := ParseNEVRA(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestNEVRAString(t *testing.T) {
+	p := PackageNEVRA{Name: "shadow-utils", Epoch: 2, Version: "4.9", Release: "8.fc35", Arch: "x86_64"}
+	assert.Equal(t, "shadow-utils-2:4.9-8.fc35.x86_64", p.String())
+	assert.Equal(t, "2:4.9-8.fc35", p.EVR())
+	p = PackageNEVRA{Name: "tzdata", Version: "2021e", Release: "1.fc35"}
+	assert.Equal(t, "tzdata-2021e-1.fc35", p.String())
+
+	spec := ProjectSpecV0{Name: "shadow-utils", Epoch: 2, Version: "4.9", Release: "8.fc35", Arch: "x86_64"}
+	assert.Equal(t, "shadow-utils-2:4.9-8.fc35.x86_64", spec.String())
+
+	for _, s := range []string{"bash-5.1.8-2.fc35.x86_64", "shadow-utils-2:4.9-8.fc35.x86_64"} {
+		p, err := ParseNEVRA(s)
+		require.Nil(t, err)
+		assert.Equal(t, s, p.String())
+	}
+}
+
+func TestRpmVerCmp(t *testing.T) {
+	// These are from the rpmvercmp tests in rpm's test suite
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "2.0", -1},
+		{"2.0", "1.0", 1},
+		{"2.0.1", "2.0.1", 0},
+		{"2.0", "2.0.1", -1},
+		{"2.0.1", "2.0", 1},
+		{"2.0.1a", "2.0.1a", 0},
+		{"2.0.1a", "2.0.1", 1},
+		{"2.0.1", "2.0.1a", -1},
+		{"5.5p1", "5.5p1", 0},
+		{"5.5p1", "5.5p2", -1},
+		{"5.5p2", "5.5p1", 1},
+		{"5.5p10", "5.5p10", 0},
+		{"5.5p1", "5.5p10", -1},
+		{"5.5p10", "5.5p1", 1},
+		{"10xyz", "10.1xyz", -1},
+		{"10.1xyz", "10xyz", 1},
+		{"xyz10", "xyz10", 0},
+		{"xyz10", "xyz10.1", -1},
+		{"xyz10.1", "xyz10", 1},
+		{"xyz.4", "xyz.4", 0},
+		{"xyz.4", "8", -1},
+		{"8", "xyz.4", 1},
+		{"xyz.4", "2", -1},
+		{"2", "xyz.4", 1},
+		{"5.5p2", "5.6p1", -1},
+		{"5.6p1", "5.5p2", 1},
+		{"5.6p1", "6.5p1", -1},
+		{"6.5p1", "5.6p1", 1},
+		{"6.0.rc1", "6.0", 1},
+		{"6.0", "6.0.rc1", -1},
+		{"10b2", "10a1", 1},
+		{"10a2", "10b2", -1},
+		{"1.0aa", "1.0aa", 0},
+		{"1.0a", "1.0aa", -1},
+		{"1.0aa", "1.0a", 1},
+		{"10.0001", "10.0001", 0},
+		{"10.0001", "10.1", 0},
+		{"10.1", "10.0001", 0},
+		{"10.0001", "10.0039", -1},
+		{"10.0039", "10.0001", 1},
+		{"4.999.9", "5.0", -1},
+		{"5.0", "4.999.9", 1},
+		{"20101121", "20101121", 0},
+		{"20101121", "20101122", -1},
+		{"20101122", "20101121", 1},
+		{"2_0", "2_0", 0},
+		{"2.0", "2_0", 0},
+		{"2_0", "2.0", 0},
+		{"a", "a", 0},
+		{"a+", "a+", 0},
+		{"a+", "a_", 0},
+		{"a_", "a+", 0},
+		{"+a", "+a", 0},
+		{"+a", "_a", 0},
+		{"_a", "+a", 0},
+		{"+_", "+_", 0},
+		{"_+", "+_", 0},
+		{"_+", "_+", 0},
+		{"+", "_", 0},
+		{"_", "+", 0},
+		{"1.0~rc1", "1.0~rc1", 0},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0", "1.0~rc1", 1},
+		{"1.0~rc1", "1.0~rc2", -1},
+		{"1.0~rc2", "1.0~rc1", 1},
+		{"1.0~rc1~git123", "1.0~rc1~git123", 0},
+		{"1.0~rc1~git123", "1.0~rc1", -1},
+		{"1.0~rc1", "1.0~rc1~git123", 1},
+		{"1.0^", "1.0^", 0},
+		{"1.0^", "1.0", 1},
+		{"1.0", "1.0^", -1},
+		{"1.0^git1", "1.0^git1", 0},
+		{"1.0^git1", "1.0", 1},
+		{"1.0", "1.0^git1", -1},
+		{"1.0^git1", "1.0^git2", -1},
+		{"1.0^git2", "1.0^git1", 1},
+		{"1.0^git1", "1.01", -1},
+		{"1.01", "1.0^git1", 1},
+		{"1.0^20160101", "1.0^20160101", 0},
+		{"1.0^20160101", "1.0.1", -1},
+		{"1.0.1", "1.0^20160101", 1},
+		{"1.0^20160101^git1", "1.0^20160101^git1", 0},
+		{"1.0^20160102", "1.0^20160101^git1", 1},
+		{"1.0^20160101^git1", "1.0^20160102", -1},
+		{"1.0~rc1^git1", "1.0~rc1^git1", 0},
+		{"1.0~rc1^git1", "1.0~rc1", 1},
+		{"1.0~rc1", "1.0~rc1^git1", -1},
+		{"1.0^git1~pre", "1.0^git1~pre", 0},
+		{"1.0^git1", "1.0^git1~pre", 1},
+		{"1.0^git1~pre", "1.0^git1", -1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, RpmVerCmp(tt.a, tt.b), "%s <=> %s", tt.a, tt.b)
+	}
+}
+
+func TestCompareEVR(t *testing.T) {
+	a := PackageNEVRA{Name: "bash", Epoch: 1, Version: "1.0", Release: "1"}
+	b := PackageNEVRA{Name: "bash", Epoch: 0, Version: "2.0", Release: "1"}
+	assert.Equal(t, 1, CompareEVR(a, b))
+	assert.Equal(t, -1, CompareEVR(b, a))
+
+	a = PackageNEVRA{Name: "bash", Version: "5.1.8", Release: "10.fc35"}
+	b = PackageNEVRA{Name: "bash", Version: "5.1.8", Release: "9.fc35"}
+	assert.Equal(t, 1, CompareEVR(a, b))
+	assert.Equal(t, 0, CompareEVR(a, a))
+}
+
+func TestSortNEVRAs(t *testing.T) {
+	pkgs := []PackageNEVRA{
+		{Name: "tmux", Version: "3.10", Release: "1", Arch: "x86_64"},
+		{Name: "bash", Version: "5.1.8", Release: "10", Arch: "x86_64"},
+		{Name: "tmux", Version: "3.9", Release: "1", Arch: "x86_64"},
+		{Name: "bash", Version: "5.1.8", Release: "9", Arch: "x86_64"},
+	}
+	SortNEVRAs(pkgs)
+	var names []string
+	for _, p := range pkgs {
+		names = append(names, p.String())
+	}
+	assert.Equal(t, []string{"bash-5.1.8-9.x86_64", "bash-5.1.8-10.x86_64", "tmux-3.9-1.x86_64", "tmux-3.10-1.x86_64"}, names)
+
+	newest, ok := NewestNEVRA(pkgs[2:])
+	require.True(t, ok)
+	assert.Equal(t, "tmux-3.10-1.x86_64", newest.String())
+	_, ok = NewestNEVRA(nil)
+	assert.False(t, ok)
+}
+
+func TestSortBuilds(t *testing.T) {
+	builds := []ProjectBuildV0{
+		{Epoch: 0, Release: "1.fc34", Source: ProjectSourceV0{Version: "5.1.10"}},
+		{Epoch: 1, Release: "1.fc34", Source: ProjectSourceV0{Version: "5.0"}},
+		{Epoch: 0, Release: "1.fc34", Source: ProjectSourceV0{Version: "5.1.9"}},
+	}
+	newest, ok := NewestBuild(builds)
+	require.True(t, ok)
+	assert.Equal(t, "1:5.0-1.fc34", newest.EVR())
+
+	SortBuilds(builds)
+	var versions []string
+	for _, b := range builds {
+		versions = append(versions, b.EVR())
+	}
+	assert.Equal(t, []string{"5.1.9-1.fc34", "5.1.10-1.fc34", "1:5.0-1.fc34"}, versions)
+}